@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/geektype/dependy/domain"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -82,15 +86,15 @@ func (g *GitManager) CloneRepo(repo domain.Repository) error {
 	return nil
 }
 
-func (g *GitManager) BranchMain() error {
-	slog.Debug(fmt.Sprintf("Creating %s branch from %s", g.Config.PatchBranchPrefix, g.MainBranch))
+func (g *GitManager) BranchMain(branch string) error {
+	slog.Debug(fmt.Sprintf("Creating %s branch from %s", branch, g.MainBranch))
 
 	headRef, err := g.Repository.Head()
 	if err != nil {
 		return err
 	}
 
-	branchRefName := plumbing.NewBranchReferenceName(g.Config.PatchBranchPrefix)
+	branchRefName := plumbing.NewBranchReferenceName(branch)
 	branchHashRef := plumbing.NewHashReference(branchRefName, headRef.Hash())
 
 	err = g.Repository.Storer.SetReference(branchHashRef)
@@ -100,7 +104,7 @@ func (g *GitManager) BranchMain() error {
 
 	// Checkout DependyBranch
 
-	slog.Debug(fmt.Sprintf("Checking out %s", g.Config.PatchBranchPrefix))
+	slog.Debug(fmt.Sprintf("Checking out %s", branch))
 
 	if err := g.WorkTree.Checkout(&git.CheckoutOptions{
 		Branch: branchRefName,
@@ -118,15 +122,24 @@ func (g *GitManager) OpenFile(fileName string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	buffer := make([]byte, 50000)
+	return io.ReadAll(f)
+}
 
-	i, err := f.Read(buffer)
-	if err != nil {
-		return nil, err
-	}
+// Call fn with the path of every regular file in the work tree
+func (g *GitManager) WalkFiles(fn func(path string) error) error {
+	return util.Walk(g.FileSystem, "/", func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 
-	return buffer[:i], nil
+		if info.IsDir() {
+			return nil
+		}
+
+		return fn(strings.TrimPrefix(path, "/"))
+	})
 }
 
 func (g *GitManager) OverwriteFile(filename string, content []byte) error {
@@ -136,24 +149,28 @@ func (g *GitManager) OverwriteFile(filename string, content []byte) error {
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	_, err = f.Write(content)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	return nil
 }
 
-func (g *GitManager) CommitFile(filename string, content []byte) error {
-	err := g.OverwriteFile(filename, content)
-	if err != nil {
-		return err
-	}
-
-	_, err = g.WorkTree.Add(filename)
-	if err != nil {
-		return err
+// Overwrite the given files and commit them together
+func (g *GitManager) CommitFiles(files map[string][]byte) error {
+	for filename, content := range files {
+		err := g.OverwriteFile(filename, content)
+		if err != nil {
+			return err
+		}
+
+		_, err = g.WorkTree.Add(filename)
+		if err != nil {
+			return err
+		}
 	}
 
 	commitOptions := &git.CommitOptions{
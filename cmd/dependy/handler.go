@@ -8,19 +8,93 @@ import (
 	"github.com/geektype/dependy/domain"
 	"github.com/geektype/dependy/policy"
 	"github.com/geektype/dependy/remote"
+	"github.com/geektype/dependy/schedule"
+	"github.com/geektype/dependy/source"
 	"github.com/spf13/viper"
 )
 
-func newPolicy(config domain.GlobalConfig) (domain.Policy, error) {
-	switch config.DefaultPolicy {
+func newPolicy(name string, security domain.SecurityPolicy) (domain.Policy, error) {
+	switch name {
 	case "simple":
 		return policy.SimpleUpdatePolicy{}, nil
+	case "security":
+		if security == nil {
+			return nil, errors.New("config for Security not found")
+		}
+
+		return security, nil
 	case "":
 		slog.Warn("Policy not defined in config, defaulting to SimplePolicy")
 		return policy.SimpleUpdatePolicy{}, nil
 	default:
-		return nil, fmt.Errorf("policy %s not found", config.DefaultPolicy)
+		return nil, fmt.Errorf("policy %s not found", name)
+	}
+}
+
+// Security updates are raised alongside routine updates whenever the
+// security policy is configured
+func newSecurityPolicy() (domain.SecurityPolicy, error) {
+	c := viper.Sub("Security")
+	if c == nil {
+		return nil, nil
+	}
+
+	var security policy.SecurityConfig
+
+	err := c.Unmarshal(&security)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.NewSecurityUpdatePolicy(security)
+}
+
+// License gate is optional and only enabled when configured
+func newLicenseGate() (*policy.LicenseGate, error) {
+	c := viper.Sub("License")
+	if c == nil {
+		return nil, nil
+	}
+
+	var license policy.LicenseConfig
+
+	err := c.Unmarshal(&license)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.NewLicenseGate(license), nil
+}
+
+// Without a schedule config updates may be raised at any time
+func newSchedules() (*schedule.Schedules, error) {
+	var config schedule.Config
+
+	c := viper.Sub("Schedule")
+	if c != nil {
+		err := c.Unmarshal(&config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return schedule.NewSchedules(config)
+}
+
+// Version lookups are always cached in memory, the cache config only tunes
+// the TTL and enables persistence
+func newVersionCache() (*source.CacheStore, error) {
+	var config source.CacheConfig
+
+	c := viper.Sub("Cache")
+	if c != nil {
+		err := c.Unmarshal(&config)
+		if err != nil {
+			return nil, err
+		}
 	}
+
+	return source.NewCacheStore(config)
 }
 
 func NewRemoteHandler(global domain.GlobalConfig) (domain.RemoteHandler, error) {
@@ -12,35 +12,40 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/geektype/dependy/dependency"
 	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/policy"
+	"github.com/geektype/dependy/source"
 	"github.com/lmittmann/tint"
 	"github.com/spf13/viper"
 )
 
 type Global struct {
-	gitConfig     GitConfig
-	remoteHandler domain.RemoteHandler
-	updatePolicy  domain.Policy
+	globalConfig   domain.GlobalConfig
+	gitConfig      GitConfig
+	managerConfig  managerConfigs
+	managers       []string // Names of the enabled dependency managers
+	remoteHandler  domain.RemoteHandler
+	updatePolicy   domain.Policy
+	indirectPolicy domain.Policy         // Policy applied to indirect dependencies
+	securityPolicy domain.SecurityPolicy // Policy raising security updates, if configured
+	licenseGate    *policy.LicenseGate
+	versionCache   *source.CacheStore
 }
 
-func Checker(g Global) {
-	var wg sync.WaitGroup
-
-	repos, err := g.remoteHandler.GetRepositories()
-	if err != nil {
-		panic(err)
-	}
-
-	for _, r := range repos {
-		wg.Add(1)
-
-		go func(r domain.Repository) {
-			processRepo(g, r)
-			wg.Done()
-		}(r)
-	}
-
-	wg.Wait()
+// Settings of the dependency managers, each read from the section named
+// after its ecosystem. Missing sections leave the defaults.
+type managerConfigs struct {
+	Go            dependency.GoConfig
+	Npm           dependency.NpmConfig
+	Cargo         dependency.CargoConfig
+	Python        dependency.PythonConfig
+	Docker        dependency.DockerConfig
+	Helm          dependency.HelmConfig
+	Terraform     dependency.TerraformConfig
+	Maven         dependency.MavenConfig
+	GitlabCI      dependency.GitlabCIConfig
+	GitHubActions dependency.GitHubActionsConfig
 }
 
 var VERSION string
@@ -86,6 +91,14 @@ func main() {
 		panic(err)
 	}
 
+	var managerConfig managerConfigs
+
+	err = viper.Unmarshal(&managerConfig)
+	if err != nil {
+		slog.Error("Could not read dependency manager config", slog.Any("error", err))
+		panic(err)
+	}
+
 	switch global.DebugLevel {
 	case "DEBUG":
 		slog.Info("Using DEBUG log level")
@@ -108,7 +121,13 @@ func main() {
 		)
 	}
 
-	updatePolicy, err := newPolicy(global)
+	securityPolicy, err := newSecurityPolicy()
+	if err != nil {
+		slog.Error("Could not initialise security policy", slog.Any("error", err))
+		panic(err)
+	}
+
+	updatePolicy, err := newPolicy(global.DefaultPolicy, securityPolicy)
 	if err != nil {
 		slog.Error("Could not initialise update policy", slog.Any("error", err))
 		panic(err)
@@ -116,16 +135,59 @@ func main() {
 
 	slog.Info("Update policy set to: " + updatePolicy.GetName())
 
+	indirectPolicy := updatePolicy
+
+	if global.IndirectPolicy != "" {
+		indirectPolicy, err = newPolicy(global.IndirectPolicy, securityPolicy)
+		if err != nil {
+			slog.Error("Could not initialise indirect update policy", slog.Any("error", err))
+			panic(err)
+		}
+
+		slog.Info("Indirect update policy set to: " + indirectPolicy.GetName())
+	}
+
+	licenseGate, err := newLicenseGate()
+	if err != nil {
+		slog.Error("Could not initialise license gate", slog.Any("error", err))
+		panic(err)
+	}
+
+	managers, err := enabledManagers(global)
+	if err != nil {
+		slog.Error("Could not enable dependency managers", slog.Any("error", err))
+		panic(err)
+	}
+
 	remoteHandler, err := NewRemoteHandler(global)
 	if err != nil {
 		slog.Error("Could not initialise Remote Git Handler", slog.Any("error", err))
 		panic(err)
 	}
 
+	versionCache, err := newVersionCache()
+	if err != nil {
+		slog.Error("Could not initialise version cache", slog.Any("error", err))
+		panic(err)
+	}
+
+	schedules, err := newSchedules()
+	if err != nil {
+		slog.Error("Could not read schedule windows", slog.Any("error", err))
+		panic(err)
+	}
+
 	g := &Global{
-		gitConfig:     gitConfig,
-		remoteHandler: remoteHandler,
-		updatePolicy:  updatePolicy,
+		globalConfig:   global,
+		gitConfig:      gitConfig,
+		managerConfig:  managerConfig,
+		managers:       managers,
+		remoteHandler:  remoteHandler,
+		updatePolicy:   updatePolicy,
+		indirectPolicy: indirectPolicy,
+		securityPolicy: securityPolicy,
+		licenseGate:    licenseGate,
+		versionCache:   versionCache,
 	}
 
 	slog.Info("Successfully setup " + remoteHandler.GetName())
@@ -135,10 +197,9 @@ func main() {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-	ticker := time.NewTicker(time.Duration(global.RunInterval) * time.Second)
-	tickDone := make(chan bool)
+	scheduler := NewScheduler(*g, schedules, time.Duration(global.RunInterval)*time.Second)
+	schedulerDone := make(chan bool)
 	serverDone := make(chan bool)
-	firstCheck := make(chan bool)
 
 	procWG.Add(1)
 	slog.Info("Starting Webhook Server")
@@ -173,30 +234,17 @@ func main() {
 	go func() {
 		defer procWG.Done()
 
-		for {
-			select {
-			case <-tickDone:
-				return
-			case <-firstCheck:
-				slog.Debug("Initial Check")
-				Checker(*g)
-			case t := <-ticker.C:
-				fmt.Println("Tick at ", t)
-				Checker(*g)
-			}
-		}
+		scheduler.Run(schedulerDone)
 	}()
 
 	slog.Info(fmt.Sprintf("Finished startup in %s", time.Since(startMs)))
 
-	firstCheck <- true
 	// Wait for SIGINT//SIGTERM
 	<-sigs
 
 	// Signal goroutines to wind it up
 	slog.Info("Attempting to shutdown gracefully")
-	ticker.Stop()
-	tickDone <- true
+	schedulerDone <- true
 	serverDone <- true
 
 	procWG.Wait()
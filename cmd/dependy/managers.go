@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+)
+
+// Constructs a manager for a single dependency file
+type managerFactory func(g Global) (domain.DependencyManager, error)
+
+// Dependency managers keyed by the name used in GlobalConfig.DependencyManagers
+var managerRegistry = map[string]managerFactory{
+	"cargo": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewCargoDependencyManager(g.managerConfig.Cargo, g.versionCache)
+	},
+	"docker": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewDockerDependencyManager(g.managerConfig.Docker, g.versionCache), nil
+	},
+	"githubactions": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewGitHubActionsDependencyManager(g.managerConfig.GitHubActions, g.versionCache), nil
+	},
+	"gitlabci": func(g Global) (domain.DependencyManager, error) {
+		tags, ok := g.remoteHandler.(domain.TagLister)
+		if !ok {
+			return nil, fmt.Errorf("%s can not list tags", g.remoteHandler.GetName())
+		}
+
+		return dependency.NewGitlabCIDependencyManager(g.managerConfig.GitlabCI, tags, g.versionCache), nil
+	},
+	"go": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewGoLangDependencyManager(g.managerConfig.Go, g.versionCache)
+	},
+	"gradle": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewGradleDependencyManager(g.managerConfig.Maven, g.versionCache)
+	},
+	"helm": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewHelmDependencyManager(g.managerConfig.Helm, g.versionCache), nil
+	},
+	"maven": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewMavenDependencyManager(g.managerConfig.Maven, g.versionCache)
+	},
+	"npm": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewNpmDependencyManager(g.managerConfig.Npm, g.versionCache)
+	},
+	"pip": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewPipDependencyManager(g.managerConfig.Python, g.versionCache)
+	},
+	"pyproject": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewPyProjectDependencyManager(g.managerConfig.Python, g.versionCache)
+	},
+	"terraform": func(g Global) (domain.DependencyManager, error) {
+		return dependency.NewTerraformDependencyManager(g.managerConfig.Terraform, g.versionCache), nil
+	},
+}
+
+// Manager enabled when the config lists none, as dependy started out
+// updating go.mod alone
+const defaultManager = "go"
+
+// Get the names of the managers enabled by the config. Only the Go manager
+// is enabled when none are listed, other ecosystems have to be opted into.
+func enabledManagers(config domain.GlobalConfig) ([]string, error) {
+	if len(config.DependencyManagers) == 0 {
+		slog.Info("DependencyManagers not defined in config, enabling " + defaultManager + " only")
+
+		return []string{defaultManager}, nil
+	}
+
+	for _, name := range config.DependencyManagers {
+		if _, ok := managerRegistry[name]; !ok {
+			return nil, fmt.Errorf("dependency manager %s not found", name)
+		}
+	}
+
+	return config.DependencyManagers, nil
+}
+
+// Whether the file at p is one of the manager's dependency files
+func matchesFile(manager domain.DependencyManager, p string) bool {
+	if m, ok := manager.(domain.FileMatcher); ok {
+		return m.MatchFile(p)
+	}
+
+	return path.Base(p) == manager.GetFileName()
+}
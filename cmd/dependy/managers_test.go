@@ -0,0 +1,32 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+)
+
+func TestEnabledManagers(t *testing.T) {
+	names, err := enabledManagers(domain.GlobalConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(names, []string{"go"}) {
+		t.Errorf("expected only the Go manager by default, got %v", names)
+	}
+
+	names, err = enabledManagers(domain.GlobalConfig{DependencyManagers: []string{"npm", "cargo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(names, []string{"npm", "cargo"}) {
+		t.Errorf("expected the listed managers, got %v", names)
+	}
+
+	if _, err := enabledManagers(domain.GlobalConfig{DependencyManagers: []string{"bogus"}}); err == nil {
+		t.Error("expected unknown manager to be rejected")
+	}
+}
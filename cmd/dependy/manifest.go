@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Directories never searched for dependency files, as they hold copies of
+// dependencies or fixtures rather than projects of their own
+var ignoredDirs = map[string]bool{
+	"vendor":       true,
+	"testdata":     true,
+	"node_modules": true,
+}
+
+// A dependency file found in the work tree along with the manager parsing it
+//
+// Managers hold the state of the file they parsed, so every file gets its
+// own.
+type manifest struct {
+	Path    string // Relative to the repository root
+	Manager domain.DependencyManager
+	Deps    []domain.Dependency
+}
+
+// Updates raised for a single dependency file
+type manifestUpdates struct {
+	Path    string
+	Updated []domain.Dependency
+}
+
+// Find and parse every dependency file in the work tree handled by an
+// enabled manager
+//
+// Dependencies the manager resolves from the work tree rather than a
+// registry, such as Go modules sharing a go.work or npm workspace packages,
+// are dropped. Files which fail to parse are logged and skipped.
+func discoverManifests(g Global, gitM *GitManager) ([]manifest, error) {
+	type candidate struct {
+		path    string
+		factory managerFactory
+	}
+
+	probes := make([]domain.DependencyManager, len(g.managers))
+	factories := make([]managerFactory, len(g.managers))
+
+	for i, name := range g.managers {
+		factories[i] = managerRegistry[name]
+
+		probe, err := factories[i](g)
+		if err != nil {
+			return nil, fmt.Errorf("initialising %s manager: %w", name, err)
+		}
+
+		probes[i] = probe
+	}
+
+	candidates := make([]candidate, 0)
+
+	err := gitM.WalkFiles(func(p string) error {
+		if isIgnoredPath(p) {
+			return nil
+		}
+
+		for i, probe := range probes {
+			if matchesFile(probe, p) {
+				candidates = append(candidates, candidate{path: p, factory: factories[i]})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].path < candidates[j].path })
+
+	manifests := make([]manifest, 0, len(candidates))
+	members := make(map[string]map[string]string) // Paths of local dependency files by ecosystem and name
+
+	for _, c := range candidates {
+		m, err := c.factory(g)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := gitM.OpenFile(c.path)
+		if err != nil {
+			slog.Error("Error opening "+c.path, slog.Any("error", err))
+			continue
+		}
+
+		ds, err := m.ParseFile(content)
+		if err != nil {
+			slog.Error("Error parsing "+c.path, slog.Any("error", err))
+			continue
+		}
+
+		if member, ok := m.(domain.WorkspaceMember); ok && member.GetManifestName() != "" {
+			name := member.GetManifestName()
+
+			if members[m.GetEcosystem()] == nil {
+				members[m.GetEcosystem()] = make(map[string]string)
+			}
+
+			members[m.GetEcosystem()][name] = c.path
+		}
+
+		manifests = append(manifests, manifest{Path: c.path, Manager: m, Deps: ds})
+	}
+
+	for i := range manifests {
+		m := &manifests[i]
+
+		member, ok := m.Manager.(domain.WorkspaceMember)
+		if !ok {
+			continue
+		}
+
+		member.SetWorkspace(m.Path, members[m.Manager.GetEcosystem()], gitM.OpenFile)
+
+		external := make([]domain.Dependency, 0, len(m.Deps))
+
+		for _, d := range m.Deps {
+			if member.IsLocal(d.Name) {
+				slog.Debug("Skipping dependency within the repository", slog.String("file", m.Path), slog.String("dependency", d.Name))
+				continue
+			}
+
+			external = append(external, d)
+		}
+
+		m.Deps = external
+	}
+
+	return manifests, nil
+}
+
+// Hidden directories searched nonetheless, as they hold configuration of the
+// repository's own
+var searchedHiddenDirs = map[string]bool{
+	".github": true,
+}
+
+func isIgnoredPath(p string) bool {
+	dirs := strings.Split(path.Dir(p), "/")
+
+	for _, d := range dirs {
+		if searchedHiddenDirs[d] {
+			continue
+		}
+
+		if ignoredDirs[d] || (len(d) > 1 && (d[0] == '.' || d[0] == '_')) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether file belongs to m rather than to a dependency file of the same
+// ecosystem nested deeper in the tree
+func ownsFile(m manifest, manifests []manifest, file string) bool {
+	dir := manifestDir(m.Path)
+	if !strings.HasPrefix(file, dir) {
+		return false
+	}
+
+	for _, o := range manifests {
+		if o.Manager.GetEcosystem() != m.Manager.GetEcosystem() {
+			continue
+		}
+
+		if nested := manifestDir(o.Path); len(nested) > len(dir) && strings.HasPrefix(file, nested) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get the directory of a dependency file as a prefix of the paths within,
+// empty at the repository root
+func manifestDir(p string) string {
+	dir := path.Dir(p)
+	if dir == "." {
+		return ""
+	}
+
+	return dir + "/"
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Manager exposing nothing but its ecosystem
+type ecosystemManager struct {
+	domain.DependencyManager
+	ecosystem string
+}
+
+func (m ecosystemManager) GetEcosystem() string {
+	return m.ecosystem
+}
+
+func TestOwnsFile(t *testing.T) {
+	golang := ecosystemManager{ecosystem: "Go"}
+	docker := ecosystemManager{ecosystem: "Docker"}
+
+	// In path order, the root Dockerfile comes before the go.mod next to it
+	manifests := []manifest{
+		{Path: "Dockerfile", Manager: docker},
+		{Path: "go.mod", Manager: golang},
+		{Path: "tools/go.mod", Manager: golang},
+	}
+
+	tests := []struct {
+		manifest int
+		file     string
+		want     bool
+	}{
+		{1, "main.go", true},
+		{1, "internal/app/app.go", true},
+		{1, "tools/gen.go", false},
+		{2, "tools/gen.go", true},
+		{2, "main.go", false},
+		{0, "main.go", true},
+		{0, "tools/gen.go", true},
+	}
+
+	for _, tt := range tests {
+		m := manifests[tt.manifest]
+		if got := ownsFile(m, manifests, tt.file); got != tt.want {
+			t.Errorf("ownsFile(%s, %s) = %v, want %v", m.Path, tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const (
+	defaultTitlePrefix = "[Dependy]"
+	securityLabel      = "security"
+	securityBranch     = "security"
+	majorLabel         = "major-migration"
+	indirectLabel      = "indirect"
+	blockedLabel       = "blocked"
+	deprecatedLabel    = "deprecated"
+	majorBranch        = "major"
+	toolchainLabel     = "toolchain"
+	toolchainBranch    = "toolchain"
+)
+
+var qualitativeSeverities = map[string]bool{
+	"low":      true,
+	"moderate": true,
+	"medium":   true,
+	"high":     true,
+	"critical": true,
+}
+
+// Kind of change set raised in a single merge request
+type updateKind struct {
+	Security         bool // Updates fix known vulnerabilities
+	BypassLimits     bool // Updates should be raised while a routine merge request is open
+	OverrideSchedule bool // Updates should be raised outside schedule windows
+	Major            bool // Updates migrate dependencies to new major versions
+	Toolchain        bool // Updates move the language or toolchain version
+	Deprecations     bool // Deprecation notices of the dependencies are reported along with the updates
+}
+
+func getUpdateKind(p domain.Policy) updateKind {
+	s, ok := p.(domain.SecurityPolicy)
+	if !ok {
+		return updateKind{}
+	}
+
+	return updateKind{Security: true, BypassLimits: s.BypassLimits(), OverrideSchedule: s.OverrideSchedule()}
+}
+
+func titlePrefix(global domain.GlobalConfig) string {
+	if global.TitlePrefix == "" {
+		return defaultTitlePrefix
+	}
+
+	return global.TitlePrefix
+}
+
+func (k updateKind) Title(global domain.GlobalConfig) string {
+	switch {
+	case k.Major:
+		return titlePrefix(global) + " Major Version Migration"
+	case k.Toolchain:
+		return titlePrefix(global) + " Toolchain Update"
+	case k.Security:
+		return titlePrefix(global) + " Security Update"
+	default:
+		return titlePrefix(global) + " Dependency Update"
+	}
+}
+
+// Name of the branch updates are pushed to. Security updates, major
+// migrations and toolchain updates get their own branch so they can be
+// raised alongside an open routine merge request.
+func (k updateKind) Branch(git GitConfig) string {
+	switch {
+	case k.Major:
+		return git.PatchBranchPrefix + "-" + majorBranch
+	case k.Toolchain:
+		return git.PatchBranchPrefix + "-" + toolchainBranch
+	case k.Security:
+		return git.PatchBranchPrefix + "-" + securityBranch
+	default:
+		return git.PatchBranchPrefix
+	}
+}
+
+// Terms used to look for already open merge requests which should hold back
+// a change set of this kind. Routine and security updates hold each other
+// back unless security updates may bypass limits. Major migrations and
+// toolchain updates are only held back by themselves.
+func (k updateKind) Searches(global domain.GlobalConfig) []string {
+	if k.Major || k.Toolchain || k.BypassLimits {
+		return []string{k.Title(global)}
+	}
+
+	return []string{
+		updateKind{}.Title(global),
+		updateKind{Security: true}.Title(global),
+	}
+}
+
+func newMergeRequest(
+	g Global,
+	repo domain.Repository,
+	kind updateKind,
+	changes []manifestUpdates,
+	deprecated []deprecatedDependency,
+) domain.MergeRequest {
+	updated := make([]domain.Dependency, 0)
+	for _, c := range changes {
+		updated = append(updated, c.Updated...)
+	}
+
+	return domain.MergeRequest{
+		SourceBranch: kind.Branch(g.gitConfig),
+		TargetBranch: repo.Branch,
+		Title:        kind.Title(g.globalConfig),
+		Description:  describeKind(kind) + describeChanges(changes) + describeDeprecations(deprecated, g.globalConfig.SuggestReplacements),
+		Labels:       labelUpdates(kind, updated),
+	}
+}
+
+// Updates of repositories with several dependency files are grouped by file
+func describeChanges(changes []manifestUpdates) string {
+	if len(changes) == 1 {
+		return describeUpdates(changes[0].Updated)
+	}
+
+	var b strings.Builder
+
+	for i, c := range changes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(&b, "## `%s`\n\n", c.Path)
+		b.WriteString(describeUpdates(c.Updated))
+	}
+
+	return b.String()
+}
+
+func describeKind(kind updateKind) string {
+	switch {
+	case kind.Major:
+		return "**Major version migration.** Dependencies are moved to new major versions and " +
+			"import paths have been rewritten. Breaking API changes may require manual fixes.\n\n"
+	case kind.Toolchain:
+		return "**Toolchain update.** Developer machines and CI jobs building this repository " +
+			"need the new version, or must be able to download it.\n\n"
+	default:
+		return ""
+	}
+}
+
+func describeUpdates(updated []domain.Dependency) string {
+	var b strings.Builder
+
+	b.WriteString("| Dependency | Version |\n| --- | --- |\n")
+
+	blocked := make([]domain.Dependency, 0)
+	indirect := make([]domain.Dependency, 0)
+
+	for _, d := range updated {
+		switch {
+		case d.Blocked != "":
+			blocked = append(blocked, d)
+		case d.Indirect:
+			indirect = append(indirect, d)
+		case d.Replaces != "":
+			fmt.Fprintf(&b, "| `%s` → `%s` | `%s` |\n", d.Replaces, d.Name, d.Version.String())
+		default:
+			fmt.Fprintf(&b, "| `%s` | `%s` |\n", d.Name, d.Version.String())
+		}
+	}
+
+	if len(indirect) > 0 {
+		b.WriteString("\n### Indirect Dependencies\n\n")
+		b.WriteString("The following dependencies are only required through other dependencies.\n\n")
+		b.WriteString("| Dependency | Version |\n| --- | --- |\n")
+
+		for _, d := range indirect {
+			fmt.Fprintf(&b, "| `%s` | `%s` |\n", d.Name, d.Version.String())
+		}
+	}
+
+	advisories := make([]string, 0)
+
+	for _, d := range updated {
+		if d.Blocked != "" {
+			continue
+		}
+
+		for _, a := range d.Advisories {
+			ids := a.ID
+			if len(a.Aliases) > 0 {
+				ids += " (" + strings.Join(a.Aliases, ", ") + ")"
+			}
+
+			advisories = append(
+				advisories,
+				fmt.Sprintf("| `%s` | %s | %s | %s |\n", d.Name, ids, a.Severity, a.Summary),
+			)
+		}
+	}
+
+	if len(advisories) > 0 {
+		b.WriteString("\n### Security Advisories\n\n")
+		b.WriteString("| Dependency | Advisory | Severity | Summary |\n| --- | --- | --- | --- |\n")
+
+		for _, a := range advisories {
+			b.WriteString(a)
+		}
+	}
+
+	if len(blocked) > 0 {
+		b.WriteString("\n### Blocked Updates\n\n")
+		b.WriteString("The following updates were withheld and need manual review.\n\n")
+		b.WriteString(describeBlocked(blocked))
+	}
+
+	return b.String()
+}
+
+func describeBlocked(blocked []domain.Dependency) string {
+	var b strings.Builder
+
+	b.WriteString("| Dependency | Version | Reason |\n| --- | --- | --- |\n")
+
+	for _, d := range blocked {
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", d.Name, d.Version.String(), d.Blocked)
+	}
+
+	return b.String()
+}
+
+// Report of blocked updates and deprecation notices for when no merge
+// request carries them. Returns false if there is nothing to report.
+func newReport(
+	g Global,
+	kind updateKind,
+	changes []manifestUpdates,
+	deprecated []deprecatedDependency,
+) (domain.Issue, bool) {
+	var b strings.Builder
+
+	labels := make([]string, 0)
+
+	for _, c := range changes {
+		blocked := make([]domain.Dependency, 0)
+
+		for _, d := range c.Updated {
+			if d.Blocked != "" {
+				blocked = append(blocked, d)
+			}
+		}
+
+		if len(blocked) == 0 {
+			continue
+		}
+
+		if len(labels) == 0 {
+			b.WriteString("### Blocked Updates\n\n")
+			b.WriteString("The following updates were withheld and need manual review.\n")
+			labels = append(labels, blockedLabel)
+		}
+
+		fmt.Fprintf(&b, "\n#### `%s`\n\n", c.Path)
+		b.WriteString(describeBlocked(blocked))
+	}
+
+	if len(deprecated) > 0 {
+		labels = append(labels, deprecatedLabel)
+	}
+
+	b.WriteString(describeDeprecations(deprecated, g.globalConfig.SuggestReplacements))
+
+	return domain.Issue{
+		Title:       kind.Title(g.globalConfig) + " Report",
+		Description: strings.TrimPrefix(b.String(), "\n"),
+		Labels:      labels,
+	}, len(labels) > 0
+}
+
+func labelUpdates(kind updateKind, updated []domain.Dependency) []string {
+	if kind.Major {
+		return []string{majorLabel}
+	}
+
+	if kind.Toolchain {
+		return []string{toolchainLabel}
+	}
+
+	security := false
+	indirect := false
+	severities := make(map[string]bool)
+	advisories := make(map[string]bool)
+
+	for _, d := range updated {
+		if d.Blocked != "" {
+			continue
+		}
+
+		indirect = indirect || d.Indirect
+
+		for _, a := range d.Advisories {
+			security = true
+			advisories[a.ID] = true
+
+			// Only qualitative ratings make sensible labels, raw CVSS vectors
+			// are left to the description
+			s := strings.ToLower(a.Severity)
+			if qualitativeSeverities[s] {
+				severities[s] = true
+			}
+		}
+	}
+
+	var labels []string
+
+	if security {
+		sevLabels := make([]string, 0, len(severities))
+
+		for s := range severities {
+			sevLabels = append(sevLabels, "severity::"+s)
+		}
+
+		sort.Strings(sevLabels)
+
+		ids := make([]string, 0, len(advisories))
+
+		for id := range advisories {
+			ids = append(ids, id)
+		}
+
+		sort.Strings(ids)
+
+		labels = append([]string{securityLabel}, sevLabels...)
+		labels = append(labels, ids...)
+	}
+
+	if indirect {
+		labels = append(labels, indirectLabel)
+	}
+
+	return labels
+}
+
+func describeDeprecations(deprecated []deprecatedDependency, suggest bool) string {
+	if len(deprecated) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteString("\n### Deprecated Dependencies\n\n")
+
+	for _, d := range deprecated {
+		fmt.Fprintf(&b, "- `%s`: %s\n", d.Name, strings.ReplaceAll(d.Message, "\n", " "))
+
+		if suggest && d.Replacement != "" {
+			fmt.Fprintf(&b, "  - Consider migrating to `%s`\n", d.Replacement)
+		}
+	}
+
+	return b.String()
+}
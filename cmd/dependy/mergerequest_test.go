@@ -0,0 +1,26 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+)
+
+func TestLabelUpdates(t *testing.T) {
+	updated := []domain.Dependency{
+		{Name: "example.com/a", Advisories: []domain.Advisory{
+			{ID: "GO-2024-0002", Severity: "HIGH"},
+			{ID: "GO-2024-0001", Severity: "CVSS:3.1/AV:N"},
+		}},
+		{Name: "example.com/b", Indirect: true, Advisories: []domain.Advisory{{ID: "GO-2024-0002", Severity: "HIGH"}}},
+		{Name: "example.com/c", Blocked: "held back", Advisories: []domain.Advisory{{ID: "GO-2024-0003", Severity: "LOW"}}},
+	}
+
+	labels := labelUpdates(updateKind{Security: true}, updated)
+
+	want := []string{"security", "severity::high", "GO-2024-0001", "GO-2024-0002", "indirect"}
+	if !slices.Equal(labels, want) {
+		t.Errorf("expected labels %v, got %v", want, labels)
+	}
+}
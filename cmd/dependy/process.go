@@ -1,105 +1,521 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path"
 
-	"github.com/geektype/dependy/dependency"
 	"github.com/geektype/dependy/domain"
 )
 
-func processRepo(g Global, repo domain.Repository) {
+// Raise every kind of change set due for the repository. Outside its
+// schedule windows only security updates allowed to override them are raised.
+func processRepo(g Global, repo domain.Repository, windowOpen bool) {
 	// TODO: Handle all panics
 	slog.Info(fmt.Sprintf("Processing %s repository", repo.Name))
-	// Check if a dependy PR already exists
-	slog.Debug("Checking if a dependy merge request is already active")
 
-	exists, err := g.remoteHandler.CheckMRExists(repo)
-	if err != nil {
-		slog.Error("Failed to check if an active MR exists. Skipping...")
+	routine := getUpdateKind(g.updatePolicy)
+	routine.Deprecations = true
+
+	if windowOpen || routine.OverrideSchedule {
+		raiseUpdates(g, repo, routine)
+	}
+
+	// Security updates are raised alongside routine ones, unless the routine
+	// ones already are security updates
+	if g.securityPolicy != nil && !routine.Security {
+		security := getUpdateKind(g.securityPolicy)
+
+		if windowOpen || security.OverrideSchedule {
+			raiseUpdates(g, repo, security)
+		}
+	}
+
+	if !windowOpen {
+		slog.Info("Done processing")
 		return
 	}
 
-	if exists {
-		slog.Info("There is already an active dependy merge request. Skipping...")
+	if g.globalConfig.MajorUpgrades {
+		raiseUpdates(g, repo, updateKind{Major: true})
+	}
+
+	if g.managerConfig.Go.Toolchain.Policy != "" {
+		raiseUpdates(g, repo, updateKind{Toolchain: true})
+	}
+
+	slog.Info("Done processing")
+}
+
+// Whether security updates of the repository may be raised outside its
+// schedule windows
+func overridesSchedule(g Global, repo domain.Repository) bool {
+	return g.securityPolicy != nil && g.securityPolicy.OverrideSchedule() && g.securityPolicy.AppliesTo(repo)
+}
+
+// Raise a merge request containing a single kind of change set, covering
+// every dependency file of the repository
+func raiseUpdates(g Global, repo domain.Repository, kind updateKind) {
+	if kind.Security && !g.securityPolicy.AppliesTo(repo) {
+		slog.Debug("Security updates are not enabled for the repository")
 		return
 	}
 
+	// Check if a dependy PR already exists
+	slog.Debug("Checking if a dependy merge request is already active")
+
+	for _, search := range kind.Searches(g.globalConfig) {
+		exists, err := g.remoteHandler.CheckMRExists(repo, search)
+		if err != nil {
+			slog.Error("Failed to check if an active MR exists. Skipping...")
+			return
+		}
+
+		if exists {
+			slog.Info("There is already an active dependy merge request. Skipping...")
+			return
+		}
+	}
+
 	gitM := NewGitManager(g.gitConfig)
 
-	err = gitM.CloneRepo(repo)
+	err := gitM.CloneRepo(repo)
 	if err != nil {
 		slog.Error("Failed to clone "+repo.URL, slog.Any("error", err))
 		panic(err)
 	}
 
-	err = gitM.BranchMain()
+	err = gitM.BranchMain(kind.Branch(g.gitConfig))
 	if err != nil {
 		slog.Error("Failed to create fix branch", slog.Any("error", err))
 		panic(err)
 	}
 
-	// TODO: This should be decided based on repo content
-	var depManager domain.DependencyManager = dependency.NewGoLangDependencyManager()
-
-	f, err := gitM.OpenFile(depManager.GetFileName())
+	manifests, err := discoverManifests(g, gitM)
 	if err != nil {
-		slog.Error("Error opening file: ", slog.Any("error", err))
+		slog.Error("Failed to discover dependency files", slog.Any("error", err))
+		return
+	}
+
+	if len(manifests) == 0 {
+		slog.Info("No dependency files found. Skipping")
+		return
+	}
+
+	files := make(map[string][]byte)
+	changes := make([]manifestUpdates, 0)
+	deprecated := make([]deprecatedDependency, 0)
+	seenDeprecations := make(map[string]bool)
+
+	for _, m := range manifests {
+		updated, dep := updateManifest(g, gitM, kind, m, manifests, files)
+
+		if len(updated) > 0 {
+			changes = append(changes, manifestUpdates{Path: m.Path, Updated: updated})
+		}
+
+		for _, d := range dep {
+			if !seenDeprecations[d.Name] {
+				seenDeprecations[d.Name] = true
+				deprecated = append(deprecated, d)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		if report, ok := newReport(g, kind, changes, deprecated); ok {
+			reportIssue(g, repo, report)
+			return
+		}
+
+		slog.Info("Already up to date. Skipping")
+
+		return
 	}
 
-	ds, err := depManager.ParseFile(f)
+	err = gitM.CommitFiles(files)
 	if err != nil {
-		slog.Error("Error parsing "+depManager.GetFileName(), slog.Any("error", err))
+		slog.Error("Error encountered while creating commit", slog.Any("error", err))
 		panic(err)
 	}
 
-	updated, err := g.updatePolicy.GetNextDependencies(ds, depManager)
+	slog.Info("Pushing changes to remote")
+
+	err = gitM.Push()
+	if err != nil {
+		slog.Error("Failed to push to remote repository", slog.Any("error", err))
+		return
+	}
+
+	slog.Info("Creating merge request")
+
+	err = g.remoteHandler.CreateMergeRequest(repo, newMergeRequest(g, repo, kind, changes, deprecated))
 	if err != nil {
-		slog.Error("Error while fetching latest dependency versions", slog.Any("error", err))
+		slog.Error("Failed to create Merge Request", slog.Any("error", err))
 		panic(err)
 	}
+}
+
+// Raise an issue with what needs attention when there is no merge request
+// to report it in. Remotes which can not raise issues only get it logged.
+func reportIssue(g Global, repo domain.Repository, report domain.Issue) {
+	reporter, ok := g.remoteHandler.(domain.IssueReporter)
+	if !ok {
+		slog.Warn(
+			"Nothing to update but there are blocked updates or deprecated dependencies, and "+
+				g.remoteHandler.GetName()+" can not raise issues",
+			slog.String("report", report.Description),
+		)
 
-	if len(updated) == 0 {
-		slog.Info("Already up to date. Skipping")
 		return
 	}
 
-	slog.Info("Updating dependencies")
+	slog.Info("Nothing to update, raising an issue for blocked updates and deprecated dependencies")
+
+	err := reporter.ReportIssue(repo, report)
+	if err != nil {
+		slog.Error("Failed to raise issue", slog.Any("error", err))
+	}
+}
+
+// Apply a kind of change set to a single dependency file, adding every
+// changed file to files. Returns the proposed updates, including blocked
+// ones, and deprecation notices of the file's dependencies. Failures are
+// logged and leave the file untouched.
+func updateManifest(
+	g Global,
+	gitM *GitManager,
+	kind updateKind,
+	m manifest,
+	manifests []manifest,
+	files map[string][]byte,
+) ([]domain.Dependency, []deprecatedDependency) {
+	depManager := m.Manager
+	logger := slog.With(slog.String("file", m.Path))
+
+	upgrader, ok := depManager.(domain.MajorUpgrader)
+	if kind.Major && !ok {
+		logger.Debug(depManager.GetName() + " does not support major version migrations")
+		return nil, nil
+	}
+
+	toolchain, ok := depManager.(domain.ToolchainUpdater)
+	if kind.Toolchain && !ok {
+		logger.Debug(depManager.GetName() + " does not support toolchain updates")
+		return nil, nil
+	}
+
+	var (
+		updated []domain.Dependency
+		err     error
+	)
+
+	switch {
+	case kind.Major:
+		updated = findMajorUpgrades(m.Deps, upgrader)
+	case kind.Toolchain:
+		updated, err = toolchain.FetchToolchainUpdates()
+		if err != nil {
+			logger.Error("Error while fetching toolchain releases", slog.Any("error", err))
+			return nil, nil
+		}
+	default:
+		updated, err = nextDependencies(g, kind, m.Deps, depManager)
+		if err != nil {
+			logger.Error("Error while fetching latest dependency versions", slog.Any("error", err))
+			return nil, nil
+		}
+	}
+
+	// Toolchains are not dependencies with licenses or deprecation notices
+	if g.licenseGate != nil && !kind.Toolchain {
+		updated = g.licenseGate.Check(m.Deps, updated, depManager)
+	}
+
+	dir := path.Dir(m.Path)
+
+	companion, isCompanion := depManager.(domain.CompanionUpdater)
+
+	if checker, ok := depManager.(domain.UpdateChecker); ok && !kind.Toolchain {
+		var companions map[string][]byte
+
+		if isCompanion {
+			companions, err = readCompanions(gitM, companion, dir, files)
+			if err != nil {
+				logger.Error("Could not read accompanying files", slog.Any("error", err))
+				return nil, nil
+			}
+		}
+
+		updated, err = checker.CheckUpdates(updated, companions)
+		if err != nil {
+			logger.Error("Error while checking dependency updates", slog.Any("error", err))
+			return nil, nil
+		}
+	}
+
+	applicable := make([]domain.Dependency, 0, len(updated))
 
 	for _, d := range updated {
-		err := depManager.ApplyDependency(d)
+		if d.Blocked != "" {
+			logger.Warn(
+				"Update blocked",
+				slog.String("dependency", d.Name),
+				slog.String("version", d.Version.String()),
+				slog.String("reason", d.Blocked),
+			)
+
+			continue
+		}
+
+		applicable = append(applicable, d)
+	}
+
+	// Dependencies already at their latest version may well be deprecated,
+	// so notices are collected whether or not anything gets updated
+	var deprecated []deprecatedDependency
+
+	if kind.Deprecations {
+		deprecated = collectDeprecations(m.Deps, depManager)
+	}
+
+	if len(applicable) == 0 {
+		logger.Info("Already up to date")
+		return updated, deprecated
+	}
+
+	apply := depManager.ApplyDependency
+
+	if kind.Toolchain {
+		apply = toolchain.ApplyToolchain
+	}
+
+	logger.Info("Updating dependencies")
+
+	// Updates which fail to apply are reported as blocked rather than as
+	// part of the change
+	applied := make([]domain.Dependency, 0, len(applicable))
+
+	for i, d := range updated {
+		if d.Blocked != "" {
+			continue
+		}
+
+		err := apply(d)
+		if err != nil {
+			logger.Error("Could not apply dependency update", slog.String("dependency", d.Name), slog.Any("error", err))
+			updated[i].Blocked = "could not be applied: " + err.Error()
+
+			continue
+		}
+
+		applied = append(applied, d)
+	}
+
+	// Companions are updated first as doing so may change the dependency
+	// file, but only staged along with it
+	var companions map[string][]byte
+
+	if isCompanion {
+		companions, err = updateCompanions(gitM, companion, dir, files)
 		if err != nil {
-			slog.Error("Could not apply dependency update", slog.Any("error", err))
+			logger.Warn("Could not update accompanying files, they may need updating by hand", slog.Any("error", err))
 		}
 	}
 
 	final, err := depManager.GetFile()
 	if err != nil {
-		slog.Error("Failed to edit "+depManager.GetFileName(), slog.Any("error", err))
-		panic(err)
+		logger.Error("Failed to edit dependency file", slog.Any("error", err))
+		return nil, nil
 	}
 
-	err = gitM.CommitFile(depManager.GetFileName(), final)
+	for name, content := range companions {
+		files[path.Join(dir, name)] = content
+	}
+
+	files[m.Path] = final
+
+	if kind.Major {
+		err = rewriteSources(gitM, upgrader, applied, files, func(file string) bool {
+			return ownsFile(m, manifests, file)
+		})
+		if err != nil {
+			logger.Error("Failed to rewrite source files", slog.Any("error", err))
+			panic(err)
+		}
+	}
+
+	return updated, deprecated
+}
+
+// Run the update policies, indirect dependencies going through their own.
+// Security updates raised alongside routine ones only go through the security
+// policy.
+func nextDependencies(
+	g Global,
+	kind updateKind,
+	current []domain.Dependency,
+	manager domain.DependencyManager,
+) ([]domain.Dependency, error) {
+	directPolicy, indirectPolicy := g.updatePolicy, g.indirectPolicy
+
+	if kind.Security && !getUpdateKind(g.updatePolicy).Security {
+		directPolicy, indirectPolicy = g.securityPolicy, g.securityPolicy
+	}
+
+	direct := make([]domain.Dependency, 0, len(current))
+	indirect := make([]domain.Dependency, 0)
+
+	for _, d := range current {
+		if d.Indirect {
+			indirect = append(indirect, d)
+		} else {
+			direct = append(direct, d)
+		}
+	}
+
+	updated, err := directPolicy.GetNextDependencies(direct, manager)
 	if err != nil {
-		slog.Error("Error encountered while creating commit", slog.Any("error", err))
-		panic(err)
+		return nil, err
 	}
 
-	slog.Info("Pushing changes to remote")
+	if len(indirect) == 0 {
+		return updated, nil
+	}
 
-	err = gitM.Push()
+	indirectUpdates, err := indirectPolicy.GetNextDependencies(indirect, manager)
 	if err != nil {
-		slog.Error("Failed to push to remote repository", slog.Any("error", err))
-		return
+		return nil, err
 	}
 
-	slog.Info("Creating merge request")
+	// Policies build new dependencies, so carry the marker over
+	for _, d := range indirectUpdates {
+		d.Indirect = true
+		updated = append(updated, d)
+	}
+
+	return updated, nil
+}
+
+// Find dependencies which have a newer major version published under a new
+// name. Failed lookups are logged and skipped.
+func findMajorUpgrades(current []domain.Dependency, upgrader domain.MajorUpgrader) []domain.Dependency {
+	upgrades := make([]domain.Dependency, 0)
+
+	for _, d := range current {
+		// Pinned dependencies have been deliberately held back and import
+		// paths of indirect dependencies do not appear in the source
+		if d.Constraint != nil || d.Indirect {
+			continue
+		}
+
+		u, err := upgrader.FetchMajorUpgrade(d)
+		if err != nil {
+			slog.Warn("Could not check for major version", slog.String("dependency", d.Name), slog.Any("error", err))
+			continue
+		}
+
+		if u != nil {
+			upgrades = append(upgrades, *u)
+		}
+	}
+
+	return upgrades
+}
+
+// Rewrite references to replaced dependencies in the source files selected
+// by include, adding every changed file to files
+func rewriteSources(
+	gitM *GitManager,
+	upgrader domain.MajorUpgrader,
+	updates []domain.Dependency,
+	files map[string][]byte,
+	include func(path string) bool,
+) error {
+	return gitM.WalkFiles(func(path string) error {
+		if !upgrader.IsSourceFile(path) || !include(path) {
+			return nil
+		}
+
+		content, err := gitM.OpenFile(path)
+		if err != nil {
+			return err
+		}
+
+		rewritten, err := upgrader.RewriteSource(path, content, updates)
+		if err != nil {
+			slog.Warn("Could not rewrite source file", slog.String("file", path), slog.Any("error", err))
+			return nil
+		}
+
+		if rewritten != nil {
+			files[path] = rewritten
+		}
+
+		return nil
+	})
+}
 
-	err = g.remoteHandler.CreateMergeRequest(repo, g.gitConfig.PatchBranchPrefix, repo.Branch)
+// Bring the files accompanying a dependency file in dir in line with the
+// applied updates. Returns the changed files, relative to dir
+func updateCompanions(gitM *GitManager, companion domain.CompanionUpdater, dir string, files map[string][]byte) (map[string][]byte, error) {
+	current, err := readCompanions(gitM, companion, dir, files)
 	if err != nil {
-		slog.Error("Failed to create Merge Request", slog.Any("error", err))
-		panic(err)
+		return nil, err
 	}
 
-	slog.Info("Done processing")
+	return companion.UpdateCompanions(current)
+}
+
+// Read the companion files of a dependency file in dir, nil for missing ones
+func readCompanions(gitM *GitManager, companion domain.CompanionUpdater, dir string, files map[string][]byte) (map[string][]byte, error) {
+	current := make(map[string][]byte)
+
+	for _, name := range companion.GetCompanionFiles() {
+		// Companions shared by several dependency files, such as a go.work,
+		// may already have been changed for another one
+		if content, ok := files[path.Join(dir, name)]; ok {
+			current[name] = content
+			continue
+		}
+
+		content, err := gitM.OpenFile(path.Join(dir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		current[name] = content
+	}
+
+	return current, nil
+}
+
+type deprecatedDependency struct {
+	Name string
+	domain.Deprecation
+}
+
+// Look up deprecation notices for the current dependencies. Failures are
+// logged and otherwise ignored as notices are purely informational.
+func collectDeprecations(
+	current []domain.Dependency,
+	manager domain.DependencyManager,
+) []deprecatedDependency {
+	deprecated := make([]deprecatedDependency, 0)
+
+	for _, d := range current {
+		dep, err := manager.FetchDeprecation(d)
+		if err != nil {
+			slog.Debug("Could not check deprecation", slog.String("dependency", d.Name), slog.Any("error", err))
+			continue
+		}
+
+		if dep != nil {
+			deprecated = append(deprecated, deprecatedDependency{Name: d.Name, Deprecation: *dep})
+		}
+	}
+
+	return deprecated
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/schedule"
+)
+
+// Default time between runs against the same repository
+const defaultRunInterval = time.Hour
+
+func NewScheduler(g Global, schedules *schedule.Schedules, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		slog.Warn(fmt.Sprintf("RunInterval not set, defaulting to %s", defaultRunInterval))
+		interval = defaultRunInterval
+	}
+
+	return &Scheduler{
+		global:    g,
+		schedules: schedules,
+		interval:  interval,
+		lastRun:   make(map[string]time.Time),
+	}
+}
+
+// Decides when each repository is processed
+//
+// A repository is due once `interval` has passed since it was last
+// processed and its schedule window is open. Security updates allowed to
+// override schedules ignore the windows. The list of repositories is refreshed
+// at least once every interval.
+type Scheduler struct {
+	global    Global
+	schedules *schedule.Schedules
+	interval  time.Duration
+	lastRun   map[string]time.Time
+}
+
+// Check repositories until done is signalled
+func (s *Scheduler) Run(done <-chan bool) {
+	for {
+		wait := s.RunDue(time.Now())
+
+		slog.Debug(fmt.Sprintf("Next check in %s", wait))
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Process every repository due at `now` and get the time to wait until the
+// next repository becomes due
+func (s *Scheduler) RunDue(now time.Time) time.Duration {
+	repos, err := s.global.remoteHandler.GetRepositories()
+	if err != nil {
+		slog.Error("Failed to fetch repositories", slog.Any("error", err))
+		return s.interval
+	}
+
+	var wg sync.WaitGroup
+
+	wait := s.interval
+
+	for _, r := range repos {
+		due := s.nextDue(r, now)
+		if due.IsZero() {
+			continue
+		}
+
+		if due.After(now) {
+			if d := due.Sub(now); d < wait {
+				wait = d
+			}
+
+			slog.Debug(fmt.Sprintf("%s is next due at %s", r.Name, due))
+
+			continue
+		}
+
+		s.lastRun[r.Name] = now
+
+		wg.Add(1)
+
+		go func(r domain.Repository, windowOpen bool) {
+			processRepo(s.global, r, windowOpen)
+			wg.Done()
+		}(r, s.schedules.For(r.Name).Contains(now))
+	}
+
+	wg.Wait()
+
+	if err := s.global.versionCache.Save(); err != nil {
+		slog.Warn("Failed to persist version cache", slog.Any("error", err))
+	}
+
+	return wait
+}
+
+// Get the time at which the repository is next due. Returns the zero time if
+// the repository's schedule never opens.
+func (s *Scheduler) nextDue(repo domain.Repository, now time.Time) time.Time {
+	earliest := now
+
+	if last, ok := s.lastRun[repo.Name]; ok {
+		if e := last.Add(s.interval); e.After(now) {
+			earliest = e
+		}
+	}
+
+	if overridesSchedule(s.global, repo) {
+		return earliest
+	}
+
+	return s.schedules.For(repo.Name).Next(earliest)
+}
@@ -0,0 +1,313 @@
+package dependency
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// Tables of Cargo.toml holding dependencies
+var cargoSections = map[string]bool{
+	"dependencies":       true,
+	"dev-dependencies":   true,
+	"build-dependencies": true,
+}
+
+// Matches version requirements dependy can update: a single, possibly
+// partial, version optionally preceded by the ^, ~ or = operator which is
+// kept on update
+var cargoReqRe = regexp.MustCompile(`^((?:\^|~|=)?\s*)([0-9]+(?:\.[0-9]+){0,2}(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)$`)
+
+type CargoConfig struct {
+	VersionSource string                   // Where versions are looked up, either registry (default) or depsdev
+	Index         source.CratesIndexConfig // Sparse index versions are looked up in
+}
+
+func NewCargoDependencyManager(config CargoConfig, cache *source.CacheStore) (*CargoDependencyManager, error) {
+	c := &CargoDependencyManager{}
+
+	versions, err := selectVersionSource(c.GetEcosystem(), config.VersionSource, source.NewCratesIndex(config.Index), source.SystemCargo)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Source = cache.Wrap(c.GetEcosystem(), versions)
+
+	return c, nil
+}
+
+type CargoDependencyManager struct {
+	Source  domain.VersionSource
+	content []byte
+	name    string                    // Name of the package declared by the file
+	members map[string]string         // Crate files of the repository, by name
+	reqs    []cargoRequirement        // Updatable version requirements, in file order
+	applied map[string]domain.Version // Applied updates by crate name
+}
+
+// An updatable version requirement in Cargo.toml
+type cargoRequirement struct {
+	Crate  string // Name of the crate in the registry, which a `package` key can make differ from the dependency's
+	Prefix string // Operator kept on update, including any whitespace after it
+	Raw    unstable.Range
+}
+
+// A dependency entry as it is assembled from the keys defining it
+type cargoEntry struct {
+	Key     string // Name used in Cargo.toml
+	Package string
+	Version string
+	Raw     unstable.Range
+	Local   bool // Taken from a path, git repository, alternative registry or the workspace
+}
+
+func (*CargoDependencyManager) GetName() string {
+	return "CargoManager"
+}
+
+func (*CargoDependencyManager) GetEcosystem() string {
+	return "crates.io"
+}
+
+func (*CargoDependencyManager) GetFileName() string {
+	return "Cargo.toml"
+}
+
+// Dependencies may be given as a plain requirement, an inline table or a
+// table of their own. Those taken from a path, git repository, alternative
+// registry or inherited from the workspace are skipped, as are requirements
+// which are not a single version.
+func (c *CargoDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	entries := make([]*cargoEntry, 0)
+	byPath := make(map[string]*cargoEntry)
+
+	entry := func(section []string, key string) *cargoEntry {
+		p := strings.Join(section, "\x00") + "\x00" + key
+
+		e, ok := byPath[p]
+		if !ok {
+			e = &cargoEntry{Key: key}
+			byPath[p] = e
+			entries = append(entries, e)
+		}
+
+		return e
+	}
+
+	c.content = contents
+	c.name = ""
+	c.reqs = make([]cargoRequirement, 0)
+	c.applied = make(map[string]domain.Version)
+
+	p := unstable.Parser{}
+	p.Reset(contents)
+
+	table := make([]string, 0)
+
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = tomlKey(expr.Key())
+		case unstable.KeyValue:
+			key := append(append([]string{}, table...), tomlKey(expr.Key())...)
+			value := expr.Value()
+
+			if len(key) == 2 && key[0] == "package" && key[1] == "name" && value.Kind == unstable.String {
+				c.name = string(value.Data)
+			}
+
+			switch {
+			case isCargoSection(key[:len(key)-1]):
+				e := entry(key[:len(key)-1], key[len(key)-1])
+
+				if value.Kind == unstable.String {
+					e.setVersion(value)
+					continue
+				}
+
+				if value.Kind != unstable.InlineTable {
+					continue
+				}
+
+				it := value.Children()
+				for it.Next() {
+					field := it.Node()
+					e.setField(tomlKey(field.Key()), field.Value())
+				}
+			case len(key) > 2 && isCargoSection(key[:len(key)-2]):
+				// Either [dependencies.foo] or a dotted key such as foo.version
+				entry(key[:len(key)-2], key[len(key)-2]).setField(key[len(key)-1:], value)
+			}
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+
+	newDeps := make([]domain.Dependency, 0)
+	seen := make(map[string]bool)
+
+	for _, e := range entries {
+		if e.Local || e.Version == "" {
+			continue
+		}
+
+		m := cargoReqRe.FindStringSubmatch(e.Version)
+		if m == nil {
+			slog.Debug(
+				"Skipping dependency with unsupported version requirement",
+				slog.String("dependency", e.Key),
+				slog.String("version", e.Version),
+			)
+
+			continue
+		}
+
+		v, err := domain.ParseVersion(domain.Semver, m[2])
+		if err != nil {
+			continue
+		}
+
+		crate := e.Key
+		if e.Package != "" {
+			crate = e.Package
+		}
+
+		c.reqs = append(c.reqs, cargoRequirement{Crate: crate, Prefix: m[1], Raw: e.Raw})
+
+		// The same crate may be required by several tables
+		if seen[crate] {
+			continue
+		}
+
+		seen[crate] = true
+
+		newDeps = append(newDeps, domain.Dependency{Name: crate, Version: v})
+	}
+
+	// Keys of a dependency's table need not be next to each other
+	sort.Slice(c.reqs, func(i, j int) bool { return c.reqs[i].Raw.Offset < c.reqs[j].Raw.Offset })
+
+	return newDeps, nil
+}
+
+func (e *cargoEntry) setVersion(value *unstable.Node) {
+	e.Version = string(value.Data)
+	e.Raw = value.Raw
+}
+
+// Record a key of the dependency's table
+func (e *cargoEntry) setField(key []string, value *unstable.Node) {
+	if len(key) != 1 {
+		return
+	}
+
+	switch key[0] {
+	case "version":
+		if value.Kind == unstable.String {
+			e.setVersion(value)
+		}
+	case "package":
+		e.Package = string(value.Data)
+	case "path", "git", "registry", "workspace":
+		e.Local = true
+	}
+}
+
+// Whether the table at key lists dependencies, i.e. [dependencies],
+// [workspace.dependencies] or [target.'cfg(unix)'.dev-dependencies]
+func isCargoSection(key []string) bool {
+	switch len(key) {
+	case 1:
+		return cargoSections[key[0]]
+	case 2:
+		return key[0] == "workspace" && key[1] == "dependencies"
+	case 3:
+		return key[0] == "target" && cargoSections[key[2]]
+	default:
+		return false
+	}
+}
+
+func tomlKey(it unstable.Iterator) []string {
+	key := make([]string, 0)
+
+	for it.Next() {
+		key = append(key, string(it.Node().Data))
+	}
+
+	return key
+}
+
+func (c *CargoDependencyManager) GetManifestName() string {
+	return c.name
+}
+
+func (c *CargoDependencyManager) SetWorkspace(_ string, members map[string]string, _ func(string) ([]byte, error)) {
+	c.members = members
+}
+
+// Crates of the same workspace are taken from their path
+func (c *CargoDependencyManager) IsLocal(name string) bool {
+	_, ok := c.members[name]
+	return ok
+}
+
+func (c *CargoDependencyManager) GetVersionSource() domain.VersionSource {
+	return c.Source
+}
+
+func (*CargoDependencyManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+func (c *CargoDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	for _, r := range c.reqs {
+		if r.Crate == dependency.Name {
+			c.applied[dependency.Name] = dependency.Version
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+// Requirements are rewritten in place, keeping comments and formatting
+func (c *CargoDependencyManager) GetFile() ([]byte, error) {
+	out := make([]byte, 0, len(c.content))
+	last := 0
+
+	for _, r := range c.reqs {
+		v, ok := c.applied[r.Crate]
+		if !ok {
+			continue
+		}
+
+		start, end := int(r.Raw.Offset), int(r.Raw.Offset+r.Raw.Length)
+		raw := c.content[start:end]
+
+		// Keep the quoting style, which may be a multi-line string
+		quote := raw[:1]
+		if bytes.HasPrefix(raw, []byte(`"""`)) || bytes.HasPrefix(raw, []byte("'''")) {
+			quote = raw[:3]
+		}
+
+		out = append(out, c.content[last:start]...)
+		out = append(out, quote...)
+		out = append(out, r.Prefix+v.String()...)
+		out = append(out, quote...)
+		last = end
+	}
+
+	return append(out, c.content[last:]...), nil
+}
@@ -0,0 +1,106 @@
+package dependency_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+)
+
+const cargoToml = `[package]
+name = "app"
+
+[workspace.dependencies]
+serde = { version = "1.0", features = ["derive"] } # shared
+
+[dependencies]
+# Logging
+log = "^0.4.20"
+rand = { workspace = true }
+local = { path = "../local", version = "0.1" }
+tokio.version = '~1.35.0'
+any = "*"
+
+[dependencies.json]
+package = "serde_json"
+version = "1.0.100"
+
+[target.'cfg(unix)'.dev-dependencies]
+log = "0.4"
+`
+
+func TestCargoManager(t *testing.T) {
+	m, err := dependency.NewCargoDependencyManager(dependency.CargoConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte(cargoToml))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, d := range deps {
+		got[d.Name] = d.Version.String()
+	}
+
+	want := map[string]string{"serde": "1.0", "log": "0.4.20", "tokio": "1.35.0", "serde_json": "1.0.100"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	if m.GetManifestName() != "app" {
+		t.Errorf("unexpected manifest name %q", m.GetManifestName())
+	}
+
+	for _, u := range []domain.Dependency{
+		{Name: "serde", Version: domain.MustParseVersion(domain.Semver, "1.0.195")},
+		{Name: "log", Version: domain.MustParseVersion(domain.Semver, "0.4.21")},
+		{Name: "tokio", Version: domain.MustParseVersion(domain.Semver, "1.36.0")},
+		{Name: "serde_json", Version: domain.MustParseVersion(domain.Semver, "1.0.111")},
+	} {
+		if err := m.ApplyDependency(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		`"1.0", features`, `"1.0.195", features`,
+		`"^0.4.20"`, `"^0.4.21"`,
+		`'~1.35.0'`, `'~1.36.0'`,
+		`"1.0.100"`, `"1.0.111"`,
+		`log = "0.4"`, `log = "0.4.21"`,
+	).Replace(cargoToml)
+
+	if string(file) != expected {
+		t.Errorf("unexpected Cargo.toml:\n%s\nwant:\n%s", file, expected)
+	}
+}
+
+func TestCargoVersionSource(t *testing.T) {
+	m, err := dependency.NewCargoDependencyManager(dependency.CargoConfig{VersionSource: dependency.VersionSourceDepsDev}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := m.GetVersionSource().GetName(); name != "DepsDev" {
+		t.Errorf("expected versions to be looked up in deps.dev, got %s", name)
+	}
+
+	if _, err := dependency.NewCargoDependencyManager(dependency.CargoConfig{VersionSource: "bogus"}, nil); err == nil {
+		t.Error("expected an unknown version source to be rejected")
+	}
+}
@@ -0,0 +1,83 @@
+package dependency
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"golang.org/x/mod/modfile"
+)
+
+const directivePrefix = "dependy:"
+
+// Inline directives attached to a single dependency in a manifest
+//
+// Developers can annotate a dependency with a comment such as
+// `// dependy:ignore` or `// dependy:pin <=v1.8` to keep the intent next to
+// the dependency it affects.
+type directives struct {
+	Ignore bool
+	Pin    domain.Constraint
+}
+
+// Extract dependy directives from the comments attached to a go.mod line
+//
+// Both whole-line comments directly above the requirement and end-of-line
+// comments are considered. Multiple directives can share a comment when
+// separated by `;`, which is also how the go tool combines them with
+// `// indirect`. Unknown or malformed directives are logged and ignored so a
+// typo does not stop the rest of the file from being updated.
+func parseModDirectives(name string, line *modfile.Line) directives {
+	var d directives
+
+	if line == nil {
+		return d
+	}
+
+	comments := make([]modfile.Comment, 0, len(line.Before)+len(line.Suffix))
+	comments = append(comments, line.Before...)
+	comments = append(comments, line.Suffix...)
+
+	for _, c := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Token, "//"))
+
+		for _, part := range strings.Split(text, ";") {
+			part = strings.TrimSpace(part)
+			if !strings.HasPrefix(part, directivePrefix) {
+				continue
+			}
+
+			if err := d.apply(strings.TrimPrefix(part, directivePrefix)); err != nil {
+				slog.Warn("Ignoring invalid directive", slog.String("dependency", name), slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return d
+}
+
+func (d *directives) apply(directive string) error {
+	name, arg, _ := strings.Cut(directive, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "ignore":
+		d.Ignore = true
+	case "pin":
+		if arg == "" {
+			return fmt.Errorf("directive %s%s requires a version constraint", directivePrefix, name)
+		}
+
+		c, err := domain.NewSemverConstraint(arg)
+		if err != nil {
+			return fmt.Errorf("invalid constraint for %s%s: %w", directivePrefix, name, err)
+		}
+
+		d.Pin = c
+	default:
+		return fmt.Errorf("unknown directive %s%s", directivePrefix, name)
+	}
+
+	return nil
+}
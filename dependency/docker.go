@@ -0,0 +1,445 @@
+package dependency
+
+import (
+	"errors"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+type DockerConfig struct {
+	Registries map[string]source.RegistryConfig // Registry settings keyed by host, i.e. docker.io or ghcr.io
+	PinDigests bool                             // Pin updated images to the digest of their new tag
+}
+
+func NewDockerDependencyManager(config DockerConfig, cache *source.CacheStore) *DockerDependencyManager {
+	registry := source.NewOCIRegistry(config.Registries)
+
+	d := &DockerDependencyManager{
+		Registry:   registry,
+		pinDigests: config.PinDigests,
+	}
+	d.Source = &dockerVersionSource{VersionSource: cache.Wrap(d.GetEcosystem(), registry)}
+
+	return d
+}
+
+// Manager of the base images of Dockerfiles
+//
+// Images referenced by FROM instructions are updated when tagged with a
+// version, i.e. golang:1.23.1 or python:3.12-slim. Tags such as latest are
+// left alone. Build arguments declared before the first FROM are substituted
+// and, when a tag comes from one, its default value is updated instead.
+type DockerDependencyManager struct {
+	Registry   *source.OCIRegistry
+	Source     domain.VersionSource
+	pinDigests bool
+	content    []byte
+	images     []dockerImage
+	reported   map[string]string       // Tag reported for each image
+	applied    map[string]dockerUpdate // Applied updates by image name
+}
+
+// Base image of a build stage
+type dockerImage struct {
+	Name      string
+	Tag       string
+	Digest    string // Empty unless the image is pinned
+	tagOffs   []int  // Offset in the file of every byte of the tag, which may lie in a build argument
+	digestOff []int
+	end       int // Offset just past the image in the FROM instruction
+}
+
+type dockerUpdate struct {
+	Tag    string
+	Digest string // Empty if the image is not to be pinned
+}
+
+// Default value of a build argument
+type dockerArg struct {
+	Value  string
+	Offset int
+}
+
+// Whitespace separated word of an instruction
+type dockerField struct {
+	Text   string
+	Offset int
+}
+
+func (*DockerDependencyManager) GetName() string {
+	return "DockerManager"
+}
+
+func (*DockerDependencyManager) GetEcosystem() string {
+	return "Docker"
+}
+
+func (*DockerDependencyManager) GetFileName() string {
+	return "Dockerfile"
+}
+
+func (*DockerDependencyManager) MatchFile(p string) bool {
+	base := path.Base(p)
+	return base == "Dockerfile" || strings.HasSuffix(base, ".Dockerfile")
+}
+
+// Images used by several stages are reported once, with the tag of their
+// first stage
+func (d *DockerDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	d.content = contents
+	d.images = make([]dockerImage, 0)
+	d.reported = make(map[string]string)
+	d.applied = make(map[string]dockerUpdate)
+
+	args := make(map[string]dockerArg)
+	stages := make(map[string]bool)
+	seenFrom := false
+	newDeps := make([]domain.Dependency, 0)
+
+	for start := 0; start < len(contents); {
+		end := instructionEnd(contents, start)
+		fields := splitInstruction(string(contents[start:end]), start)
+		start = end
+
+		if len(fields) == 0 || strings.HasPrefix(fields[0].Text, "#") {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0].Text) {
+		case "ARG":
+			// Only arguments declared before the first stage are in scope
+			// of FROM instructions
+			if !seenFrom {
+				parseArgs(fields[1:], args)
+			}
+		case "FROM":
+			seenFrom = true
+
+			img, stage, ok := parseFrom(fields[1:], args, stages)
+			if stage != "" {
+				stages[stage] = true
+			}
+
+			if !ok {
+				continue
+			}
+
+			v, err := domain.ParseVersion(source.DockerTag, img.Tag)
+			if err != nil {
+				slog.Debug("Skipping image without a version tag", slog.String("image", img.Name), slog.String("tag", img.Tag))
+				continue
+			}
+
+			d.images = append(d.images, img)
+
+			if _, ok := d.reported[img.Name]; ok {
+				continue
+			}
+
+			d.reported[img.Name] = img.Tag
+			newDeps = append(newDeps, domain.Dependency{Name: img.Name, Version: v})
+		}
+	}
+
+	return newDeps, nil
+}
+
+// Find the end of the instruction starting at start, following lines
+// continued with a backslash
+func instructionEnd(contents []byte, start int) int {
+	for i := start; i < len(contents); i++ {
+		if contents[i] != '\n' {
+			continue
+		}
+
+		if !strings.HasSuffix(strings.TrimRight(string(contents[start:i]), "\r"), "\\") {
+			return i + 1
+		}
+	}
+
+	return len(contents)
+}
+
+// Split an instruction into its words. Line continuations separate words
+// like whitespace does.
+func splitInstruction(text string, offset int) []dockerField {
+	fields := make([]dockerField, 0)
+	begin := -1
+
+	for i := 0; i <= len(text); i++ {
+		space := i == len(text) || strings.IndexByte(" \t\r\n", text[i]) >= 0 ||
+			(text[i] == '\\' && strings.HasPrefix(strings.TrimLeft(text[i+1:], " \t\r"), "\n"))
+
+		switch {
+		case space && begin >= 0:
+			fields = append(fields, dockerField{Text: text[begin:i], Offset: offset + begin})
+			begin = -1
+		case !space && begin < 0:
+			begin = i
+		}
+	}
+
+	return fields
+}
+
+// Record the default values of the arguments declared by an ARG
+// instruction, i.e. ARG GO_VERSION=1.23.1
+func parseArgs(fields []dockerField, args map[string]dockerArg) {
+	for _, f := range fields {
+		name, value, ok := strings.Cut(f.Text, "=")
+		if !ok {
+			continue
+		}
+
+		offset := f.Offset + len(name) + 1
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+			offset++
+		}
+
+		args[name] = dockerArg{Value: value, Offset: offset}
+	}
+}
+
+// Read the image of a FROM instruction along with the name of the stage it
+// starts, if any. Returns false for images which can not be updated, such as
+// scratch, earlier stages or untagged images.
+func parseFrom(fields []dockerField, args map[string]dockerArg, stages map[string]bool) (dockerImage, string, bool) {
+	for len(fields) > 0 && strings.HasPrefix(fields[0].Text, "--") {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return dockerImage{}, "", false
+	}
+
+	stage := ""
+	if len(fields) >= 3 && strings.EqualFold(fields[1].Text, "AS") {
+		stage = strings.ToLower(fields[2].Text)
+	}
+
+	ref, offs, ok := expandArgs(fields[0], args)
+	if !ok || ref == "scratch" || stages[strings.ToLower(ref)] {
+		return dockerImage{}, stage, false
+	}
+
+	img := dockerImage{end: fields[0].Offset + len(fields[0].Text)}
+
+	if at := strings.Index(ref, "@"); at >= 0 {
+		img.Digest, img.digestOff = ref[at+1:], offs[at+1:]
+		ref, offs = ref[:at], offs[:at]
+	}
+
+	colon := strings.LastIndex(ref, ":")
+	if colon < 0 || colon < strings.LastIndex(ref, "/") {
+		return dockerImage{}, stage, false
+	}
+
+	img.Name = ref[:colon]
+	img.Tag, img.tagOffs = ref[colon+1:], offs[colon+1:]
+
+	return img, stage, img.Name != "" && img.Tag != ""
+}
+
+// Substitute build arguments into an image reference, mapping every byte of
+// the result to its offset in the file. Returns false if an argument has no
+// default value or uses a modifier such as ${NAME:-default}.
+func expandArgs(f dockerField, args map[string]dockerArg) (string, []int, bool) {
+	var (
+		out  strings.Builder
+		offs = make([]int, 0, len(f.Text))
+	)
+
+	text := f.Text
+
+	for i := 0; i < len(text); {
+		if text[i] != '$' {
+			out.WriteByte(text[i])
+			offs = append(offs, f.Offset+i)
+			i++
+
+			continue
+		}
+
+		var name string
+
+		if strings.HasPrefix(text[i:], "${") {
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return "", nil, false
+			}
+
+			name = text[i+2 : i+end]
+			i += end + 1
+		} else {
+			j := i + 1
+			for j < len(text) && (text[j] == '_' || isAlphanumeric(text[j])) {
+				j++
+			}
+
+			name = text[i+1 : j]
+			i = j
+		}
+
+		arg, ok := args[name]
+		if !ok || name == "" || arg.Value == "" {
+			return "", nil, false
+		}
+
+		out.WriteString(arg.Value)
+
+		for k := range arg.Value {
+			offs = append(offs, arg.Offset+k)
+		}
+	}
+
+	return out.String(), offs, true
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (d *DockerDependencyManager) GetVersionSource() domain.VersionSource {
+	return d.Source
+}
+
+func (*DockerDependencyManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+// Every stage using the image at the reported tag is moved to the new one.
+// The digest of the new tag is looked up when the image is to be pinned.
+func (d *DockerDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	tag, ok := d.reported[dependency.Name]
+	if !ok {
+		return errors.New("dependency not found in file")
+	}
+
+	update := dockerUpdate{Tag: dependency.Version.String()}
+
+	pinned := d.pinDigests
+	for _, img := range d.images {
+		if img.Name == dependency.Name && img.Tag == tag && img.Digest != "" {
+			pinned = true
+		}
+	}
+
+	if pinned {
+		digest, err := d.Registry.Digest(dependency.Name, update.Tag)
+		if err != nil {
+			return err
+		}
+
+		update.Digest = digest
+	}
+
+	d.applied[dependency.Name] = update
+
+	return nil
+}
+
+// Tags are rewritten in place, in the FROM instruction or the build argument
+// they come from
+func (d *DockerDependencyManager) GetFile() ([]byte, error) {
+	edits := make([]textEdit, 0)
+
+	for _, img := range d.images {
+		u, ok := d.applied[img.Name]
+		if !ok || img.Tag != d.reported[img.Name] {
+			continue
+		}
+
+		tagEdit, tagOk := spliceEdit(img.Tag, u.Tag, img.tagOffs)
+
+		digestEdit, digestOk := textEdit{}, true
+
+		switch {
+		case u.Digest == "":
+		case img.Digest != "":
+			digestEdit, digestOk = spliceEdit(img.Digest, u.Digest, img.digestOff)
+		default:
+			digestEdit = textEdit{Start: img.end, End: img.end, Text: "@" + u.Digest}
+		}
+
+		if !tagOk || !digestOk {
+			slog.Warn(
+				"Image reference can not be updated in place",
+				slog.String("image", img.Name),
+				slog.String("tag", img.Tag),
+			)
+
+			continue
+		}
+
+		edits = append(edits, tagEdit, digestEdit)
+	}
+
+	return applyEdits(d.content, edits), nil
+}
+
+// Find the smallest edit turning old into new, given the offset in the file
+// of every byte of old. Returns false if the bytes to replace are not
+// contiguous in the file.
+func spliceEdit(old string, new string, offs []int) (textEdit, bool) {
+	if old == new {
+		return textEdit{}, true
+	}
+
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	text := new[prefix : len(new)-suffix]
+	end := len(old) - suffix
+
+	// Insertions attach to the byte before them when there is one
+	if prefix == end {
+		at := offs[0]
+		if prefix > 0 {
+			at = offs[prefix-1] + 1
+		}
+
+		return textEdit{Start: at, End: at, Text: text}, true
+	}
+
+	for i := prefix + 1; i < end; i++ {
+		if offs[i] != offs[i-1]+1 {
+			return textEdit{}, false
+		}
+	}
+
+	return textEdit{Start: offs[prefix], End: offs[end-1] + 1, Text: text}, true
+}
+
+// Only tags of the variant in use are proposed, so an image on 1.23.1-alpine
+// does not move to 1.24 or 1.23.2-bookworm
+type dockerVersionSource struct {
+	domain.VersionSource
+}
+
+func (r *dockerVersionSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	versions, err := r.VersionSource.FetchVersions(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		if !source.SameDockerVariant(dep.Version, versions[i].Version) {
+			versions[i].Excluded = "different image variant"
+		}
+	}
+
+	return versions, nil
+}
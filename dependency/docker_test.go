@@ -0,0 +1,97 @@
+package dependency_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const dockerfile = `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22.7
+ARG REGISTRY=ghcr.io/org
+
+FROM golang:${GO_VERSION}-alpine AS build
+RUN make bin
+
+FROM --platform=$BUILDPLATFORM ${REGISTRY}/runtime:1.4@sha256:old AS runtime
+
+FROM build AS test
+
+FROM scratch
+COPY --from=build /src/bin /
+`
+
+func TestDockerManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/golang/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["1.22.7-alpine", "1.22.10-alpine", "1.23-alpine", "1.23.1", "latest"]}`))
+		case "/v2/org/runtime/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["1.4", "1.5", "1.5.0"]}`))
+		case "/v2/org/runtime/manifests/1.5":
+			w.Header().Set("Docker-Content-Digest", "sha256:new")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := dependency.NewDockerDependencyManager(dependency.DockerConfig{
+		Registries: map[string]source.RegistryConfig{
+			"docker.io": {URL: server.URL},
+			"ghcr.io":   {URL: server.URL},
+		},
+	}, nil)
+
+	if !m.MatchFile("build.Dockerfile") || !m.MatchFile("deploy/Dockerfile") || m.MatchFile("Dockerfile.dockerignore") {
+		t.Error("unexpected file matches")
+	}
+
+	deps, err := m.ParseFile([]byte(dockerfile))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 ||
+		deps[0].Name != "golang" || deps[0].Version.String() != "1.22.7-alpine" ||
+		deps[1].Name != "ghcr.io/org/runtime" || deps[1].Version.String() != "1.4" {
+		t.Fatalf("unexpected dependencies %+v", deps)
+	}
+
+	for _, d := range deps {
+		versions, err := m.GetVersionSource().FetchVersions(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		latest, ok := versions.Latest(nil)
+		if !ok {
+			t.Fatalf("no update for %s", d.Name)
+		}
+
+		if err := m.ApplyDependency(domain.Dependency{Name: d.Name, Version: latest.Version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only tags of the same variant are proposed and pinned images keep
+	// their digest up to date
+	expected := strings.NewReplacer(
+		"GO_VERSION=1.22.7", "GO_VERSION=1.22.10",
+		"runtime:1.4@sha256:old", "runtime:1.5@sha256:new",
+	).Replace(dockerfile)
+
+	if string(file) != expected {
+		t.Errorf("unexpected Dockerfile:\n%s\nwant:\n%s", file, expected)
+	}
+}
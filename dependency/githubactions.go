@@ -0,0 +1,290 @@
+package dependency
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"gopkg.in/yaml.v3"
+)
+
+// Matches a full commit SHA an action may be pinned to
+var actionCommitRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Matches the version noted in the comment following an action pinned to a
+// commit, i.e. # v4.1.1 or # tag=v4.1.1
+var actionCommentRe = regexp.MustCompile(`^[ \t]+#[ \t]*(?:tag[=:][ \t]*)?(v?[0-9]+(?:\.[0-9]+)*(?:-[0-9A-Za-z.-]+)?)`)
+
+type GitHubActionsConfig struct {
+	API        source.GitHubConfig // GitHub API the tags of actions are listed from
+	PinCommits bool                // Pin actions referenced by tag to the commit of the tag, noting the tag in a comment
+}
+
+func NewGitHubActionsDependencyManager(config GitHubActionsConfig, cache *source.CacheStore) *GitHubActionsDependencyManager {
+	tags := source.NewTagSource("GitHubTags", source.NewGitHub(config.API))
+
+	a := &GitHubActionsDependencyManager{Tags: tags, pinCommits: config.PinCommits}
+	a.Source = &tagVersionSource{VersionSource: cache.Wrap(a.GetEcosystem(), tags)}
+
+	return a
+}
+
+// Manager of the actions used by GitHub Actions workflows in
+// .github/workflows
+//
+// Actions and reusable workflows are named after their repository, i.e.
+// actions/checkout, and updated when referenced by a version tag. Actions
+// pinned to a commit are updated too when the comment following them notes
+// their version, as written by pinning tools. Local actions, Docker images
+// and actions referenced by branch are left alone. Actions referenced at
+// several versions are reported with the version of their first reference,
+// and only references at that version are updated.
+type GitHubActionsDependencyManager struct {
+	Tags       *source.TagSource
+	Source     domain.VersionSource
+	pinCommits bool
+	content    []byte
+	uses       []actionRef
+	reported   map[string]string       // Version reported for each repository
+	applied    map[string]actionUpdate // Applied updates by repository
+}
+
+// Reference to an action in a uses key
+type actionRef struct {
+	Name         string
+	Version      string // Version tag as written, in the comment of a pinned ref
+	Start        int    // Offset of the ref following the @
+	End          int
+	Pinned       bool // Whether the ref is a commit, its version noted in a comment
+	CommentStart int  // Offset of the version in the comment of a pinned ref
+	CommentEnd   int
+	ValueEnd     int // Offset just past the value, including any quote
+}
+
+type actionUpdate struct {
+	Tag    string
+	Commit string // Empty unless the action is pinned
+}
+
+func (*GitHubActionsDependencyManager) GetName() string {
+	return "GitHubActionsManager"
+}
+
+func (*GitHubActionsDependencyManager) GetEcosystem() string {
+	return "GitHub Actions"
+}
+
+func (*GitHubActionsDependencyManager) GetFileName() string {
+	return "workflow.yml"
+}
+
+// Workflows are the YAML files of .github/workflows
+func (*GitHubActionsDependencyManager) MatchFile(p string) bool {
+	ext := path.Ext(p)
+	return path.Dir(p) == ".github/workflows" && (ext == ".yml" || ext == ".yaml")
+}
+
+func (a *GitHubActionsDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, err
+	}
+
+	a.content = contents
+	a.uses = make([]actionRef, 0)
+	a.reported = make(map[string]string)
+	a.applied = make(map[string]actionUpdate)
+
+	newDeps := make([]domain.Dependency, 0)
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return newDeps, nil
+	}
+
+	jobs := yamlValue(doc.Content[0], "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return newDeps, nil
+	}
+
+	for i := 1; i < len(jobs.Content); i += 2 {
+		job := jobs.Content[i]
+		if job.Kind != yaml.MappingNode {
+			continue
+		}
+
+		// Jobs either call a reusable workflow or run steps
+		uses := make([]*yaml.Node, 0)
+
+		if u := yamlValue(job, "uses"); u != nil {
+			uses = append(uses, u)
+		}
+
+		if steps := yamlValue(job, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+			for _, step := range steps.Content {
+				if step.Kind != yaml.MappingNode {
+					continue
+				}
+
+				if u := yamlValue(step, "uses"); u != nil {
+					uses = append(uses, u)
+				}
+			}
+		}
+
+		for _, u := range uses {
+			if d, ok := a.addUse(u); ok {
+				newDeps = append(newDeps, d)
+			}
+		}
+	}
+
+	return dedupeDependencies(newDeps), nil
+}
+
+// Record a reference of the form <owner>/<repo>[/<path>]@<ref>
+func (a *GitHubActionsDependencyManager) addUse(n *yaml.Node) (domain.Dependency, bool) {
+	value := n.Value
+
+	if n.Kind != yaml.ScalarNode || strings.HasPrefix(value, "./") || strings.HasPrefix(value, "docker://") {
+		return domain.Dependency{}, false
+	}
+
+	at := strings.LastIndex(value, "@")
+	if at < 0 {
+		return domain.Dependency{}, false
+	}
+
+	parts := strings.SplitN(value[:at], "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return domain.Dependency{}, false
+	}
+
+	name := parts[0] + "/" + parts[1]
+
+	offset, ok := yamlScalarOffset(a.content, n)
+	if !ok {
+		slog.Debug("Skipping action with escaped reference", slog.String("dependency", name))
+		return domain.Dependency{}, false
+	}
+
+	ref := actionRef{
+		Name:         name,
+		Start:        offset + at + 1,
+		End:          offset + len(value),
+		CommentStart: -1,
+		ValueEnd:     offset + len(value),
+	}
+
+	if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		ref.ValueEnd++
+	}
+
+	version := value[at+1:]
+
+	if actionCommitRe.MatchString(version) {
+		rest := a.content[ref.ValueEnd:]
+		if eol := bytes.IndexByte(rest, '\n'); eol >= 0 {
+			rest = rest[:eol]
+		}
+
+		m := actionCommentRe.FindSubmatchIndex(rest)
+		if m == nil {
+			slog.Debug("Skipping action pinned to a commit without a version comment", slog.String("dependency", name))
+			return domain.Dependency{}, false
+		}
+
+		version = string(rest[m[2]:m[3]])
+		ref.Pinned = true
+		ref.CommentStart, ref.CommentEnd = ref.ValueEnd+m[2], ref.ValueEnd+m[3]
+	}
+
+	v, err := domain.ParseVersion(domain.Semver, version)
+	if err != nil {
+		slog.Debug("Skipping action referenced by branch", slog.String("dependency", name), slog.String("ref", version))
+		return domain.Dependency{}, false
+	}
+
+	ref.Version = version
+	a.uses = append(a.uses, ref)
+
+	if _, ok := a.reported[name]; !ok {
+		a.reported[name] = version
+	}
+
+	return domain.Dependency{Name: name, Version: v}, true
+}
+
+func (a *GitHubActionsDependencyManager) GetVersionSource() domain.VersionSource {
+	return a.Source
+}
+
+func (*GitHubActionsDependencyManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+// The commit of the tag is looked up when the action is to be pinned
+func (a *GitHubActionsDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	version, ok := a.reported[dependency.Name]
+	if !ok {
+		return errors.New("dependency not found in file")
+	}
+
+	pin := a.pinCommits
+
+	for _, r := range a.uses {
+		if r.Name == dependency.Name && r.Version == version {
+			pin = pin || r.Pinned
+		}
+	}
+
+	u := actionUpdate{Tag: dependency.Version.String()}
+
+	if pin {
+		commit, err := a.Tags.Commit(dependency.Name, u.Tag)
+		if err != nil {
+			return err
+		}
+
+		u.Commit = commit
+	}
+
+	a.applied[dependency.Name] = u
+
+	return nil
+}
+
+// References are rewritten in place. Pinned actions keep their comment, with
+// the version in it updated.
+func (a *GitHubActionsDependencyManager) GetFile() ([]byte, error) {
+	edits := make([]textEdit, 0)
+
+	for _, r := range a.uses {
+		u, ok := a.applied[r.Name]
+		if !ok || r.Version != a.reported[r.Name] {
+			continue
+		}
+
+		switch {
+		case r.Pinned:
+			edits = append(edits,
+				textEdit{Start: r.Start, End: r.End, Text: u.Commit},
+				textEdit{Start: r.CommentStart, End: r.CommentEnd, Text: u.Tag},
+			)
+		case a.pinCommits:
+			edits = append(edits,
+				textEdit{Start: r.Start, End: r.End, Text: u.Commit},
+				textEdit{Start: r.ValueEnd, End: r.ValueEnd, Text: " # " + u.Tag},
+			)
+		default:
+			edits = append(edits, textEdit{Start: r.Start, End: r.End, Text: u.Tag})
+		}
+	}
+
+	return applyEdits(a.content, edits), nil
+}
@@ -0,0 +1,129 @@
+package dependency_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/source"
+)
+
+const workflowFile = `name: ci
+on: push
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v3
+      - uses: "actions/setup-go@v4.0.0"
+      - uses: actions/cache@0c45773b623bea8c8e75f6c82b208c3cf94ea4f9 # v4.0.0
+      - uses: ./.github/actions/local
+      - uses: docker://alpine:3.18
+      - uses: org/tool@main
+  release:
+    uses: org/workflows/.github/workflows/release.yml@v1.0.0
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      # Only references at the reported version are updated
+      - uses: actions/checkout@v4.1.0
+`
+
+func githubServer() *httptest.Server {
+	tags := map[string]string{
+		"/repos/actions/checkout/tags": `[{"name": "v4", "commit": {"sha": "b4ffde65f46336ab88eb53be808477a3936bae11"}}, {"name": "v4.1.1", "commit": {"sha": "b4ffde65f46336ab88eb53be808477a3936bae11"}}, {"name": "v3", "commit": {"sha": "f43a0e5ff2bd294095638e18286ca9a3d1956744"}}]`,
+		"/repos/actions/setup-go/tags": `[{"name": "v4.0.0", "commit": {"sha": "1111111111111111111111111111111111111111"}}, {"name": "v5.0.0", "commit": {"sha": "0c52d547c9bc32b1aa3301fd7a9cb496313a4491"}}]`,
+		"/repos/actions/cache/tags":    `[{"name": "v4.0.0", "commit": {"sha": "0c45773b623bea8c8e75f6c82b208c3cf94ea4f9"}}, {"name": "v4.0.2", "commit": {"sha": "0c45773b623bea8c8e75f6c82b208c3cf94ea4f0"}}]`,
+		"/repos/org/workflows/tags":    `[{"name": "v1.0.0", "commit": {"sha": "2222222222222222222222222222222222222222"}}, {"name": "v1.1.0", "commit": {"sha": "3333333333333333333333333333333333333333"}}]`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := tags[r.URL.Path]
+		if !ok || r.Header.Get("Authorization") != "Bearer token" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestGitHubActionsManager(t *testing.T) {
+	server := githubServer()
+	defer server.Close()
+
+	m := dependency.NewGitHubActionsDependencyManager(dependency.GitHubActionsConfig{
+		API: source.GitHubConfig{URL: server.URL, Token: "token"},
+	}, nil)
+
+	if !m.MatchFile(".github/workflows/ci.yml") || m.MatchFile(".github/dependabot.yml") || m.MatchFile("workflows/ci.yml") {
+		t.Error("unexpected file matching")
+	}
+
+	got := applyLatest(t, m, workflowFile)
+
+	want := map[string]string{
+		"actions/checkout": "v3",
+		"actions/setup-go": "v4.0.0",
+		"actions/cache":    "v4.0.0",
+		"org/workflows":    "v1.0.0",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		"checkout@v3\n", "checkout@v4\n",
+		"setup-go@v4.0.0", "setup-go@v5.0.0",
+		"cache@0c45773b623bea8c8e75f6c82b208c3cf94ea4f9 # v4.0.0", "cache@0c45773b623bea8c8e75f6c82b208c3cf94ea4f0 # v4.0.2",
+		"release.yml@v1.0.0", "release.yml@v1.1.0",
+	).Replace(workflowFile)
+
+	if string(file) != expected {
+		t.Errorf("unexpected workflow:\n%s\nwant:\n%s", file, expected)
+	}
+}
+
+func TestGitHubActionsPinCommits(t *testing.T) {
+	server := githubServer()
+	defer server.Close()
+
+	m := dependency.NewGitHubActionsDependencyManager(dependency.GitHubActionsConfig{
+		API:        source.GitHubConfig{URL: server.URL, Token: "token"},
+		PinCommits: true,
+	}, nil)
+
+	applyLatest(t, m, workflowFile)
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		"- uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4\n",
+		`- uses: "actions/setup-go@0c52d547c9bc32b1aa3301fd7a9cb496313a4491" # v5.0.0` + "\n",
+		"cache@0c45773b623bea8c8e75f6c82b208c3cf94ea4f0 # v4.0.2\n",
+		"release.yml@3333333333333333333333333333333333333333 # v1.1.0\n",
+		"- uses: actions/checkout@v4.1.0\n",
+	} {
+		if !strings.Contains(string(file), s) {
+			t.Errorf("expected workflow to contain %q:\n%s", s, file)
+		}
+	}
+}
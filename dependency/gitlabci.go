@@ -0,0 +1,272 @@
+package dependency
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"gopkg.in/yaml.v3"
+)
+
+type GitlabCIConfig struct {
+	Registries map[string]source.RegistryConfig // Settings of OCI registries images are pulled from, keyed by host
+}
+
+// Projects are looked up in tags, which are listed by the remote hosting them
+func NewGitlabCIDependencyManager(config GitlabCIConfig, tags domain.TagLister, cache *source.CacheStore) *GitlabCIDependencyManager {
+	g := &GitlabCIDependencyManager{}
+
+	g.Source = &gitlabCISources{
+		projects: &tagVersionSource{VersionSource: cache.Wrap(g.GetEcosystem(), source.NewTagSource("GitlabTags", tags))},
+		images:   &dockerVersionSource{VersionSource: cache.Wrap("Docker", source.NewOCIRegistry(config.Registries))},
+	}
+
+	return g
+}
+
+// Manager of GitLab CI/CD configurations in .gitlab-ci.yml
+//
+// Files included from other projects are updated when their ref is a
+// version tag, as are the versions of CI/CD components, and both are named
+// after the path of the project they come from. Images of jobs and services
+// are updated when tagged with a version, like base images of Dockerfiles.
+// References built from CI/CD variables are left alone. Projects and images
+// referenced at several versions are reported with the version of their
+// first reference, and only references at that version are updated.
+type GitlabCIDependencyManager struct {
+	Source   domain.VersionSource
+	content  []byte
+	refs     []ciRef
+	reported map[string]string         // Version reported for each project path or image name
+	applied  map[string]domain.Version // Applied updates by project path or image name
+}
+
+// Location of a version tag in a CI configuration
+type ciRef struct {
+	Name    string
+	Version string // Version as written
+	Start   int
+	End     int
+}
+
+func (*GitlabCIDependencyManager) GetName() string {
+	return "GitlabCIManager"
+}
+
+func (*GitlabCIDependencyManager) GetEcosystem() string {
+	return "GitLab CI"
+}
+
+func (*GitlabCIDependencyManager) GetFileName() string {
+	return ".gitlab-ci.yml"
+}
+
+// Top level keys which are neither jobs nor default, whose image applies to
+// every job
+var gitlabCIKeywords = map[string]bool{
+	"variables": true,
+	"workflow":  true,
+	"stages":    true,
+}
+
+func (g *GitlabCIDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, err
+	}
+
+	g.content = contents
+	g.refs = make([]ciRef, 0)
+	g.reported = make(map[string]string)
+	g.applied = make(map[string]domain.Version)
+
+	newDeps := make([]domain.Dependency, 0)
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return newDeps, nil
+	}
+
+	root := doc.Content[0]
+
+	add := func(d domain.Dependency, ok bool) {
+		if ok {
+			newDeps = append(newDeps, d)
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+
+		switch {
+		case key == "include":
+			for _, include := range yamlItems(value) {
+				if include.Kind != yaml.MappingNode {
+					continue
+				}
+
+				if project, ref := yamlValue(include, "project"), yamlValue(include, "ref"); project != nil && ref != nil {
+					add(g.addRef(project.Value, ref, 0, ref.Value, domain.Semver))
+				}
+
+				if component := yamlValue(include, "component"); component != nil {
+					add(g.addComponent(component))
+				}
+			}
+		case key == "image" || key == "services":
+			// Global images, superseded by default
+			for _, image := range yamlItems(value) {
+				add(g.addImage(image))
+			}
+		case !gitlabCIKeywords[key] && value.Kind == yaml.MappingNode:
+			for _, field := range []string{"image", "services"} {
+				if images := yamlValue(value, field); images != nil {
+					for _, image := range yamlItems(images) {
+						add(g.addImage(image))
+					}
+				}
+			}
+		}
+	}
+
+	return dedupeDependencies(newDeps), nil
+}
+
+// Get the items of a sequence node, or the node itself if it is not one
+func yamlItems(n *yaml.Node) []*yaml.Node {
+	if n.Kind == yaml.SequenceNode {
+		return n.Content
+	}
+
+	return []*yaml.Node{n}
+}
+
+// Record a component reference of the form <host>/<project>/<name>@<version>
+func (g *GitlabCIDependencyManager) addComponent(n *yaml.Node) (domain.Dependency, bool) {
+	at := strings.LastIndex(n.Value, "@")
+	if at < 0 {
+		return domain.Dependency{}, false
+	}
+
+	parts := strings.Split(n.Value[:at], "/")
+	if len(parts) < 3 {
+		return domain.Dependency{}, false
+	}
+
+	project := strings.Join(parts[1:len(parts)-1], "/")
+
+	return g.addRef(project, n, at+1, n.Value[at+1:], domain.Semver)
+}
+
+// Record the image of a job or service, given as a name or a mapping with
+// one
+func (g *GitlabCIDependencyManager) addImage(n *yaml.Node) (domain.Dependency, bool) {
+	if n.Kind == yaml.MappingNode {
+		n = yamlValue(n, "name")
+	}
+
+	if n == nil || n.Kind != yaml.ScalarNode || strings.ContainsAny(n.Value, "$@") {
+		return domain.Dependency{}, false
+	}
+
+	colon := strings.LastIndex(n.Value, ":")
+	if colon < 0 || colon < strings.LastIndex(n.Value, "/") {
+		return domain.Dependency{}, false
+	}
+
+	return g.addRef(n.Value[:colon], n, colon+1, n.Value[colon+1:], source.DockerTag)
+}
+
+// Record a version tag found at start within the value of a scalar node
+func (g *GitlabCIDependencyManager) addRef(
+	name string,
+	n *yaml.Node,
+	start int,
+	ref string,
+	scheme domain.VersionScheme,
+) (domain.Dependency, bool) {
+	if name == "" || strings.Contains(name, "$") {
+		return domain.Dependency{}, false
+	}
+
+	v, err := domain.ParseVersion(scheme, ref)
+	if err != nil {
+		slog.Debug("Skipping reference which is not a version", slog.String("dependency", name), slog.String("ref", ref))
+		return domain.Dependency{}, false
+	}
+
+	offset, ok := yamlScalarOffset(g.content, n)
+	if !ok {
+		slog.Debug("Skipping dependency with escaped version", slog.String("dependency", name))
+		return domain.Dependency{}, false
+	}
+
+	g.refs = append(g.refs, ciRef{Name: name, Version: ref, Start: offset + start, End: offset + start + len(ref)})
+
+	if _, ok := g.reported[name]; !ok {
+		g.reported[name] = ref
+	}
+
+	return domain.Dependency{Name: name, Version: v}, true
+}
+
+func (g *GitlabCIDependencyManager) GetVersionSource() domain.VersionSource {
+	return g.Source
+}
+
+func (*GitlabCIDependencyManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+func (g *GitlabCIDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	for _, r := range g.refs {
+		if r.Name == dependency.Name {
+			g.applied[dependency.Name] = dependency.Version
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+// Tags are rewritten in place, keeping comments and formatting
+func (g *GitlabCIDependencyManager) GetFile() ([]byte, error) {
+	edits := make([]textEdit, 0)
+
+	for _, r := range g.refs {
+		if v, ok := g.applied[r.Name]; ok && r.Version == g.reported[r.Name] {
+			edits = append(edits, textEdit{Start: r.Start, End: r.End, Text: v.String()})
+		}
+	}
+
+	return applyEdits(g.content, edits), nil
+}
+
+// Look images up in their registries and projects in their tags, telling
+// them apart by the scheme of their version
+type gitlabCISources struct {
+	projects domain.VersionSource
+	images   domain.VersionSource
+}
+
+func (*gitlabCISources) GetName() string {
+	return "GitlabCISources"
+}
+
+func (s *gitlabCISources) source(dep domain.Dependency) domain.VersionSource {
+	if dep.Version.Scheme() == source.DockerTag {
+		return s.images
+	}
+
+	return s.projects
+}
+
+func (s *gitlabCISources) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	return s.source(dep).FetchVersions(dep)
+}
+
+func (s *gitlabCISources) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	return s.source(dep).FetchVersion(dep, version)
+}
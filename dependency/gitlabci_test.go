@@ -0,0 +1,133 @@
+package dependency_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const gitlabCIFile = `include:
+  - project: 'platform/ci-templates'
+    ref: v1.2.0
+    file: '/templates/build.yml'
+  - project: platform/ci-templates
+    ref: main
+    file: '/templates/lint.yml'
+  - component: $CI_SERVER_FQDN/platform/components/sast@2.1
+  - local: '/ci/test.yml'
+
+image: alpine:3.18
+
+variables:
+  image: "not:1.0"
+
+default:
+  image:
+    name: "python:3.11-slim"
+    entrypoint: [""]
+
+test:
+  image: $CI_REGISTRY_IMAGE:latest
+  services:
+    - postgres:15.4
+    - name: redis:7.0
+      alias: cache
+  script: make test
+
+build:
+  image: golang:1.22-alpine
+  script: make
+
+# Only references at the reported version are updated
+release:
+  image: golang:1.23
+  script: make release
+`
+
+// Tag lister serving fixed tags by project
+type fakeTags map[string][]domain.Tag
+
+func (f fakeTags) ListTags(project string) ([]domain.Tag, error) {
+	tags, ok := f[project]
+	if !ok {
+		return nil, fmt.Errorf("project %s not found", project)
+	}
+
+	return tags, nil
+}
+
+func TestGitlabCIManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/alpine/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["3.18", "3.19", "3.19.1", "edge"]}`))
+		case "/v2/library/python/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["3.11-slim", "3.12-slim", "3.12-alpine"]}`))
+		case "/v2/library/postgres/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["15.4", "16.1"]}`))
+		case "/v2/library/redis/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["7.0", "7.2"]}`))
+		case "/v2/library/golang/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["1.22-alpine", "1.23", "1.23-alpine"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tags := fakeTags{
+		"platform/ci-templates": {{Name: "v1.2.0"}, {Name: "v1.3.0"}, {Name: "v2"}, {Name: "v2.0.0-rc1"}},
+		"platform/components":   {{Name: "2.1"}, {Name: "2.4"}, {Name: "2.4.1"}},
+	}
+
+	m := dependency.NewGitlabCIDependencyManager(dependency.GitlabCIConfig{
+		Registries: map[string]source.RegistryConfig{"docker.io": {URL: server.URL}},
+	}, tags, nil)
+
+	got := applyLatest(t, m, gitlabCIFile)
+
+	want := map[string]string{
+		"platform/ci-templates": "v1.2.0",
+		"platform/components":   "2.1",
+		"alpine":                "3.18",
+		"python":                "3.11-slim",
+		"postgres":              "15.4",
+		"redis":                 "7.0",
+		"golang":                "1.22-alpine",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		"ref: v1.2.0", "ref: v1.3.0",
+		"sast@2.1", "sast@2.4",
+		"alpine:3.18", "alpine:3.19",
+		"python:3.11-slim", "python:3.12-slim",
+		"postgres:15.4", "postgres:16.1",
+		"redis:7.0", "redis:7.2",
+		"golang:1.22-alpine", "golang:1.23-alpine",
+	).Replace(gitlabCIFile)
+
+	if string(file) != expected {
+		t.Errorf("unexpected .gitlab-ci.yml:\n%s\nwant:\n%s", file, expected)
+	}
+}
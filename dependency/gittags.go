@@ -0,0 +1,46 @@
+package dependency
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Matches the release at the start of a tag, i.e. v4 or 1.2.3
+var tagReleaseRe = regexp.MustCompile(`^(v?)([0-9]+(?:\.[0-9]+)*)`)
+
+// Ignore tags written differently from the one in use, so a reference to a
+// moving major tag such as v4 is only moved to v5 rather than to v5.0.1,
+// and one to v1.2.3 never to a tag without its v
+type tagVersionSource struct {
+	domain.VersionSource
+}
+
+func (r *tagVersionSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	versions, err := r.VersionSource.FetchVersions(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, segments := tagForm(dep.Version.String())
+
+	for i := range versions {
+		p, s := tagForm(versions[i].Version.String())
+		if p != prefix || s != segments {
+			versions[i].Excluded = "different tag format"
+		}
+	}
+
+	return versions, nil
+}
+
+// Get the prefix and the number of release segments of a tag
+func tagForm(tag string) (string, int) {
+	m := tagReleaseRe.FindStringSubmatch(tag)
+	if m == nil {
+		return "", 0
+	}
+
+	return m[1], strings.Count(m[2], ".") + 1
+}
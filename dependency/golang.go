@@ -2,30 +2,110 @@ package dependency
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 
-	"github.com/Masterminds/semver/v3"
-	"github.com/edoardottt/depsdev/pkg/depsdev"
 	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 )
 
-func NewGoLangDependencyManager() *GoLangDependencyManager {
-	depsClient := depsdev.NewAPI()
+type GoConfig struct {
+	VersionSource      string               // Where versions are looked up, either depsdev (default) or goproxy
+	Proxy              source.GoProxyConfig // Module proxies used for go.mod lookups and the goproxy version source
+	Track              []TrackConfig        // Modules following the latest commit of a branch rather than tagged releases
+	SkipGoSum          bool                 // Leave go.sum and requirements of the wider module graph untouched
+	Indirect           bool                 // Also update requirements marked `// indirect`
+	UpdateReplacements bool                 // Update the version of replacements pointing at another module rather than a local directory
+	Toolchain          ToolchainConfig      // Updates of the go and toolchain directives
+}
+
+type TrackConfig struct {
+	Module string
+	Branch string
+}
+
+func NewGoLangDependencyManager(config GoConfig, cache *source.CacheStore) (*GoLangDependencyManager, error) {
+	proxy := source.NewGoProxy(config.Proxy)
+
+	var versions domain.VersionSource
+
+	switch config.VersionSource {
+	case VersionSourceDepsDev, "":
+		versions = source.NewDepsDev(source.SystemGo)
+	case VersionSourceGoProxy:
+		versions = proxy
+	default:
+		return nil, fmt.Errorf("unknown Go version source %s", config.VersionSource)
+	}
 
-	return &GoLangDependencyManager{
-		APIClient: depsClient,
+	track := make(map[string]string, len(config.Track))
+	for _, t := range config.Track {
+		track[t.Module] = t.Branch
 	}
+
+	checksums, err := source.NewChecksums(config.Proxy, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GoLangDependencyManager{
+		Proxy:              proxy,
+		Checksums:          checksums,
+		track:              track,
+		indirect:           config.Indirect,
+		updateReplacements: config.UpdateReplacements,
+		toolchain:          config.Toolchain,
+		skipGoSum:          config.SkipGoSum,
+		latestMods:         make(map[string]*modfile.File),
+		mods:               make(map[module.Version]*modfile.File),
+	}
+	// Only the raw lookups are shared, the Go specific handling depends on
+	// the version each repository is currently on
+	g.Source = &goVersionSource{VersionSource: cache.Wrap(g.GetEcosystem(), versions), manager: g}
+	g.releases = cache.Wrap(g.GetEcosystem(), source.NewGoReleases(config.Toolchain.Releases))
+
+	return g, nil
 }
 
 type GoLangDependencyManager struct {
-	ModFile   *modfile.File
-	APIClient *depsdev.API
+	ModFile            *modfile.File
+	Source             domain.VersionSource
+	Proxy              *source.GoProxy   // Module proxy used to read go.mod files of dependencies
+	Checksums          *source.Checksums // Source of go.sum hashes
+	track              map[string]string
+	indirect           bool
+	updateReplacements bool
+	replaceTargets     map[string]*modfile.Replace // Replacements reported as dependencies, by target module path
+	toolchain          ToolchainConfig
+	releases           domain.VersionSource // Go toolchain releases
+	skipGoSum          bool
+	path               string                            // Path of the go.mod within the repository
+	open               func(path string) ([]byte, error) // Reads files of the repository
+	local              map[string]string                 // go.mod paths of other modules of the workspace, by module path
+	required           map[string]string                 // Required versions as parsed, by module path
+	applied            bool                              // Whether any dependency update was applied
+	workFile           string                            // go.work of the workspace the module belongs to relative to the go.mod, if any
+	latestMods         map[string]*modfile.File
+	mods               map[module.Version]*modfile.File
 }
 
+// Matches module paths named in deprecation notices i.e. "use example.com/foo/v2 instead"
+var modulePathRe = regexp.MustCompile(`[a-z0-9.\-]+\.[a-z]{2,}(?:/[A-Za-z0-9._~\-]+)+`)
+
 func (*GoLangDependencyManager) GetName() string {
 	return "GoLangManager"
 }
 
+func (*GoLangDependencyManager) GetEcosystem() string {
+	return "Go"
+}
+
 func (g *GoLangDependencyManager) GetFileName() string {
 	return "go.mod"
 }
@@ -37,20 +117,42 @@ func (g *GoLangDependencyManager) ParseFile(contents []byte) ([]domain.Dependenc
 	}
 
 	g.ModFile = f
+	g.replaceTargets = make(map[string]*modfile.Replace)
+	g.required = make(map[string]string, len(f.Require))
+	g.applied = false
+
+	for _, req := range f.Require {
+		g.required[req.Mod.Path] = req.Mod.Version
+	}
 
 	newDeps := make([]domain.Dependency, 0)
 
 	for _, req := range g.ModFile.Require {
-		var dep domain.Dependency
-		if !req.Indirect {
-			dep.Name = req.Mod.Path
+		if req.Indirect && !g.indirect {
+			continue
+		}
+
+		// The required version of a replaced module is not what gets built,
+		// so bumping it achieves nothing or, for a replacement of a single
+		// version, silently drops the replacement
+		if r := g.findReplace(req.Mod); r != nil {
+			if r.New.Version != "" && g.updateReplacements {
+				if _, ok := g.replaceTargets[r.New.Path]; ok {
+					continue
+				}
 
-			v, err := semver.NewVersion(req.Mod.Version)
-			if err != nil {
-				return nil, err
+				if dep, ok := parseModLine(r.New, r.Syntax); ok {
+					dep.Indirect = req.Indirect
+					g.replaceTargets[r.New.Path] = r
+					newDeps = append(newDeps, dep)
+				}
 			}
 
-			dep.Version = *v
+			continue
+		}
+
+		if dep, ok := parseModLine(req.Mod, req.Syntax); ok {
+			dep.Indirect = req.Indirect
 			newDeps = append(newDeps, dep)
 		}
 	}
@@ -58,34 +160,139 @@ func (g *GoLangDependencyManager) ParseFile(contents []byte) ([]domain.Dependenc
 	return newDeps, nil
 }
 
-func (g *GoLangDependencyManager) FetchLatestVersion(
-	dep domain.Dependency,
-) (semver.Version, error) {
-	defVer := semver.New(0, 0, 0, "", "")
+// Turn a module version found on a go.mod line into a dependency, applying
+// the line's directives. Returns false if the dependency should be skipped.
+func parseModLine(mod module.Version, line *modfile.Line) (domain.Dependency, bool) {
+	d := parseModDirectives(mod.Path, line)
+	if d.Ignore {
+		return domain.Dependency{}, false
+	}
 
-	info, err := g.APIClient.GetInfo("go", dep.Name)
+	v, err := domain.ParseVersion(domain.Semver, mod.Version)
 	if err != nil {
-		return *defVer, err
+		slog.Warn(
+			"Skipping dependency with unrecognised version",
+			slog.String("dependency", mod.Path),
+			slog.String("version", mod.Version),
+		)
+
+		return domain.Dependency{}, false
 	}
 
-	for i := len(info.Versions) - 1; i >= 0; i-- {
-		if info.Versions[i].IsDefault {
-			ver, err := semver.NewVersion(info.Versions[i].VersionKey.Version)
-			if err != nil {
-				return *defVer, err
-			}
+	return domain.Dependency{
+		Name:       mod.Path,
+		Version:    v,
+		Constraint: d.Pin,
+	}, true
+}
+
+func (g *GoLangDependencyManager) GetManifestName() string {
+	if g.ModFile == nil || g.ModFile.Module == nil {
+		return ""
+	}
+
+	return g.ModFile.Module.Mod.Path
+}
+
+// Other modules of the repository are built from the work tree when they
+// share a go.work with the main module. Replacements by a local directory
+// are read as they come up.
+func (g *GoLangDependencyManager) SetWorkspace(p string, members map[string]string, open func(string) ([]byte, error)) {
+	g.path = p
+	g.open = open
+	g.local = make(map[string]string, len(members))
+	g.workFile = ""
+
+	work, uses := g.findWorkspace()
+	if work == "" {
+		return
+	}
+
+	if rel, err := filepath.Rel(path.Dir(p), work); err == nil {
+		g.workFile = filepath.ToSlash(rel)
+	}
+
+	for name, file := range members {
+		if file != p && uses[file] {
+			g.local[name] = file
+		}
+	}
+}
+
+func (g *GoLangDependencyManager) GetVersionSource() domain.VersionSource {
+	return g.Source
+}
 
-			return *ver, nil
+func (g *GoLangDependencyManager) fetchRetractions(path string) ([]*modfile.Retract, error) {
+	f, err := g.fetchLatestModFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Retract, nil
+}
+
+func (g *GoLangDependencyManager) FetchDeprecation(dep domain.Dependency) (*domain.Deprecation, error) {
+	f, err := g.fetchLatestModFile(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Module == nil || f.Module.Deprecated == "" {
+		return nil, nil
+	}
+
+	deprecation := &domain.Deprecation{Message: f.Module.Deprecated}
+
+	for _, candidate := range modulePathRe.FindAllString(f.Module.Deprecated, -1) {
+		candidate = strings.TrimRight(candidate, ".")
+		if candidate != dep.Name && module.CheckPath(candidate) == nil {
+			deprecation.Replacement = candidate
+			break
 		}
 	}
 
-	return *defVer, nil
+	return deprecation, nil
 }
 
 func (g *GoLangDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	g.applied = true
+
+	if dependency.Replaces != "" {
+		return g.replaceRequire(dependency)
+	}
+
+	if r, ok := g.replaceTargets[dependency.Name]; ok {
+		// The replacement is edited in place as AddReplace would drop other
+		// replacements of the same module. The target version is always the
+		// last token of the line.
+		v := source.GoVersion(dependency.Version)
+		r.New.Version = v
+		r.Syntax.Token[len(r.Syntax.Token)-1] = v
+
+		return nil
+	}
+
 	for _, r := range g.ModFile.Require {
 		if r.Mod.Path == dependency.Name {
-			r.Mod.Version = "v" + dependency.Version.String()
+			r.Mod.Version = source.GoVersion(dependency.Version)
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+// Swap the requirement on a module for one on its new major version path
+func (g *GoLangDependencyManager) replaceRequire(dependency domain.Dependency) error {
+	for _, r := range g.ModFile.Require {
+		if r.Mod.Path == dependency.Replaces {
+			if err := g.ModFile.DropRequire(dependency.Replaces); err != nil {
+				return err
+			}
+
+			g.ModFile.AddNewRequire(dependency.Name, source.GoVersion(dependency.Version), r.Indirect)
+
 			return nil
 		}
 	}
@@ -94,6 +301,9 @@ func (g *GoLangDependencyManager) ApplyDependency(dependency domain.Dependency)
 }
 
 func (g *GoLangDependencyManager) GetFile() ([]byte, error) {
+	// Drop requirements removed by replaceRequire before rewriting the rest
+	g.ModFile.Cleanup()
+
 	// Dodgy hack...
 	g.ModFile.SetRequire(g.ModFile.Require)
 
@@ -104,3 +314,81 @@ func (g *GoLangDependencyManager) GetFile() ([]byte, error) {
 
 	return f, nil
 }
+
+// Get the latest go.mod published for a module
+//
+// The go.mod of the latest version is authoritative for module wide
+// metadata such as retractions and deprecation notices.
+func (g *GoLangDependencyManager) fetchLatestModFile(path string) (*modfile.File, error) {
+	if f, ok := g.latestMods[path]; ok {
+		return f, nil
+	}
+
+	info, err := g.Proxy.Latest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := g.Proxy.GoMod(path, info.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := modfile.ParseLax(path+"@"+info.Version+"/go.mod", body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g.latestMods[path] = f
+
+	return f, nil
+}
+
+// Get the module version actually used for m according to the replace
+// directives of the main module. Returns false if m is replaced by a local
+// directory.
+func (g *GoLangDependencyManager) replacement(m module.Version) (module.Version, bool) {
+	match := g.findReplace(m)
+	if match == nil {
+		return m, true
+	}
+
+	return match.New, match.New.Version != ""
+}
+
+// Get the replace directive applying to m, if any
+func (g *GoLangDependencyManager) findReplace(m module.Version) *modfile.Replace {
+	var match *modfile.Replace
+
+	for _, r := range g.ModFile.Replace {
+		if r.Old.Path != m.Path {
+			continue
+		}
+
+		// A replacement of a specific version takes precedence
+		if r.Old.Version == m.Version {
+			match = r
+			break
+		}
+
+		if r.Old.Version == "" {
+			match = r
+		}
+	}
+
+	return match
+}
+
+func (g *GoLangDependencyManager) excluded(m module.Version) bool {
+	if g.ModFile == nil {
+		return false
+	}
+
+	for _, e := range g.ModFile.Exclude {
+		if e.Mod == m {
+			return true
+		}
+	}
+
+	return false
+}
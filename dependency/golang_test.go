@@ -0,0 +1,306 @@
+package dependency_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const directiveModFile = `module example.com/app
+
+go 1.21
+
+require (
+	example.com/ignored v1.0.0 // dependy:ignore
+	// dependy:pin <=v1.8
+	example.com/pinned v1.2.0
+	example.com/plain v1.0.0
+)
+`
+
+func TestParseFileDirectives(t *testing.T) {
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte(directiveModFile))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 dependencies, got %d", len(deps))
+	}
+
+	for _, d := range deps {
+		switch d.Name {
+		case "example.com/pinned":
+			if d.Constraint == nil || d.Constraint.String() != "<=v1.8" {
+				t.Errorf("expected pin <=v1.8, got %v", d.Constraint)
+			}
+		case "example.com/plain":
+			if d.Constraint != nil {
+				t.Errorf("expected no constraint, got %v", d.Constraint)
+			}
+		default:
+			t.Errorf("unexpected dependency %s", d.Name)
+		}
+	}
+}
+
+func TestParseFileInvalidDirective(t *testing.T) {
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const modFile = "module example.com/app\n\nrequire (\n\texample.com/a v1.0.0 // dependy:bogus\n\texample.com/b v1.0.0 // dependy:pin\n\texample.com/c v1.0.0 // dependy:pin not-a-version; dependy:ignore\n)\n"
+
+	deps, err := m.ParseFile([]byte(modFile))
+	if err != nil {
+		t.Fatalf("expected invalid directives to be ignored, got %v", err)
+	}
+
+	if len(deps) != 2 || deps[0].Name != "example.com/a" || deps[1].Name != "example.com/b" {
+		t.Fatalf("expected example.com/a and example.com/b, got %v", deps)
+	}
+
+	for _, d := range deps {
+		if d.Constraint != nil {
+			t.Errorf("expected no constraint on %s, got %v", d.Name, d.Constraint)
+		}
+	}
+}
+
+func TestParseFileIndirect(t *testing.T) {
+	const modFile = "module example.com/app\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.0.0 // indirect\n)\n"
+
+	for _, include := range []bool{false, true} {
+		m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{Indirect: include}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deps, err := m.ParseFile([]byte(modFile))
+		if err != nil {
+			t.Fatalf("ParseFile: %v", err)
+		}
+
+		if !include {
+			if len(deps) != 1 || deps[0].Indirect {
+				t.Errorf("expected only the direct dependency, got %+v", deps)
+			}
+
+			continue
+		}
+
+		if len(deps) != 2 || deps[0].Indirect || !deps[1].Indirect || deps[1].Name != "example.com/b" {
+			t.Errorf("expected example.com/b to be reported as indirect, got %+v", deps)
+		}
+	}
+}
+
+func TestFetchDeprecation(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/old/@latest":
+			fmt.Fprint(w, `{"Version":"v1.4.0"}`)
+		case "/example.com/old/@v/v1.4.0.mod":
+			fmt.Fprint(w, "// Deprecated: use example.com/new/v2 instead.\nmodule example.com/old\n\nretract v1.3.0\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{
+		Proxy: source.GoProxyConfig{GoProxy: proxy.URL},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := m.FetchDeprecation(domain.Dependency{Name: "example.com/old"})
+	if err != nil {
+		t.Fatalf("FetchDeprecation: %v", err)
+	}
+
+	if d == nil || d.Replacement != "example.com/new/v2" {
+		t.Fatalf("expected replacement example.com/new/v2, got %+v", d)
+	}
+}
+
+func TestRetractedVersionsMarked(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/lib/@v/list":
+			fmt.Fprint(w, "v1.0.0\nv1.1.0\nv1.2.0\n")
+		case "/example.com/lib/@latest":
+			fmt.Fprint(w, `{"Version":"v1.2.0"}`)
+		case "/example.com/lib/@v/v1.2.0.mod":
+			fmt.Fprint(w, "module example.com/lib\n\nretract v1.2.0 // broken release\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{
+		VersionSource: dependency.VersionSourceGoProxy,
+		Proxy:         source.GoProxyConfig{GoProxy: proxy.URL},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := m.GetVersionSource().FetchVersions(domain.Dependency{Name: "example.com/lib"})
+	if err != nil {
+		t.Fatalf("FetchVersions: %v", err)
+	}
+
+	latest, ok := versions.Latest(nil)
+	if !ok || latest.Version.String() != "v1.1.0" {
+		t.Errorf("expected retracted v1.2.0 to be skipped, got %s", latest.Version.String())
+	}
+}
+
+func TestPseudoAndIncompatibleVersions(t *testing.T) {
+	files := map[string]string{
+		"/example.com/lib/@v/list":          "v0.1.0\nv0.2.0\nv2.0.0+incompatible\n",
+		"/example.com/lib/@latest":          `{"Version":"v0.2.0"}`,
+		"/example.com/lib/@v/v0.2.0.mod":    "module example.com/lib\n",
+		"/example.com/lib/@v/v0.1.0.info":   `{"Version":"v0.1.0","Time":"2023-01-01T00:00:00Z"}`,
+		"/example.com/lib/@v/v0.2.0.info":   `{"Version":"v0.2.0","Time":"2024-06-01T00:00:00Z"}`,
+		"/example.com/lib/@v/main.info":     `{"Version":"v0.2.1-0.20240701000000-abcdefabcdef","Time":"2024-07-01T00:00:00Z"}`,
+		"/example.com/tracked/@v/main.info": `{"Version":"v0.0.0-20240701000000-abcdefabcdef","Time":"2024-07-01T00:00:00Z"}`,
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, body)
+	}))
+	defer proxy.Close()
+
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{
+		VersionSource: dependency.VersionSourceGoProxy,
+		Proxy:         source.GoProxyConfig{GoProxy: proxy.URL},
+		Track:         []dependency.TrackConfig{{Module: "example.com/tracked", Branch: "main"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte(`module example.com/app
+
+require (
+	example.com/lib v0.0.0-20240101000000-123456789abc
+	example.com/tracked v0.0.0-20240101000000-123456789abc
+)
+`))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 dependencies, got %d", len(deps))
+	}
+
+	tests := map[string]string{
+		// v0.1.0 was tagged before the pseudo-version commit and v2 is incompatible
+		"example.com/lib":     "v0.2.0",
+		"example.com/tracked": "v0.0.0-20240701000000-abcdefabcdef",
+	}
+
+	for _, d := range deps {
+		versions, err := m.GetVersionSource().FetchVersions(d)
+		if err != nil {
+			t.Fatalf("FetchVersions(%s): %v", d.Name, err)
+		}
+
+		latest, ok := versions.Latest(nil)
+		if !ok || latest.Version.String() != tests[d.Name] {
+			t.Errorf("%s: expected %s, got %s", d.Name, tests[d.Name], latest.Version.String())
+		}
+	}
+}
+
+func TestReplaceAndExclude(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/c/@v/list":
+			fmt.Fprint(w, "v1.0.0\nv1.1.0\nv1.2.0\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	const modFile = `module example.com/app
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.0.0
+	example.com/c v1.0.0
+)
+
+replace example.com/a => ../a
+
+replace example.com/b => example.com/fork v1.0.0
+
+exclude example.com/c v1.2.0
+`
+
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{
+		VersionSource:      dependency.VersionSourceGoProxy,
+		Proxy:              source.GoProxyConfig{GoProxy: proxy.URL},
+		UpdateReplacements: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte(modFile))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 || deps[0].Name != "example.com/fork" || deps[1].Name != "example.com/c" {
+		t.Fatalf("expected the replacement target and example.com/c, got %+v", deps)
+	}
+
+	versions, err := m.GetVersionSource().FetchVersions(deps[1])
+	if err != nil {
+		t.Fatalf("FetchVersions: %v", err)
+	}
+
+	latest, ok := versions.Latest(nil)
+	if !ok || latest.Version.String() != "v1.1.0" {
+		t.Errorf("expected excluded v1.2.0 to be skipped, got %s", latest.Version.String())
+	}
+
+	if err := m.ApplyDependency(domain.Dependency{Name: "example.com/fork", Version: domain.MustParseVersion(domain.Semver, "v1.1.0")}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(out), "replace example.com/b => example.com/fork v1.1.0") ||
+		!strings.Contains(string(out), "\texample.com/b v1.0.0\n") {
+		t.Errorf("expected only the replacement to be updated, got:\n%s", out)
+	}
+}
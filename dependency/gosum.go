@@ -0,0 +1,494 @@
+package dependency
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+const goSumFile = "go.sum"
+
+var go117 = domain.MustParseVersion(source.GoRelease, "1.17")
+
+func (g *GoLangDependencyManager) GetCompanionFiles() []string {
+	files := make([]string, 0, 2)
+
+	if !g.skipGoSum {
+		files = append(files, goSumFile)
+	}
+
+	if g.workFile != "" {
+		files = append(files, g.workFile)
+	}
+
+	return files
+}
+
+// Bring go.sum in line with the updated dependencies and the go.work of the
+// workspace in line with the go and toolchain lines
+func (g *GoLangDependencyManager) UpdateCompanions(current map[string][]byte) (map[string][]byte, error) {
+	updated := make(map[string][]byte)
+
+	if !g.skipGoSum && g.applied {
+		sum, err := g.updateGoSum(current[goSumFile])
+		if err != nil {
+			return nil, err
+		}
+
+		if sum != nil {
+			updated[goSumFile] = sum
+		}
+	}
+
+	if g.workFile != "" {
+		work, err := g.updateGoWork(current[g.workFile])
+		if err != nil {
+			return nil, fmt.Errorf("updating %s: %w", g.workFile, err)
+		}
+
+		if work != nil {
+			updated[g.workFile] = work
+		}
+	}
+
+	return updated, nil
+}
+
+// Bring the requirements in line with the updated module graph and add the
+// go.sum hashes needed to build against it
+//
+// Existing go.sum lines are kept as they are. New lines are added for every
+// go.mod loaded from the module proxy while resolving the graph and for the
+// content of modules which either are required by the main module or
+// already had their content hashed. Nothing is changed unless every lookup
+// succeeds. Returns nil if go.sum is unchanged.
+func (g *GoLangDependencyManager) updateGoSum(current []byte) ([]byte, error) {
+	graph, err := g.tidyRequirements()
+	if err != nil {
+		return nil, err
+	}
+
+	sums := parseGoSum(current)
+
+	hashContent := make(map[string]bool)
+
+	for _, r := range g.ModFile.Require {
+		hashContent[r.Mod.Path] = true
+	}
+
+	for m := range sums {
+		if !strings.HasSuffix(m.Version, "/go.mod") {
+			hashContent[m.Path] = true
+		}
+	}
+
+	for m := range graph.loaded {
+		key := module.Version{Path: m.Path, Version: m.Version + "/go.mod"}
+		if _, ok := sums[key]; ok {
+			continue
+		}
+
+		sum, err := g.Checksums.GoModSum(m.Path, m.Version)
+		if err != nil {
+			return nil, fmt.Errorf("go.mod checksum of %s: %w", m, err)
+		}
+
+		sums[key] = sum
+	}
+
+	for path, version := range graph.selected {
+		if !hashContent[path] {
+			continue
+		}
+
+		m, ok := g.replacement(module.Version{Path: path, Version: version})
+		if !ok {
+			continue
+		}
+
+		if _, ok := sums[m]; ok {
+			continue
+		}
+
+		sum, err := g.Checksums.ZipSum(m.Path, m.Version)
+		if err != nil {
+			return nil, fmt.Errorf("checksum of %s: %w", m, err)
+		}
+
+		sums[m] = sum
+	}
+
+	updated := sums.format()
+	if bytes.Equal(updated, current) {
+		return nil, nil
+	}
+
+	return updated, nil
+}
+
+// Raise requirements to the versions selected by the module graph and, when
+// the main module prunes its graph, require what the updated dependencies
+// need
+//
+// With graph pruning, go 1.17 and later, the main module has to list every
+// module providing packages to its build. Which packages are imported is not
+// known, so every module required by an updated dependency is taken to
+// provide some and becomes an indirect requirement. The go command accepts
+// these even where go mod tidy would drop them. Returns the module graph of
+// the final requirements.
+func (g *GoLangDependencyManager) tidyRequirements() (*moduleGraph, error) {
+	for {
+		graph, err := g.buildList()
+		if err != nil {
+			return nil, err
+		}
+
+		changed := false
+
+		for _, r := range g.ModFile.Require {
+			if v := graph.selected[r.Mod.Path]; modsemver.Compare(v, r.Mod.Version) > 0 {
+				slog.Debug(
+					"Raising requirement to the version selected by the module graph",
+					slog.String("module", r.Mod.Path),
+					slog.String("from", r.Mod.Version),
+					slog.String("to", v),
+				)
+
+				r.Mod.Version = v
+				changed = true
+			}
+		}
+
+		if prunesGraph(g.ModFile) {
+			required := make(map[string]bool, len(g.ModFile.Require))
+			updated := make([]module.Version, 0)
+
+			for _, r := range g.ModFile.Require {
+				required[r.Mod.Path] = true
+
+				// Modules added here were already required by an updated
+				// dependency whose own requirements cover what they need
+				if v, ok := g.required[r.Mod.Path]; ok && v != r.Mod.Version {
+					updated = append(updated, r.Mod)
+				}
+			}
+
+			for _, m := range graph.requiredBy(updated) {
+				if required[m] || m == g.GetManifestName() || g.IsLocal(m) {
+					continue
+				}
+
+				slog.Debug(
+					"Requiring module needed by an updated dependency",
+					slog.String("module", m),
+					slog.String("version", graph.selected[m]),
+				)
+
+				g.ModFile.AddNewRequire(m, graph.selected[m], true)
+				required[m] = true
+				changed = true
+			}
+		}
+
+		if !changed {
+			return graph, nil
+		}
+	}
+}
+
+// Module graph of the main module resolved using minimal version selection
+type moduleGraph struct {
+	selected map[string]string                   // Selected version by module path
+	loaded   map[module.Version]bool             // Module versions whose go.mod was read from the module proxy, after replacement
+	requires map[module.Version][]module.Version // Requirements of every module version whose go.mod was read
+	pruned   map[module.Version]bool             // Module versions whose go.mod prunes their dependencies out of the graph
+}
+
+// Get the paths of the modules required by the given module versions. The
+// requirements of unpruned modules are followed transitively, as their
+// go.mod does not list everything they build with.
+func (mg *moduleGraph) requiredBy(mods []module.Version) []string {
+	seen := make(map[module.Version]bool)
+	paths := make([]string, 0)
+
+	for _, m := range mods {
+		for _, r := range mg.requires[m] {
+			paths = mg.appendClosure(paths, r, !mg.pruned[m], seen)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return slices.Compact(paths)
+}
+
+func (mg *moduleGraph) appendClosure(paths []string, m module.Version, transitive bool, seen map[module.Version]bool) []string {
+	if seen[m] {
+		return paths
+	}
+
+	seen[m] = true
+	paths = append(paths, m.Path)
+
+	if !transitive {
+		return paths
+	}
+
+	for _, r := range mg.requires[m] {
+		paths = mg.appendClosure(paths, r, true, seen)
+	}
+
+	return paths
+}
+
+// Resolve the module graph of the main module the way the go command does
+//
+// Modules whose go.mod declares go 1.17 or later prune their dependencies:
+// their requirements are part of the graph, but the go.mod files of those
+// requirements are only loaded if the requiring module is reached through
+// a module which does not prune. A main module predating pruning loads the
+// go.mod of every reachable module version. Requirements of other modules
+// of the same repository and of replacements by a local directory are read
+// from the work tree.
+func (g *GoLangDependencyManager) buildList() (*moduleGraph, error) {
+	graph := &moduleGraph{
+		selected: make(map[string]string),
+		loaded:   make(map[module.Version]bool),
+		requires: make(map[module.Version][]module.Version),
+		pruned:   make(map[module.Version]bool),
+	}
+
+	type visit struct {
+		mod      module.Version
+		unpruned bool // Whether the go.mod files of all its requirements are loaded
+	}
+
+	// Versions required of modules of the same repository are never built
+	selectVersion := func(m module.Version) {
+		if !g.IsLocal(m.Path) && modsemver.Compare(m.Version, graph.selected[m.Path]) > 0 {
+			graph.selected[m.Path] = m.Version
+		}
+	}
+
+	seen := make(map[visit]bool)
+	queue := make([]visit, 0, len(g.ModFile.Require))
+	mainUnpruned := !prunesGraph(g.ModFile)
+
+	roots := make([]module.Version, 0, len(g.ModFile.Require))
+	for _, r := range g.ModFile.Require {
+		roots = append(roots, r.Mod)
+	}
+
+	// Modules of the same repository are built from the work tree, so their
+	// requirements are as good as those of the main module
+	for _, r := range g.ModFile.Require {
+		f, err := g.readLocalModFile(r.Mod)
+		if err != nil {
+			return nil, err
+		}
+
+		if f != nil {
+			for _, lr := range f.Require {
+				roots = append(roots, lr.Mod)
+			}
+		}
+	}
+
+	for _, m := range roots {
+		selectVersion(m)
+		queue = append(queue, visit{mod: m, unpruned: mainUnpruned})
+	}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		if seen[v] || g.excluded(v.mod) || g.IsLocal(v.mod.Path) {
+			continue
+		}
+
+		seen[v] = true
+
+		requires, pruned, err := g.moduleRequirements(v.mod, graph)
+		if err != nil {
+			return nil, err
+		}
+
+		graph.requires[v.mod] = requires
+		graph.pruned[v.mod] = pruned
+
+		unpruned := v.unpruned || !pruned
+
+		for _, r := range requires {
+			if g.excluded(r) {
+				continue
+			}
+
+			selectVersion(r)
+
+			if unpruned {
+				queue = append(queue, visit{mod: r, unpruned: true})
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// Get the requirements of a module version after replacement and whether
+// its go.mod prunes them
+func (g *GoLangDependencyManager) moduleRequirements(m module.Version, graph *moduleGraph) ([]module.Version, bool, error) {
+	var f *modfile.File
+
+	if target, ok := g.replacement(m); ok {
+		var err error
+
+		f, err = g.fetchModFile(target)
+		if err != nil {
+			return nil, false, fmt.Errorf("loading go.mod of %s: %w", target, err)
+		}
+
+		graph.loaded[target] = true
+	} else {
+		f = g.readReplacementModFile(g.findReplace(m))
+		if f == nil {
+			return nil, true, nil
+		}
+	}
+
+	requires := make([]module.Version, len(f.Require))
+	for i, r := range f.Require {
+		requires[i] = r.Mod
+	}
+
+	return requires, prunesGraph(f), nil
+}
+
+// Only modules sharing a go.work with the main module are built from the
+// work tree. Other modules of the repository are required at published
+// versions like any other, while requirements replaced by a local directory
+// are never reported.
+func (g *GoLangDependencyManager) IsLocal(path string) bool {
+	_, ok := g.local[path]
+	return ok
+}
+
+// Get the go.mod of another module of the same repository, nil if m is not
+// one
+func (g *GoLangDependencyManager) readLocalModFile(m module.Version) (*modfile.File, error) {
+	file, ok := g.local[m.Path]
+	if !ok || g.open == nil {
+		return nil, nil
+	}
+
+	body, err := g.open(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+
+	return modfile.ParseLax(file, body, nil)
+}
+
+// Get the go.mod of a replacement by a local directory. Directories outside
+// of the repository can not be read, nil is returned for those.
+func (g *GoLangDependencyManager) readReplacementModFile(r *modfile.Replace) *modfile.File {
+	file := path.Join(path.Dir(g.path), filepath.ToSlash(r.New.Path), "go.mod")
+	if g.open == nil || filepath.IsAbs(r.New.Path) || file == ".." || strings.HasPrefix(file, "../") {
+		return nil
+	}
+
+	body, err := g.open(file)
+	if err != nil {
+		slog.Debug("Skipping requirements of local replacement", slog.String("file", file), slog.Any("error", err))
+		return nil
+	}
+
+	f, err := modfile.ParseLax(file, body, nil)
+	if err != nil {
+		slog.Debug("Skipping requirements of local replacement", slog.String("file", file), slog.Any("error", err))
+		return nil
+	}
+
+	return f
+}
+
+// Whether a go.mod prunes the module graph, which is the case from go 1.17.
+// Files without a go directive predate it.
+func prunesGraph(f *modfile.File) bool {
+	if f.Go == nil {
+		return false
+	}
+
+	v, err := source.ParseGoVersion(f.Go.Version)
+
+	return err == nil && !v.LessThan(go117)
+}
+
+// Get the go.mod of a specific module version. Lookups are cached for the
+// lifetime of the manager as published go.mod files never change.
+func (g *GoLangDependencyManager) fetchModFile(m module.Version) (*modfile.File, error) {
+	if f, ok := g.mods[m]; ok {
+		return f, nil
+	}
+
+	body, err := g.Proxy.GoMod(m.Path, m.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := modfile.ParseLax(m.String()+"/go.mod", body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mods[m] = f
+
+	return f, nil
+}
+
+// go.sum lines keyed by module version. Hashes of go.mod files are keyed by
+// the version followed by /go.mod, as they appear in the file.
+type goSum map[module.Version]string
+
+func parseGoSum(data []byte) goSum {
+	sums := make(goSum)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 3 {
+			continue
+		}
+
+		sums[module.Version{Path: f[0], Version: f[1]}] = f[2]
+	}
+
+	return sums
+}
+
+func (s goSum) format() []byte {
+	mods := make([]module.Version, 0, len(s))
+	for m := range s {
+		mods = append(mods, m)
+	}
+
+	module.Sort(mods)
+
+	var buf bytes.Buffer
+
+	for _, m := range mods {
+		fmt.Fprintf(&buf, "%s %s %s\n", m.Path, m.Version, s[m])
+	}
+
+	return buf.Bytes()
+}
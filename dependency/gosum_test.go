@@ -0,0 +1,167 @@
+package dependency_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func moduleZip(t *testing.T, path string, version string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	z := zip.NewWriter(&buf)
+
+	w, err := z.Create(path + "@" + version + "/go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = w.Write([]byte("module " + path + "\n"))
+
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestUpdateCompanions(t *testing.T) {
+	files := map[string]string{
+		"/example.com/a/@v/v1.1.0.mod": "module example.com/a\n\ngo 1.21\n\nrequire (\n\texample.com/b v1.2.0\n\texample.com/c v1.0.0\n)\n",
+		"/example.com/a/@v/v1.1.0.zip": moduleZip(t, "example.com/a", "v1.1.0"),
+		"/example.com/b/@v/v1.0.0.mod": "module example.com/b\n",
+		"/example.com/b/@v/v1.2.0.mod": "module example.com/b\n",
+		"/example.com/b/@v/v1.2.0.zip": moduleZip(t, "example.com/b", "v1.2.0"),
+		// The go.mod of d is never needed as c prunes its requirements
+		"/example.com/c/@v/v1.0.0.mod": "module example.com/c\n\ngo 1.21\n\nrequire example.com/d v1.0.0\n",
+		"/example.com/c/@v/v1.0.0.zip": moduleZip(t, "example.com/c", "v1.0.0"),
+		"/example.com/g/@v/v1.0.0.mod": "module example.com/g\n",
+		"/example.com/g/@v/v1.1.0.mod": "module example.com/g\n",
+		"/example.com/g/@v/v1.1.0.zip": moduleZip(t, "example.com/g", "v1.1.0"),
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+	defer proxy.Close()
+
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{
+		Proxy: source.GoProxyConfig{GoProxy: proxy.URL, GoSumDB: "off"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.ParseFile([]byte(`module example.com/app
+
+go 1.21
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.0.0 // indirect
+	example.com/e v0.0.0-00010101000000-000000000000
+	example.com/g v1.0.0 // indirect
+	example.com/mono/lib v0.0.0-00010101000000-000000000000
+)
+
+replace example.com/e => ./e
+`))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	// Modules of the same workspace and local replacements are read from the
+	// work tree rather than looked up
+	tree := map[string]string{
+		"go.work":         "go 1.21\n\nuse (\n\t./app\n\t./mono/lib\n)\n",
+		"app/e/go.mod":    "module example.com/e\n\ngo 1.21\n\nrequire example.com/g v1.1.0\n",
+		"mono/lib/go.mod": "module example.com/mono/lib\n\ngo 1.21\n\nrequire example.com/b v1.0.0\n",
+	}
+
+	m.SetWorkspace("app/go.mod", map[string]string{
+		"example.com/app":      "app/go.mod",
+		"example.com/mono/lib": "mono/lib/go.mod",
+		"example.com/b":        "b/go.mod",
+	}, func(p string) ([]byte, error) {
+		body, ok := tree[p]
+		if !ok {
+			return nil, errors.New("file not found")
+		}
+
+		return []byte(body), nil
+	})
+
+	// Modules of the repository outside go.work are required at published
+	// versions
+	if !m.IsLocal("example.com/mono/lib") || m.IsLocal("example.com/b") {
+		t.Error("expected only modules of go.work to be local")
+	}
+
+	if err := m.ApplyDependency(domain.Dependency{Name: "example.com/a", Version: domain.MustParseVersion(domain.Semver, "v1.1.0")}); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := "example.com/a v1.0.0 h1:old=\nexample.com/a v1.0.0/go.mod h1:oldmod=\n"
+
+	updated, err := m.UpdateCompanions(map[string][]byte{"go.sum": []byte(existing)})
+	if err != nil {
+		t.Fatalf("UpdateCompanions: %v", err)
+	}
+
+	sum := string(updated["go.sum"])
+
+	for _, want := range []string{
+		"example.com/a v1.0.0 h1:old=\n",
+		"example.com/a v1.1.0 h1:",
+		"example.com/a v1.1.0/go.mod h1:",
+		"example.com/b v1.2.0 h1:",
+		"example.com/b v1.2.0/go.mod h1:",
+		"example.com/c v1.0.0 h1:",
+		"example.com/c v1.0.0/go.mod h1:",
+		"example.com/g v1.1.0 h1:",
+		"example.com/g v1.1.0/go.mod h1:",
+	} {
+		if !strings.Contains(sum, want) {
+			t.Errorf("expected go.sum to contain %q, got:\n%s", want, sum)
+		}
+	}
+
+	if strings.Contains(sum, "example.com/d") {
+		t.Errorf("unexpected hash of the pruned example.com/d:\n%s", sum)
+	}
+
+	mod, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"example.com/b v1.2.0 // indirect",
+		"example.com/c v1.0.0 // indirect",
+		"example.com/g v1.1.0 // indirect",
+	} {
+		if !strings.Contains(string(mod), want) {
+			t.Errorf("expected go.mod to contain %q, got:\n%s", want, mod)
+		}
+	}
+
+	if strings.Contains(string(mod), "example.com/d") {
+		t.Errorf("unexpected requirement on the pruned example.com/d:\n%s", mod)
+	}
+}
@@ -0,0 +1,127 @@
+package dependency
+
+import (
+	"log/slog"
+	"path"
+	"path/filepath"
+
+	"github.com/geektype/dependy/source"
+	"golang.org/x/mod/modfile"
+)
+
+const goWorkFile = "go.work"
+
+// Find the go.work of the workspace the main module belongs to. The go
+// command uses the nearest go.work in the directory of the module or one of
+// its parents. Returns the path of the go.work and the paths of the go.mod
+// of every module it uses, nothing if the main module is not one of them.
+func (g *GoLangDependencyManager) findWorkspace() (string, map[string]bool) {
+	if g.open == nil {
+		return "", nil
+	}
+
+	for dir := path.Dir(g.path); ; dir = path.Dir(dir) {
+		file := path.Join(dir, goWorkFile)
+
+		body, err := g.open(file)
+		if err != nil {
+			if dir == "." || dir == "/" {
+				return "", nil
+			}
+
+			continue
+		}
+
+		work, err := modfile.ParseWork(file, body, nil)
+		if err != nil {
+			slog.Warn("Ignoring unparsable go.work", slog.String("file", file), slog.Any("error", err))
+			return "", nil
+		}
+
+		uses := make(map[string]bool, len(work.Use))
+
+		for _, u := range work.Use {
+			if !filepath.IsAbs(u.Path) {
+				uses[path.Join(dir, filepath.ToSlash(u.Path), "go.mod")] = true
+			}
+		}
+
+		if !uses[g.path] {
+			return "", nil
+		}
+
+		return file, uses
+	}
+}
+
+// Raise the go and toolchain lines of the go.work to those of the main
+// module, as every module of a workspace has to be buildable with them.
+// Returns nil if nothing changed.
+func (g *GoLangDependencyManager) updateGoWork(current []byte) ([]byte, error) {
+	if current == nil {
+		return nil, nil
+	}
+
+	work, err := modfile.ParseWork(g.workFile, current, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+
+	workGo := ""
+	if work.Go != nil {
+		workGo = work.Go.Version
+	}
+
+	if g.ModFile.Go != nil && newerGoVersion(g.ModFile.Go.Version, workGo) {
+		if err := work.AddGoStmt(g.ModFile.Go.Version); err != nil {
+			return nil, err
+		}
+
+		workGo = g.ModFile.Go.Version
+		changed = true
+	}
+
+	workToolchain := workGo
+	if work.Toolchain != nil {
+		workToolchain = work.Toolchain.Name
+	}
+
+	if g.ModFile.Toolchain != nil && newerGoVersion(g.ModFile.Toolchain.Name, workToolchain) {
+		if err := work.AddToolchainStmt(g.ModFile.Toolchain.Name); err != nil {
+			return nil, err
+		}
+
+		changed = true
+	}
+
+	// A toolchain no newer than the go line is redundant
+	if changed && work.Toolchain != nil && !newerGoVersion(work.Toolchain.Name, workGo) {
+		work.DropToolchainStmt()
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	work.Cleanup()
+
+	return modfile.Format(work.Syntax), nil
+}
+
+// Whether Go version a is newer than b. Versions which fail to parse, such
+// as a missing go line, are older than any other.
+func newerGoVersion(a, b string) bool {
+	va, err := source.ParseGoVersion(a)
+	if err != nil {
+		return false
+	}
+
+	vb, err := source.ParseGoVersion(b)
+	if err != nil {
+		return true
+	}
+
+	return va.GreaterThan(vb)
+}
@@ -0,0 +1,117 @@
+package dependency_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+)
+
+func TestUpdateGoWork(t *testing.T) {
+	tests := []struct {
+		name   string
+		work   string
+		update domain.Dependency
+		want   []string // Expected lines, nothing if the go.work stays as it is
+	}{
+		{
+			"go line raised",
+			"go 1.21\n\nuse (\n\t./svc\n\t./lib\n)\n",
+			domain.Dependency{Name: "go", Version: domain.MustParseVersion(domain.Semver, "1.22.3")},
+			[]string{"go 1.22.3\n", "\t./lib\n"},
+		},
+		{
+			"toolchain added",
+			"go 1.21\n\nuse ./svc\n",
+			domain.Dependency{Name: "toolchain", Version: domain.MustParseVersion(domain.Semver, "1.22.3")},
+			[]string{"go 1.21\n", "toolchain go1.22.3\n"},
+		},
+		{
+			"already newer",
+			"go 1.23.0\n\nuse ./svc\n",
+			domain.Dependency{Name: "go", Version: domain.MustParseVersion(domain.Semver, "1.22.3")},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := m.ParseFile([]byte("module example.com/svc\n\ngo 1.21\n")); err != nil {
+				t.Fatalf("ParseFile: %v", err)
+			}
+
+			m.SetWorkspace("svc/go.mod", map[string]string{"example.com/svc": "svc/go.mod"}, func(p string) ([]byte, error) {
+				if p != "go.work" {
+					return nil, errors.New("file not found")
+				}
+
+				return []byte(tt.work), nil
+			})
+
+			companions := m.GetCompanionFiles()
+			if len(companions) != 2 || companions[1] != "../go.work" {
+				t.Fatalf("expected go.sum and ../go.work, got %v", companions)
+			}
+
+			if err := m.ApplyToolchain(tt.update); err != nil {
+				t.Fatal(err)
+			}
+
+			// Nothing but the toolchain changed, so go.sum is left alone
+			updated, err := m.UpdateCompanions(map[string][]byte{"../go.work": []byte(tt.work)})
+			if err != nil {
+				t.Fatalf("UpdateCompanions: %v", err)
+			}
+
+			if _, ok := updated["go.sum"]; ok {
+				t.Errorf("unexpected go.sum update")
+			}
+
+			work, ok := updated["../go.work"]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("expected go.work to be left alone, got:\n%s", work)
+				}
+
+				return
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(work), want) {
+					t.Errorf("expected %q, got:\n%s", want, work)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkspaceOutsideGoWork(t *testing.T) {
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{SkipGoSum: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.ParseFile([]byte("module example.com/tool\n\ngo 1.21\n")); err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	// The nearest go.work does not use the module, so it is built on its own
+	m.SetWorkspace("tools/go.mod", map[string]string{"example.com/tool": "tools/go.mod"}, func(p string) ([]byte, error) {
+		if p != "go.work" {
+			return nil, errors.New("file not found")
+		}
+
+		return []byte("go 1.21\n\nuse ./svc\n"), nil
+	})
+
+	if companions := m.GetCompanionFiles(); len(companions) != 0 {
+		t.Errorf("expected no companion files, got %v", companions)
+	}
+}
@@ -0,0 +1,291 @@
+package dependency
+
+import (
+	"bytes"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+const defaultGradlePluginPortal = "https://plugins.gradle.org/m2"
+
+// Suffix of the artifact marking a Gradle plugin, published under a group
+// named after the plugin id
+const gradlePluginMarker = ".gradle.plugin"
+
+func NewGradleDependencyManager(config MavenConfig, cache *source.CacheStore) (*GradleDependencyManager, error) {
+	plugins := config.PluginRepository
+	if plugins.URL == "" {
+		plugins.URL = defaultGradlePluginPortal
+	}
+
+	artifacts, err := mavenArtifactSource(config, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	repositories := &gradleRepositories{
+		artifacts: artifacts,
+		plugins:   cache.Wrap("Maven", source.NewMavenRepository(plugins)),
+	}
+
+	return &GradleDependencyManager{mavenManager: mavenManager{Source: &mavenVersionSource{VersionSource: repositories}}}, nil
+}
+
+// Manager of Gradle version catalogs, i.e. gradle/libs.versions.toml
+//
+// Libraries and plugins are updated, whether their version is given inline
+// or through a reference to the [versions] table. Plugins are named after
+// their marker artifact, <id>:<id>.gradle.plugin. Of rich versions only
+// require is updated, those given as strictly or prefer are skipped.
+type GradleDependencyManager struct {
+	mavenManager
+}
+
+func (*GradleDependencyManager) GetName() string {
+	return "GradleManager"
+}
+
+func (*GradleDependencyManager) GetFileName() string {
+	return "libs.versions.toml"
+}
+
+// Catalogs can be named anything ending in .versions.toml
+func (*GradleDependencyManager) MatchFile(p string) bool {
+	return strings.HasSuffix(path.Base(p), ".versions.toml")
+}
+
+// Library or plugin declared in a version catalog
+type catalogEntry struct {
+	Plugin   bool
+	Module   string // <group>:<name> of a library
+	Group    string
+	Artifact string
+	ID       string // Plugin id
+	Ref      string // Key of the version in the [versions] table
+	Version  *catalogVersion
+}
+
+// Version written as is in the catalog
+type catalogVersion struct {
+	Text  string
+	Start int
+}
+
+func (m *GradleDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	m.reset(contents)
+
+	entries := make([]*catalogEntry, 0)
+	byPath := make(map[string]*catalogEntry)
+	versions := make(map[string]*catalogVersion)
+
+	entry := func(section string, key string) *catalogEntry {
+		p := section + "\x00" + key
+
+		e, ok := byPath[p]
+		if !ok {
+			e = &catalogEntry{Plugin: section == "plugins"}
+			byPath[p] = e
+			entries = append(entries, e)
+		}
+
+		return e
+	}
+
+	p := unstable.Parser{}
+	p.Reset(contents)
+
+	table := make([]string, 0)
+
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = tomlKey(expr.Key())
+		case unstable.KeyValue:
+			key := append(append([]string{}, table...), tomlKey(expr.Key())...)
+			value := expr.Value()
+
+			if len(key) < 2 {
+				continue
+			}
+
+			switch key[0] {
+			case "versions":
+				if v, ok := m.catalogString(value); ok && len(key) == 2 {
+					versions[key[1]] = v
+				}
+			case "libraries", "plugins":
+				e := entry(key[0], key[1])
+
+				if len(key) > 2 {
+					// Either [libraries.foo] or a dotted key such as foo.version.ref
+					m.setField(e, key[2:], value)
+					continue
+				}
+
+				switch value.Kind {
+				case unstable.String:
+					m.setNotation(e, value)
+				case unstable.InlineTable:
+					it := value.Children()
+					for it.Next() {
+						field := it.Node()
+						m.setField(e, tomlKey(field.Key()), field.Value())
+					}
+				}
+			}
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+
+	newDeps := make([]domain.Dependency, 0)
+
+	for _, e := range entries {
+		name := e.name()
+		if name == "" {
+			continue
+		}
+
+		version := e.Version
+		if e.Ref != "" {
+			version = versions[e.Ref]
+		}
+
+		if version == nil {
+			slog.Debug("Skipping dependency without an updatable version", slog.String("dependency", name))
+			continue
+		}
+
+		if d, ok := m.addSpec(name, version.Text, version.Start, version.Start+len(version.Text)); ok {
+			newDeps = append(newDeps, d)
+		}
+	}
+
+	return dedupeDependencies(newDeps), nil
+}
+
+// Get the artifact name of an entry, empty if it is incomplete
+func (e *catalogEntry) name() string {
+	switch {
+	case e.Plugin && e.ID != "":
+		return e.ID + ":" + e.ID + gradlePluginMarker
+	case e.Plugin:
+		return ""
+	case e.Module != "":
+		return e.Module
+	case e.Group != "" && e.Artifact != "":
+		return e.Group + ":" + e.Artifact
+	default:
+		return ""
+	}
+}
+
+// Record a library given as group:name:version or a plugin given as
+// id:version
+func (m *GradleDependencyManager) setNotation(e *catalogEntry, value *unstable.Node) {
+	s, ok := m.catalogString(value)
+	if !ok {
+		return
+	}
+
+	parts := strings.Split(s.Text, ":")
+
+	switch {
+	case e.Plugin && len(parts) == 2:
+		e.ID = parts[0]
+	case !e.Plugin && len(parts) == 3:
+		e.Module = parts[0] + ":" + parts[1]
+	default:
+		return
+	}
+
+	i := strings.LastIndex(s.Text, ":") + 1
+	e.Version = &catalogVersion{Text: s.Text[i:], Start: s.Start + i}
+}
+
+// Record a key of an entry's table
+func (m *GradleDependencyManager) setField(e *catalogEntry, key []string, value *unstable.Node) {
+	if key[0] == "version" && len(key) == 1 && value.Kind == unstable.InlineTable {
+		it := value.Children()
+		for it.Next() {
+			field := it.Node()
+			m.setField(e, append([]string{"version"}, tomlKey(field.Key())...), field.Value())
+		}
+
+		return
+	}
+
+	if value.Kind != unstable.String {
+		return
+	}
+
+	switch strings.Join(key, ".") {
+	case "module":
+		e.Module = string(value.Data)
+	case "group":
+		e.Group = string(value.Data)
+	case "name":
+		e.Artifact = string(value.Data)
+	case "id":
+		e.ID = string(value.Data)
+	case "version.ref":
+		e.Ref = string(value.Data)
+	case "version", "version.require":
+		if v, ok := m.catalogString(value); ok {
+			e.Version = v
+		}
+	}
+}
+
+// Locate the content of a single-line TOML string in the file. Strings
+// containing escape sequences are skipped as their content does not map
+// onto the file.
+func (m *GradleDependencyManager) catalogString(n *unstable.Node) (*catalogVersion, bool) {
+	if n.Kind != unstable.String {
+		return nil, false
+	}
+
+	raw := m.content[n.Raw.Offset : n.Raw.Offset+n.Raw.Length]
+
+	if len(raw) < 2 || bytes.HasPrefix(raw, []byte(`"""`)) || bytes.HasPrefix(raw, []byte("'''")) ||
+		!bytes.Equal(raw[1:len(raw)-1], n.Data) {
+		return nil, false
+	}
+
+	return &catalogVersion{Text: string(n.Data), Start: int(n.Raw.Offset) + 1}, true
+}
+
+// Route plugin marker artifacts to the plugin repository
+type gradleRepositories struct {
+	artifacts domain.VersionSource
+	plugins   domain.VersionSource
+}
+
+func (*gradleRepositories) GetName() string {
+	return "GradleRepositories"
+}
+
+func (r *gradleRepositories) source(dep domain.Dependency) domain.VersionSource {
+	if strings.HasSuffix(dep.Name, gradlePluginMarker) {
+		return r.plugins
+	}
+
+	return r.artifacts
+}
+
+func (r *gradleRepositories) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	return r.source(dep).FetchVersions(dep)
+}
+
+func (r *gradleRepositories) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	return r.source(dep).FetchVersion(dep, version)
+}
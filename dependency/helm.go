@@ -0,0 +1,251 @@
+package dependency
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"gopkg.in/yaml.v3"
+)
+
+// Matches chart version constraints dependy can update: an exact version,
+// optionally preceded by the ^, ~ or = range operator which is kept on update
+var helmSpecRe = regexp.MustCompile(`^(\^|~|=)?(v?[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)$`)
+
+type HelmConfig struct {
+	Repositories map[string]source.HelmRepositoryConfig // Credentials of HTTP chart repositories keyed by host
+	Registries   map[string]source.RegistryConfig       // Settings of OCI registries keyed by host
+}
+
+func NewHelmDependencyManager(config HelmConfig, cache *source.CacheStore) *HelmDependencyManager {
+	repositories := source.NewHelmRepositories(config.Repositories, source.NewOCIRegistry(config.Registries))
+
+	h := &HelmDependencyManager{Repositories: repositories}
+	h.Source = cache.Wrap(h.GetEcosystem(), repositories)
+
+	return h
+}
+
+// Manager of the dependencies of Helm charts listed in Chart.yaml
+//
+// Dependencies are named after their chart reference, the repository URL
+// followed by the chart name, as charts of the same name are published by
+// different repositories. Charts from local paths and repositories referred
+// to by an alias are skipped.
+type HelmDependencyManager struct {
+	Repositories *source.HelmRepositories
+	Source       domain.VersionSource
+	content      []byte
+	name         string
+	members      map[string]string // Charts of the repository, by name
+	specs        []helmSpec
+	applied      map[string]domain.Version // Applied updates by chart reference
+}
+
+// Location of an updatable version constraint in Chart.yaml
+type helmSpec struct {
+	Ref    string
+	Prefix string // Range operator kept on update, i.e. ^ or ~
+	Start  int
+	End    int
+}
+
+func (*HelmDependencyManager) GetName() string {
+	return "HelmManager"
+}
+
+func (*HelmDependencyManager) GetEcosystem() string {
+	return "Helm"
+}
+
+func (*HelmDependencyManager) GetFileName() string {
+	return "Chart.yaml"
+}
+
+func (h *HelmDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, err
+	}
+
+	h.content = contents
+	h.name = ""
+	h.specs = make([]helmSpec, 0)
+	h.applied = make(map[string]domain.Version)
+
+	newDeps := make([]domain.Dependency, 0)
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return newDeps, nil
+	}
+
+	chart := doc.Content[0]
+
+	if n := yamlValue(chart, "name"); n != nil {
+		h.name = n.Value
+	}
+
+	deps := yamlValue(chart, "dependencies")
+	if deps == nil || deps.Kind != yaml.SequenceNode {
+		return newDeps, nil
+	}
+
+	seen := make(map[string]bool)
+
+	for _, d := range deps.Content {
+		if d.Kind != yaml.MappingNode {
+			continue
+		}
+
+		name, version, repository := yamlValue(d, "name"), yamlValue(d, "version"), yamlValue(d, "repository")
+		if name == nil || version == nil || repository == nil {
+			continue
+		}
+
+		repo := strings.TrimSuffix(repository.Value, "/")
+		if !strings.Contains(repo, "://") || strings.HasPrefix(repo, "file://") {
+			slog.Debug("Skipping chart without a remote repository", slog.String("dependency", name.Value))
+			continue
+		}
+
+		ref := repo + "/" + name.Value
+
+		m := helmSpecRe.FindStringSubmatch(version.Value)
+		if m == nil {
+			slog.Debug(
+				"Skipping dependency with unsupported version range",
+				slog.String("dependency", ref),
+				slog.String("version", version.Value),
+			)
+
+			continue
+		}
+
+		v, err := domain.ParseVersion(domain.Semver, m[2])
+		if err != nil {
+			continue
+		}
+
+		start, ok := yamlScalarOffset(contents, version)
+		if !ok {
+			slog.Debug("Skipping dependency with escaped version", slog.String("dependency", ref))
+			continue
+		}
+
+		h.specs = append(h.specs, helmSpec{
+			Ref:    ref,
+			Prefix: m[1],
+			Start:  start,
+			End:    start + len(version.Value),
+		})
+
+		// A chart used under several aliases is updated everywhere
+		if seen[ref] {
+			continue
+		}
+
+		seen[ref] = true
+
+		newDeps = append(newDeps, domain.Dependency{Name: ref, Version: v})
+	}
+
+	return newDeps, nil
+}
+
+// Get the value of a key of a mapping node. nil if the key is missing.
+func yamlValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// Find the offset of the value of a scalar node in the file, inside its
+// quotes if it has any. Returns false if the value is not written as is,
+// i.e. because it contains escape sequences or spans several lines.
+func yamlScalarOffset(contents []byte, n *yaml.Node) (int, bool) {
+	offset := 0
+
+	for line := 1; line < n.Line; line++ {
+		i := strings.IndexByte(string(contents[offset:]), '\n')
+		if i < 0 {
+			return 0, false
+		}
+
+		offset += i + 1
+	}
+
+	// Columns count characters rather than bytes
+	for col := 1; col < n.Column && offset < len(contents); col++ {
+		_, size := utf8.DecodeRune(contents[offset:])
+		offset += size
+	}
+
+	if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		offset++
+	}
+
+	end := offset + len(n.Value)
+
+	return offset, end <= len(contents) && string(contents[offset:end]) == n.Value
+}
+
+func (h *HelmDependencyManager) GetManifestName() string {
+	return h.name
+}
+
+func (h *HelmDependencyManager) SetWorkspace(_ string, members map[string]string, _ func(string) ([]byte, error)) {
+	h.members = members
+}
+
+// Charts of the same repository are referenced by file:// paths, which are
+// never updated
+func (h *HelmDependencyManager) IsLocal(name string) bool {
+	_, ok := h.members[name]
+	return ok
+}
+
+func (h *HelmDependencyManager) GetVersionSource() domain.VersionSource {
+	return h.Source
+}
+
+func (h *HelmDependencyManager) FetchDeprecation(dep domain.Dependency) (*domain.Deprecation, error) {
+	deprecated, err := h.Repositories.Deprecated(dep.Name)
+	if err != nil || !deprecated {
+		return nil, err
+	}
+
+	return &domain.Deprecation{Message: "chart is deprecated"}, nil
+}
+
+func (h *HelmDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	for _, s := range h.specs {
+		if s.Ref == dependency.Name {
+			h.applied[dependency.Name] = dependency.Version
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+// Versions are rewritten in place, keeping comments and formatting
+func (h *HelmDependencyManager) GetFile() ([]byte, error) {
+	edits := make([]textEdit, 0)
+
+	for _, s := range h.specs {
+		if v, ok := h.applied[s.Ref]; ok {
+			edits = append(edits, textEdit{Start: s.Start, End: s.End, Text: s.Prefix + v.String()})
+		}
+	}
+
+	return applyEdits(h.content, edits), nil
+}
@@ -0,0 +1,108 @@
+package dependency_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const chartYaml = `apiVersion: v2
+name: app # deployed by the platform team
+version: 0.1.0
+dependencies:
+  # Cache
+  - name: redis
+    version: "~18.1.0"
+    repository: REPO/stable/
+  - name: postgresql
+    version: 12.5.6 # pinned until the migration
+    repository: oci://REGISTRY/charts
+  - name: redis
+    alias: sessions
+    version: "~18.1.0"
+    repository: REPO/stable
+  - name: common
+    version: 2.x.x
+    repository: REPO/stable
+  - name: local
+    version: 0.1.0
+    repository: file://../local
+`
+
+func TestHelmManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stable/index.yaml":
+			_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  redis:
+    - version: 19.0.0-rc.1
+    - version: 18.2.1
+      created: "2024-01-10T12:00:00Z"
+    - version: 18.1.5
+`))
+		case "/v2/charts/postgresql/tags/list":
+			_, _ = w.Write([]byte(`{"tags": ["12.5.6", "13.2.0", "13.2.1_build.1", "sha256-abc.sig"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	chart := strings.NewReplacer("REPO", server.URL, "REGISTRY", registry).Replace(chartYaml)
+
+	m := dependency.NewHelmDependencyManager(dependency.HelmConfig{
+		Registries: map[string]source.RegistryConfig{registry: {URL: server.URL}},
+	}, nil)
+
+	deps, err := m.ParseFile([]byte(chart))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 ||
+		deps[0].Name != server.URL+"/stable/redis" || deps[0].Version.String() != "18.1.0" ||
+		deps[1].Name != "oci://"+registry+"/charts/postgresql" || deps[1].Version.String() != "12.5.6" {
+		t.Fatalf("unexpected dependencies %+v", deps)
+	}
+
+	if m.GetManifestName() != "app" {
+		t.Errorf("unexpected manifest name %q", m.GetManifestName())
+	}
+
+	for _, d := range deps {
+		versions, err := m.GetVersionSource().FetchVersions(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		latest, ok := versions.Latest(nil)
+		if !ok {
+			t.Fatalf("no versions of %s", d.Name)
+		}
+
+		if err := m.ApplyDependency(domain.Dependency{Name: d.Name, Version: latest.Version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		`"~18.1.0"`, `"~18.2.1"`,
+		"12.5.6 #", "13.2.1+build.1 #",
+	).Replace(chart)
+
+	if string(file) != expected {
+		t.Errorf("unexpected Chart.yaml:\n%s\nwant:\n%s", file, expected)
+	}
+}
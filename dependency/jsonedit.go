@@ -0,0 +1,149 @@
+package dependency
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Location of a JSON string value, quotes included
+type jsonSpan struct {
+	Start int
+	End   int
+}
+
+// Join the keys, and array indexes, leading to a value of a JSON document
+func jsonPath(keys ...string) string {
+	return strings.Join(keys, "\x00")
+}
+
+// Find every string value of a JSON document, keyed by their jsonPath
+//
+// Editing values in place, rather than decoding and encoding the whole
+// document, keeps the formatting and key order chosen by the author.
+func indexJSONStrings(data []byte) (map[string]jsonSpan, error) {
+	type frame struct {
+		object    bool
+		expectKey bool
+		key       string
+		index     int
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	stack := make([]*frame, 0)
+	spans := make(map[string]jsonSpan)
+
+	done := func() {
+		if len(stack) == 0 {
+			return
+		}
+
+		if top := stack[len(stack)-1]; top.object {
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	path := func() string {
+		keys := make([]string, len(stack))
+		for i, f := range stack {
+			if f.object {
+				keys[i] = f.key
+			} else {
+				keys[i] = strconv.Itoa(f.index)
+			}
+		}
+
+		return jsonPath(keys...)
+	}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return spans, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			done()
+
+			continue
+		}
+
+		if len(stack) > 0 && stack[len(stack)-1].expectKey {
+			top := stack[len(stack)-1]
+			top.key, _ = tok.(string)
+			top.expectKey = false
+
+			continue
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			stack = append(stack, &frame{object: t == '{', expectKey: t == '{'})
+			continue
+		case string:
+			end := int(dec.InputOffset())
+			spans[path()] = jsonSpan{Start: openingQuote(data, end), End: end}
+		}
+
+		done()
+	}
+}
+
+// Find the opening quote of the JSON string ending at end
+func openingQuote(data []byte, end int) int {
+	for i := end - 2; i >= 0; i-- {
+		if data[i] != '"' {
+			continue
+		}
+
+		escapes := 0
+		for j := i - 1; j >= 0 && data[j] == '\\'; j-- {
+			escapes++
+		}
+
+		if escapes%2 == 0 {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// Replace string values of a JSON document, leaving everything else as is
+func replaceJSONStrings(data []byte, edits map[jsonSpan]string) ([]byte, error) {
+	spans := make([]jsonSpan, 0, len(edits))
+	for s := range edits {
+		spans = append(spans, s)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start > spans[j].Start })
+
+	out := bytes.Clone(data)
+
+	for _, s := range spans {
+		var buf bytes.Buffer
+
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+
+		if err := enc.Encode(edits[s]); err != nil {
+			return nil, err
+		}
+
+		quoted := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		out = append(out[:s.Start], append(quoted, out[s.End:]...)...)
+	}
+
+	return out, nil
+}
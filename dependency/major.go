@@ -0,0 +1,149 @@
+package dependency
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"golang.org/x/mod/module"
+)
+
+// Upper bound on the number of major versions probed past the current one
+const maxMajorProbe = 10
+
+// Matches an import path remainder starting with a major version element
+var majorElemRe = regexp.MustCompile(`^/v[0-9]+(/|$)`)
+
+// Find the newest major version module path of a dependency
+//
+// Major versions from v2 onwards are published under their own module path
+// (`/v2` or, for gopkg.in, `.v2`) and are therefore never seen when looking
+// up versions of the current path. Paths are probed in order until one does
+// not exist.
+func (g *GoLangDependencyManager) FetchMajorUpgrade(dep domain.Dependency) (*domain.Dependency, error) {
+	prefix, pathMajor, ok := module.SplitPathVersion(dep.Name)
+	if !ok {
+		return nil, fmt.Errorf("invalid module path %s", dep.Name)
+	}
+
+	current := 1
+
+	if pathMajor != "" {
+		n, err := strconv.Atoi(strings.TrimLeft(pathMajor, "/.v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid major version suffix %s", pathMajor)
+		}
+
+		current = n
+	}
+
+	var upgrade *domain.Dependency
+
+	for n := current + 1; n <= current+maxMajorProbe; n++ {
+		path := majorPath(prefix, pathMajor, n)
+
+		versions, err := g.Source.FetchVersions(domain.Dependency{Name: path})
+		if err != nil {
+			slog.Debug("No further major version found", slog.String("module", path), slog.Any("error", err))
+			break
+		}
+
+		latest, ok := versions.Latest(nil)
+		if !ok {
+			break
+		}
+
+		upgrade = &domain.Dependency{
+			Name:     path,
+			Version:  latest.Version,
+			Replaces: dep.Name,
+		}
+	}
+
+	return upgrade, nil
+}
+
+func majorPath(prefix string, pathMajor string, n int) string {
+	if strings.HasPrefix(pathMajor, ".") || strings.HasPrefix(prefix, "gopkg.in/") {
+		return fmt.Sprintf("%s.v%d", prefix, n)
+	}
+
+	return fmt.Sprintf("%s/v%d", prefix, n)
+}
+
+func (*GoLangDependencyManager) IsSourceFile(name string) bool {
+	return strings.HasSuffix(name, ".go")
+}
+
+// Rewrite import paths of replaced modules, including their packages
+//
+// Only the import path literals are replaced so the formatting of the file
+// is left untouched.
+func (*GoLangDependencyManager) RewriteSource(
+	name string,
+	content []byte,
+	updates []domain.Dependency,
+) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, name, content, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+
+	edits := make([]edit, 0)
+
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		for _, u := range updates {
+			if u.Replaces == "" || !strings.HasPrefix(path, u.Replaces) {
+				continue
+			}
+
+			rest := path[len(u.Replaces):]
+
+			// Imports of a different major version of the same module are
+			// left alone
+			if (rest != "" && rest[0] != '/') || majorElemRe.MatchString(rest) {
+				continue
+			}
+
+			edits = append(edits, edit{
+				start: fset.Position(imp.Path.Pos()).Offset,
+				end:   fset.Position(imp.Path.End()).Offset,
+				text:  strconv.Quote(u.Name + rest),
+			})
+
+			break
+		}
+	}
+
+	if len(edits) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	out := append([]byte(nil), content...)
+
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package dependency_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+)
+
+const majorSource = `package app
+
+import (
+	"fmt"
+
+	lib "example.com/lib"
+	"example.com/lib/sub"
+	"example.com/lib/v3/other"
+	"example.com/library"
+)
+`
+
+func TestMajorMigration(t *testing.T) {
+	m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.ParseFile([]byte("module example.com/app\n\nrequire example.com/lib v1.5.0\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	update := domain.Dependency{
+		Name:     "example.com/lib/v2",
+		Version:  domain.MustParseVersion(domain.Semver, "v2.1.0"),
+		Replaces: "example.com/lib",
+	}
+
+	if err := m.ApplyDependency(update); err != nil {
+		t.Fatalf("ApplyDependency: %v", err)
+	}
+
+	mod, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(mod), "example.com/lib/v2 v2.1.0") || strings.Contains(string(mod), "v1.5.0") {
+		t.Errorf("expected requirement to move to v2, got:\n%s", mod)
+	}
+
+	out, err := m.RewriteSource("app.go", []byte(majorSource), []domain.Dependency{update})
+	if err != nil {
+		t.Fatalf("RewriteSource: %v", err)
+	}
+
+	want := strings.NewReplacer(
+		`"example.com/lib"`, `"example.com/lib/v2"`,
+		`"example.com/lib/sub"`, `"example.com/lib/v2/sub"`,
+	).Replace(majorSource)
+
+	if string(out) != want {
+		t.Errorf("unexpected rewrite:\n%s", out)
+	}
+}
@@ -0,0 +1,440 @@
+package dependency
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+// Matches versions dependy can update. Ranges such as [1.0,2.0), Gradle's
+// dynamic versions such as 1.+ and unresolved expressions are left alone.
+var mavenVersionRe = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._-]*$`)
+
+// Matches a version taken entirely from a property, i.e. ${jackson.version}
+var mavenPropertyRe = regexp.MustCompile(`^\$\{([^}]+)\}$`)
+
+// Group of plugins declared without one
+const defaultMavenPluginGroup = "org.apache.maven.plugins"
+
+type MavenConfig struct {
+	VersionSource    string                       // Where versions of artifacts are looked up, either registry (default) or depsdev. Gradle plugins are always looked up in the plugin repository
+	Repository       source.MavenRepositoryConfig // Repository artifacts are looked up in
+	PluginRepository source.MavenRepositoryConfig // Repository Gradle plugins are looked up in. Defaults to the Gradle Plugin Portal
+}
+
+// Shared handling of the files declaring Maven artifacts
+//
+// Artifacts are named <groupId>:<artifactId>. Versions taken from a property
+// or a version catalog entry are updated where they are defined, so every
+// artifact sharing them moves together.
+type mavenManager struct {
+	Source  domain.VersionSource
+	content []byte
+	specs   []mavenSpec
+	applied map[string]domain.Version // Applied updates by artifact name
+	shared  map[int]bool              // Versions shared by artifacts updated to different ones, by offset
+}
+
+// Location of an updatable version in the file
+type mavenSpec struct {
+	Name  string
+	Start int
+	End   int
+}
+
+func (*mavenManager) GetEcosystem() string {
+	return "Maven"
+}
+
+func (m *mavenManager) reset(contents []byte) {
+	m.content = contents
+	m.specs = make([]mavenSpec, 0)
+	m.applied = make(map[string]domain.Version)
+	m.shared = make(map[int]bool)
+}
+
+// Record the version of an artifact found between start and end. Returns
+// false if it can not be updated.
+func (m *mavenManager) addSpec(name string, version string, start int, end int) (domain.Dependency, bool) {
+	if !mavenVersionRe.MatchString(version) {
+		slog.Debug(
+			"Skipping dependency with unsupported version",
+			slog.String("dependency", name),
+			slog.String("version", version),
+		)
+
+		return domain.Dependency{}, false
+	}
+
+	v, err := domain.ParseVersion(source.Maven, version)
+	if err != nil {
+		return domain.Dependency{}, false
+	}
+
+	m.specs = append(m.specs, mavenSpec{Name: name, Start: start, End: end})
+
+	return domain.Dependency{Name: name, Version: v}, true
+}
+
+func (m *mavenManager) GetVersionSource() domain.VersionSource {
+	return m.Source
+}
+
+func (*mavenManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+// Artifacts sharing a version through a property or catalog entry can only
+// be updated if they all move to the same version, so updates disagreeing
+// on a shared version are all blocked
+func (m *mavenManager) CheckUpdates(updates []domain.Dependency, _ map[string][]byte) ([]domain.Dependency, error) {
+	byName := make(map[string]domain.Dependency, len(updates))
+	for _, u := range updates {
+		if u.Blocked == "" {
+			byName[u.Name] = u
+		}
+	}
+
+	checked := make([]domain.Dependency, len(updates))
+	copy(checked, updates)
+
+	for i, u := range checked {
+		if u.Blocked == "" {
+			checked[i].Blocked = m.sharedConflict(u, byName)
+		}
+	}
+
+	return checked, nil
+}
+
+// Find the artifact sharing a version with u which is updated to another
+// version. Empty if there is none.
+func (m *mavenManager) sharedConflict(u domain.Dependency, updates map[string]domain.Dependency) string {
+	for _, s := range m.specs {
+		if s.Name != u.Name {
+			continue
+		}
+
+		for _, o := range m.specs {
+			if o.Start != s.Start || o.Name == u.Name {
+				continue
+			}
+
+			if other, ok := updates[o.Name]; ok && other.Version.String() != u.Version.String() {
+				return fmt.Sprintf("version of %s is shared with %s, updated to %s instead", u.Name, o.Name, other.Version)
+			}
+		}
+	}
+
+	return ""
+}
+
+// Updates conflicting on a shared version, which CheckUpdates blocks, fail
+// and leave the shared version alone
+func (m *mavenManager) ApplyDependency(dependency domain.Dependency) error {
+	found := false
+
+	for _, s := range m.specs {
+		if s.Name != dependency.Name {
+			continue
+		}
+
+		found = true
+
+		if m.shared[s.Start] {
+			return fmt.Errorf("version of %s is shared with artifacts updated to other versions", dependency.Name)
+		}
+
+		for _, o := range m.specs {
+			if o.Start != s.Start || o.Name == dependency.Name {
+				continue
+			}
+
+			if v, ok := m.applied[o.Name]; ok && v.String() != dependency.Version.String() {
+				m.shared[s.Start] = true
+
+				return fmt.Errorf("version of %s is shared with %s, updated to %s instead", dependency.Name, o.Name, v)
+			}
+		}
+	}
+
+	if !found {
+		return errors.New("dependency not found in file")
+	}
+
+	m.applied[dependency.Name] = dependency.Version
+
+	return nil
+}
+
+// Versions are rewritten in place, keeping comments and formatting
+func (m *mavenManager) GetFile() ([]byte, error) {
+	edits := make([]textEdit, 0)
+
+	for _, s := range m.specs {
+		if v, ok := m.applied[s.Name]; ok && !m.shared[s.Start] {
+			edits = append(edits, textEdit{Start: s.Start, End: s.End, Text: v.String()})
+		}
+	}
+
+	return applyEdits(m.content, edits), nil
+}
+
+// Ignore versions of other variants of an artifact, i.e. -android builds of
+// Guava when the -jre one is used
+type mavenVersionSource struct {
+	domain.VersionSource
+}
+
+func (r *mavenVersionSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	versions, err := r.VersionSource.FetchVersions(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		if !source.SameMavenVariant(dep.Version, versions[i].Version) {
+			versions[i].Excluded = "different artifact variant"
+		}
+	}
+
+	return versions, nil
+}
+
+// Manager of Maven projects' pom.xml
+//
+// Versions of dependencies, plugins and build extensions are updated,
+// including those under dependencyManagement, pluginManagement and profiles.
+// A version given as ${property} is updated in the project's properties.
+// Artifacts without a version, or whose version comes from a parent POM,
+// are skipped.
+type MavenDependencyManager struct {
+	mavenManager
+	name    string
+	members map[string]string // POMs of the repository, by artifact name
+}
+
+func NewMavenDependencyManager(config MavenConfig, cache *source.CacheStore) (*MavenDependencyManager, error) {
+	repository, err := mavenArtifactSource(config, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MavenDependencyManager{mavenManager: mavenManager{Source: &mavenVersionSource{VersionSource: repository}}}, nil
+}
+
+func mavenArtifactSource(config MavenConfig, cache *source.CacheStore) (domain.VersionSource, error) {
+	versions, err := selectVersionSource("Maven", config.VersionSource, source.NewMavenRepository(config.Repository), source.SystemMaven)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.Wrap("Maven", versions), nil
+}
+
+func (*MavenDependencyManager) GetName() string {
+	return "MavenManager"
+}
+
+func (*MavenDependencyManager) GetFileName() string {
+	return "pom.xml"
+}
+
+// Element of a POM with the text it contains
+type pomElement struct {
+	Name     string
+	Children []*pomElement
+	Text     string // Text content with surrounding whitespace trimmed
+	Start    int    // Offset of Text in the file, -1 if it is not written as is
+	End      int
+}
+
+func (e *pomElement) child(name string) *pomElement {
+	for _, c := range e.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Get the text of a child element, empty if it is missing
+func (e *pomElement) childText(name string) string {
+	if c := e.child(name); c != nil {
+		return c.Text
+	}
+
+	return ""
+}
+
+func (m *MavenDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	root, err := parsePom(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	m.reset(contents)
+	m.name = ""
+
+	newDeps := make([]domain.Dependency, 0)
+
+	project := root.child("project")
+	if project == nil {
+		return newDeps, nil
+	}
+
+	group := project.childText("groupId")
+	if parent := project.child("parent"); group == "" && parent != nil {
+		group = parent.childText("groupId")
+	}
+
+	if artifact := project.childText("artifactId"); group != "" && artifact != "" {
+		m.name = group + ":" + artifact
+	}
+
+	properties := make(map[string]*pomElement)
+	if p := project.child("properties"); p != nil {
+		for _, c := range p.Children {
+			properties[c.Name] = c
+		}
+	}
+
+	var walk func(parent *pomElement)
+
+	walk = func(parent *pomElement) {
+		for _, e := range parent.Children {
+			switch {
+			case e.Name == "dependency" && parent.Name == "dependencies",
+				e.Name == "plugin" && parent.Name == "plugins",
+				e.Name == "extension" && parent.Name == "extensions":
+				if d, ok := m.addArtifact(e, properties); ok {
+					newDeps = append(newDeps, d)
+				}
+			}
+
+			walk(e)
+		}
+	}
+
+	walk(project)
+
+	return dedupeDependencies(newDeps), nil
+}
+
+func (m *MavenDependencyManager) addArtifact(e *pomElement, properties map[string]*pomElement) (domain.Dependency, bool) {
+	group, artifact := e.childText("groupId"), e.childText("artifactId")
+	if group == "" && e.Name == "plugin" {
+		group = defaultMavenPluginGroup
+	}
+
+	version := e.child("version")
+	if group == "" || artifact == "" || version == nil {
+		return domain.Dependency{}, false
+	}
+
+	name := group + ":" + artifact
+
+	if p := mavenPropertyRe.FindStringSubmatch(version.Text); p != nil {
+		property, ok := properties[p[1]]
+		if !ok {
+			slog.Debug(
+				"Skipping dependency with version from an unknown property",
+				slog.String("dependency", name),
+				slog.String("property", p[1]),
+			)
+
+			return domain.Dependency{}, false
+		}
+
+		version = property
+	}
+
+	if version.Start < 0 {
+		slog.Debug("Skipping dependency with escaped version", slog.String("dependency", name))
+		return domain.Dependency{}, false
+	}
+
+	return m.addSpec(name, version.Text, version.Start, version.End)
+}
+
+// Parse a POM into a tree of elements, recording where the text of each
+// element lies in the file
+func parsePom(contents []byte) (*pomElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(contents))
+
+	root := &pomElement{Start: -1}
+	stack := []*pomElement{root}
+
+	for {
+		offset := int(d.InputOffset())
+
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		top := stack[len(stack)-1]
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &pomElement{Name: t.Name.Local, Start: -1}
+			top.Children = append(top.Children, e)
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+
+			// Text interrupted by a comment can not be replaced as a whole
+			if top.Text != "" {
+				top.Text += text
+				top.Start = -1
+
+				continue
+			}
+
+			top.Text = text
+
+			// Entities and CDATA sections differ from the text they decode to
+			raw := contents[offset:d.InputOffset()]
+			lead := len(raw) - len(bytes.TrimLeft(raw, " \t\r\n"))
+
+			if bytes.Equal(bytes.TrimSpace(raw), []byte(text)) {
+				top.Start = offset + lead
+				top.End = top.Start + len(text)
+			}
+		}
+	}
+
+	return root, nil
+}
+
+func (m *MavenDependencyManager) GetManifestName() string {
+	return m.name
+}
+
+func (m *MavenDependencyManager) SetWorkspace(_ string, members map[string]string, _ func(string) ([]byte, error)) {
+	m.members = members
+}
+
+// Modules of the same build are versioned together through the reactor
+func (m *MavenDependencyManager) IsLocal(name string) bool {
+	_, ok := m.members[name]
+	return ok
+}
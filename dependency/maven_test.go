@@ -0,0 +1,305 @@
+package dependency_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const pomFile = `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0.0</version>
+  </parent>
+  <artifactId>service</artifactId>
+
+  <properties>
+    <jackson.version>2.15.0</jackson.version>
+  </properties>
+
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.fasterxml.jackson.core</groupId>
+        <artifactId>jackson-databind</artifactId>
+        <version>${jackson.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-core</artifactId>
+      <version>${jackson.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+      <version> 32.1.3-jre </version> <!-- keep the jre variant -->
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>[4.0,5.0)</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>managed</artifactId>
+    </dependency>
+  </dependencies>
+
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-surefire-plugin</artifactId>
+        <version>3.0.0</version>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`
+
+const versionCatalog = `[versions]
+kotlin = "1.9.20"
+okhttp = { strictly = "4.11.0" }
+
+[libraries]
+guava = "com.google.guava:guava:32.1.3-jre"
+kotlin-stdlib = { module = "org.jetbrains.kotlin:kotlin-stdlib", version.ref = "kotlin" }
+okhttp = { group = "com.squareup.okhttp3", name = "okhttp", version.ref = "okhttp" }
+retrofit = { module = "com.squareup.retrofit2:retrofit", version = { require = "2.9.0" } }
+
+[libraries.junit]
+module = "junit:junit"
+version = "4.13.1"
+
+[plugins]
+kotlin-jvm = { id = "org.jetbrains.kotlin.jvm", version.ref = "kotlin" }
+spotless = "com.diffplug.spotless:6.22.0"
+`
+
+func mavenMetadata(versions ...string) string {
+	return "<metadata><versioning><versions><version>" +
+		strings.Join(versions, "</version><version>") +
+		"</version></versions></versioning></metadata>"
+}
+
+func mavenServer() *httptest.Server {
+	metadata := map[string]string{
+		"/maven2/com/fasterxml/jackson/core/jackson-databind/maven-metadata.xml":                 mavenMetadata("2.15.0", "2.16.0", "2.17.0-rc1"),
+		"/maven2/com/fasterxml/jackson/core/jackson-core/maven-metadata.xml":                     mavenMetadata("2.15.0", "2.16.0"),
+		"/maven2/com/google/guava/guava/maven-metadata.xml":                                      mavenMetadata("32.1.3-jre", "33.0.0-android", "33.0.0-jre"),
+		"/maven2/org/apache/maven/plugins/maven-surefire-plugin/maven-metadata.xml":              mavenMetadata("3.0.0", "3.2.2"),
+		"/maven2/org/jetbrains/kotlin/kotlin-stdlib/maven-metadata.xml":                          mavenMetadata("1.9.20", "1.9.22", "2.0.0-Beta2"),
+		"/maven2/com/squareup/retrofit2/retrofit/maven-metadata.xml":                             mavenMetadata("2.9.0", "2.10.0"),
+		"/maven2/junit/junit/maven-metadata.xml":                                                 mavenMetadata("4.13.1", "4.13.2"),
+		"/m2/org/jetbrains/kotlin/jvm/org.jetbrains.kotlin.jvm.gradle.plugin/maven-metadata.xml": mavenMetadata("1.9.20", "1.9.22"),
+		"/m2/com/diffplug/spotless/com.diffplug.spotless.gradle.plugin/maven-metadata.xml":       mavenMetadata("6.22.0", "6.23.3"),
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := metadata[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+// Update every dependency to its latest version, returning the versions
+// reported by the manager
+func applyLatest(t *testing.T, m domain.DependencyManager, contents string) map[string]string {
+	t.Helper()
+
+	deps, err := m.ParseFile([]byte(contents))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	got := make(map[string]string)
+
+	for _, d := range deps {
+		got[d.Name] = d.Version.String()
+
+		versions, err := m.GetVersionSource().FetchVersions(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		latest, ok := versions.Latest(nil)
+		if !ok {
+			t.Fatalf("no versions of %s", d.Name)
+		}
+
+		if err := m.ApplyDependency(domain.Dependency{Name: d.Name, Version: latest.Version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return got
+}
+
+func TestMavenManager(t *testing.T) {
+	server := mavenServer()
+	defer server.Close()
+
+	m, err := dependency.NewMavenDependencyManager(dependency.MavenConfig{
+		Repository: source.MavenRepositoryConfig{URL: server.URL + "/maven2"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := applyLatest(t, m, pomFile)
+
+	want := map[string]string{
+		"com.fasterxml.jackson.core:jackson-databind":    "2.15.0",
+		"com.fasterxml.jackson.core:jackson-core":        "2.15.0",
+		"com.google.guava:guava":                         "32.1.3-jre",
+		"org.apache.maven.plugins:maven-surefire-plugin": "3.0.0",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	if m.GetManifestName() != "com.example:service" {
+		t.Errorf("unexpected manifest name %q", m.GetManifestName())
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		"<jackson.version>2.15.0<", "<jackson.version>2.16.0<",
+		" 32.1.3-jre ", " 33.0.0-jre ",
+		"<version>3.0.0<", "<version>3.2.2<",
+	).Replace(pomFile)
+
+	if string(file) != expected {
+		t.Errorf("unexpected pom.xml:\n%s\nwant:\n%s", file, expected)
+	}
+}
+
+func TestMavenSharedVersionConflict(t *testing.T) {
+	m, err := dependency.NewMavenDependencyManager(dependency.MavenConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.ParseFile([]byte(pomFile)); err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	updates := []domain.Dependency{
+		{Name: "com.fasterxml.jackson.core:jackson-databind", Version: domain.MustParseVersion(source.Maven, "2.16.0")},
+		// jackson-core shares ${jackson.version} but moves elsewhere
+		{Name: "com.fasterxml.jackson.core:jackson-core", Version: domain.MustParseVersion(source.Maven, "2.16.1")},
+		{Name: "com.google.guava:guava", Version: domain.MustParseVersion(source.Maven, "33.0.0-jre")},
+	}
+
+	checked, err := m.CheckUpdates(updates, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checked[0].Blocked == "" || checked[1].Blocked == "" || checked[2].Blocked != "" {
+		t.Errorf("expected only the jackson updates to be blocked, got %+v", checked)
+	}
+
+	// Updates applied without being checked leave the shared version alone
+	if err := m.ApplyDependency(updates[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.ApplyDependency(updates[1]); err == nil {
+		t.Error("expected conflicting update of a shared version to fail")
+	}
+
+	if err := m.ApplyDependency(updates[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(file) != strings.Replace(pomFile, " 32.1.3-jre ", " 33.0.0-jre ", 1) {
+		t.Errorf("expected only guava to be updated, got:\n%s", file)
+	}
+}
+
+func TestGradleManager(t *testing.T) {
+	server := mavenServer()
+	defer server.Close()
+
+	m, err := dependency.NewGradleDependencyManager(dependency.MavenConfig{
+		Repository:       source.MavenRepositoryConfig{URL: server.URL + "/maven2"},
+		PluginRepository: source.MavenRepositoryConfig{URL: server.URL + "/m2"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.MatchFile("gradle/libs.versions.toml") || !m.MatchFile("gradle/test-libs.versions.toml") || m.MatchFile("build.gradle") {
+		t.Error("unexpected file matching")
+	}
+
+	got := applyLatest(t, m, versionCatalog)
+
+	want := map[string]string{
+		"com.google.guava:guava":             "32.1.3-jre",
+		"org.jetbrains.kotlin:kotlin-stdlib": "1.9.20",
+		"com.squareup.retrofit2:retrofit":    "2.9.0",
+		"junit:junit":                        "4.13.1",
+		"org.jetbrains.kotlin.jvm:org.jetbrains.kotlin.jvm.gradle.plugin": "1.9.20",
+		"com.diffplug.spotless:com.diffplug.spotless.gradle.plugin":       "6.22.0",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(
+		`kotlin = "1.9.20"`, `kotlin = "1.9.22"`,
+		`guava:32.1.3-jre`, `guava:33.0.0-jre`,
+		`"2.9.0"`, `"2.10.0"`,
+		`"4.13.1"`, `"4.13.2"`,
+		`spotless:6.22.0`, `spotless:6.23.3`,
+	).Replace(versionCatalog)
+
+	if string(file) != expected {
+		t.Errorf("unexpected catalog:\n%s\nwant:\n%s", file, expected)
+	}
+}
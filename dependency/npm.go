@@ -0,0 +1,431 @@
+package dependency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const npmLockFile = "package-lock.json"
+
+// Sections of package.json holding dependencies to update
+var npmSections = []string{"dependencies", "devDependencies"}
+
+// Matches version specs dependy can update: a single version, optionally
+// preceded by the ^, ~ or = range operator, either complete or partial
+// with any missing parts written as x ranges. The operator is kept on update
+// along with the precision of the version, i.e. ^1.2 moves to ^1.5 and 1.x
+// to 2.x
+var npmSpecRe = regexp.MustCompile(`^(\^|~|=)?v?(?:([0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)|([0-9]+(?:\.[0-9]+)?)((?:\.[xX*])*))$`)
+
+type NpmConfig struct {
+	VersionSource string                   // Where versions are looked up, either registry (default) or depsdev
+	Registry      source.NpmRegistryConfig // Registry versions and package metadata are looked up in
+	SkipLockFile  bool                     // Leave package-lock.json untouched
+}
+
+func NewNpmDependencyManager(config NpmConfig, cache *source.CacheStore) (*NpmDependencyManager, error) {
+	registry := source.NewNpmRegistry(config.Registry)
+
+	n := &NpmDependencyManager{
+		Registry: registry,
+		skipLock: config.SkipLockFile,
+	}
+
+	versions, err := selectVersionSource(n.GetEcosystem(), config.VersionSource, registry, source.SystemNPM)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Source = &precisionVersionSource{VersionSource: cache.Wrap(n.GetEcosystem(), versions)}
+
+	return n, nil
+}
+
+type NpmDependencyManager struct {
+	Registry *source.NpmRegistry
+	Source   domain.VersionSource
+	skipLock bool
+	content  []byte
+	name     string                    // Name of the package declared by the file
+	members  map[string]string         // Package files of the repository, by name
+	specs    []npmSpec                 // Updatable version specs, in file order
+	applied  map[string]domain.Version // Applied updates by package name
+}
+
+// An updatable version spec in package.json
+type npmSpec struct {
+	Section  string // dependencies or devDependencies
+	Name     string
+	Prefix   string // Range operator kept on update, i.e. ^ or ~
+	Segments int    // Precision of the version kept on update
+	Wildcard string // x ranges following a partial version, i.e. .x
+	Span     jsonSpan
+}
+
+// Format a version at the precision of the spec it replaces
+func (s npmSpec) format(v domain.Version) string {
+	if s.Segments == 3 || v.Prerelease() {
+		return s.Prefix + v.String()
+	}
+
+	parts := []uint64{v.Major(), v.Minor(), v.Patch()}[:s.Segments]
+	text := make([]string, len(parts))
+
+	for i, p := range parts {
+		text[i] = strconv.FormatUint(p, 10)
+	}
+
+	return s.Prefix + strings.Join(text, ".") + s.Wildcard
+}
+
+// package.json fields dependy reads
+type npmPackage struct {
+	Name            string            `json:"name"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+// package-lock.json fields dependy reads
+type npmLock struct {
+	LockfileVersion int                       `json:"lockfileVersion"`
+	Packages        map[string]npmLockPackage `json:"packages"`
+}
+
+type npmLockPackage struct {
+	Version      string            `json:"version"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+func (*NpmDependencyManager) GetName() string {
+	return "NpmManager"
+}
+
+func (*NpmDependencyManager) GetEcosystem() string {
+	return "npm"
+}
+
+func (*NpmDependencyManager) GetFileName() string {
+	return "package.json"
+}
+
+// Only single versions, optionally preceded by ^, ~ or =, are reported.
+// Other ranges, tags and URLs are skipped as there is no single version to
+// move on from.
+func (n *NpmDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	var pkg npmPackage
+
+	if err := json.Unmarshal(contents, &pkg); err != nil {
+		return nil, err
+	}
+
+	spans, err := indexJSONStrings(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	n.content = contents
+	n.name = pkg.Name
+	n.specs = make([]npmSpec, 0)
+	n.applied = make(map[string]domain.Version)
+
+	newDeps := make([]domain.Dependency, 0)
+	seen := make(map[string]bool)
+
+	for _, section := range npmSections {
+		deps := pkg.Dependencies
+		if section == "devDependencies" {
+			deps = pkg.DevDependencies
+		}
+
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			m := npmSpecRe.FindStringSubmatch(deps[name])
+			if m == nil || strings.Count(m[3]+m[4], ".") > 2 {
+				slog.Info(
+					"Skipping dependency with unsupported version range",
+					slog.String("dependency", name),
+					slog.String("version", deps[name]),
+				)
+
+				continue
+			}
+
+			version, segments := m[2], 3
+			if version == "" {
+				version, segments = m[3], strings.Count(m[3], ".")+1
+			}
+
+			v, err := domain.ParseVersion(domain.Semver, version)
+			if err != nil {
+				continue
+			}
+
+			n.specs = append(n.specs, npmSpec{
+				Section:  section,
+				Name:     name,
+				Prefix:   m[1],
+				Segments: segments,
+				Wildcard: m[4],
+				Span:     spans[jsonPath(section, name)],
+			})
+
+			// A package listed in both sections is updated in both
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+
+			newDeps = append(newDeps, domain.Dependency{Name: name, Version: v})
+		}
+	}
+
+	return newDeps, nil
+}
+
+func (n *NpmDependencyManager) GetManifestName() string {
+	return n.name
+}
+
+func (n *NpmDependencyManager) SetWorkspace(_ string, members map[string]string, _ func(string) ([]byte, error)) {
+	n.members = members
+}
+
+// Packages of the same repository are linked by npm workspaces rather than
+// resolved from a version
+func (n *NpmDependencyManager) IsLocal(name string) bool {
+	_, ok := n.members[name]
+	return ok
+}
+
+func (n *NpmDependencyManager) GetVersionSource() domain.VersionSource {
+	return n.Source
+}
+
+// Packages are deprecated by deprecating the version the latest tag points at
+func (n *NpmDependencyManager) FetchDeprecation(dep domain.Dependency) (*domain.Deprecation, error) {
+	latest, err := n.Registry.Latest(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest.Deprecated == "" {
+		return nil, nil
+	}
+
+	return &domain.Deprecation{Message: latest.Deprecated}, nil
+}
+
+func (n *NpmDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	for _, s := range n.specs {
+		if s.Name == dependency.Name {
+			n.applied[dependency.Name] = dependency.Version
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+func (n *NpmDependencyManager) GetFile() ([]byte, error) {
+	edits := make(map[jsonSpan]string)
+
+	for _, s := range n.specs {
+		if v, ok := n.applied[s.Name]; ok {
+			edits[s.Span] = s.format(v)
+		}
+	}
+
+	return replaceJSONStrings(n.content, edits)
+}
+
+func (n *NpmDependencyManager) GetCompanionFiles() []string {
+	if n.skipLock {
+		return nil
+	}
+
+	return []string{npmLockFile}
+}
+
+// Block updates which the lock can not take in place, as the new version
+// needs packages which are not locked already. Resolving those requires
+// running npm install.
+func (n *NpmDependencyManager) CheckUpdates(updates []domain.Dependency, companions map[string][]byte) ([]domain.Dependency, error) {
+	content := companions[npmLockFile]
+	if content == nil {
+		return updates, nil
+	}
+
+	var lock npmLock
+
+	// UpdateCompanions reports locks it can not read
+	if err := json.Unmarshal(content, &lock); err != nil || lock.LockfileVersion < 2 {
+		return updates, nil
+	}
+
+	checked := make([]domain.Dependency, len(updates))
+	copy(checked, updates)
+
+	for i, u := range checked {
+		if u.Blocked != "" {
+			continue
+		}
+
+		m, err := n.Registry.Manifest(u.Name, u.Version.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if reason := lockConflict(lock, "node_modules/"+u.Name, m); reason != "" {
+			checked[i].Blocked = npmLockFile + " can not be updated in place, run npm install: " + reason
+		}
+	}
+
+	return checked, nil
+}
+
+// Move the locked version of every updated package, along with its tarball
+// URL and integrity, to the new version. Updates must have gone through
+// CheckUpdates, as the lock is only edited in place.
+func (n *NpmDependencyManager) UpdateCompanions(current map[string][]byte) (map[string][]byte, error) {
+	content := current[npmLockFile]
+	if content == nil || len(n.applied) == 0 {
+		return nil, nil
+	}
+
+	var lock npmLock
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", npmLockFile, err)
+	}
+
+	if lock.LockfileVersion < 2 {
+		return nil, fmt.Errorf("%s: lockfileVersion %d is not supported", npmLockFile, lock.LockfileVersion)
+	}
+
+	spans, err := indexJSONStrings(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", npmLockFile, err)
+	}
+
+	names := make([]string, 0, len(n.applied))
+	for name := range n.applied {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	edits := make(map[jsonSpan]string)
+
+	set := func(value string, keys ...string) {
+		if s, ok := spans[jsonPath(keys...)]; ok {
+			edits[s] = value
+		}
+	}
+
+	for _, name := range names {
+		v := n.applied[name]
+
+		m, err := n.Registry.Manifest(name, v.String())
+		if err != nil {
+			return nil, err
+		}
+
+		installed := "node_modules/" + name
+
+		if reason := lockConflict(lock, installed, m); reason != "" {
+			return nil, fmt.Errorf("%s: %s can not be updated in place: %s", npmLockFile, name, reason)
+		}
+
+		for _, s := range n.specs {
+			if s.Name == name {
+				set(s.format(v), "packages", "", s.Section, name)
+			}
+		}
+
+		// Lockfile version 2 repeats every package in the legacy layout
+		// read by npm 6
+		for _, entry := range [][]string{{"packages", installed}, {"dependencies", name}} {
+			set(m.Version, append(entry, "version")...)
+			set(m.Dist.Tarball, append(entry, "resolved")...)
+			set(m.Dist.Integrity, append(entry, "integrity")...)
+
+			for dep, spec := range m.Dependencies {
+				set(spec, append(entry, "dependencies", dep)...)
+				set(spec, append(entry, "requires", dep)...)
+			}
+		}
+	}
+
+	if len(edits) == 0 {
+		return nil, nil
+	}
+
+	updated, err := replaceJSONStrings(content, edits)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{npmLockFile: updated}, nil
+}
+
+// Find why the locked package installed at path can not simply be moved to
+// the published version m. Empty if it can.
+func lockConflict(lock npmLock, path string, m source.NpmManifest) string {
+	locked, ok := lock.Packages[path]
+	if !ok {
+		return "not locked at " + path
+	}
+
+	if m.Dist.Integrity == "" {
+		return "registry does not publish an integrity hash"
+	}
+
+	if len(m.Dependencies) != len(locked.Dependencies) {
+		return "dependencies changed"
+	}
+
+	for dep, spec := range m.Dependencies {
+		old, ok := locked.Dependencies[dep]
+		if !ok {
+			return "new dependency on " + dep
+		}
+
+		if spec == old {
+			continue
+		}
+
+		c, err := semver.NewConstraint(spec)
+		if err != nil {
+			return fmt.Sprintf("unsupported range %s for %s", spec, dep)
+		}
+
+		resolved, ok := lock.Packages[path+"/node_modules/"+dep]
+		if !ok {
+			resolved, ok = lock.Packages["node_modules/"+dep]
+		}
+
+		v, err := semver.NewVersion(resolved.Version)
+		if !ok || err != nil || !c.Check(v) {
+			return fmt.Sprintf("locked %s does not satisfy %s", dep, spec)
+		}
+	}
+
+	return ""
+}
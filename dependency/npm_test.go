@@ -0,0 +1,214 @@
+package dependency_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const packageJSON = `{
+  "name": "app",
+  "dependencies": {
+    "left-pad": "^1.0.0",
+    "@scope/util": "~2.1.0",
+    "tagged": "latest"
+  },
+  "devDependencies": {
+    "left-pad": "1.0.0"
+  }
+}
+`
+
+const packageLock = `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "dependencies": {
+        "left-pad": "^1.0.0",
+        "@scope/util": "~2.1.0"
+      }
+    },
+    "node_modules/left-pad": {
+      "version": "1.0.0",
+      "resolved": "https://registry.example/left-pad/-/left-pad-1.0.0.tgz",
+      "integrity": "sha512-old",
+      "dependencies": {
+        "repeat": "^1.0.0"
+      }
+    },
+    "node_modules/repeat": {
+      "version": "1.2.0"
+    },
+    "node_modules/@scope/util": {
+      "version": "2.1.0"
+    }
+  }
+}
+`
+
+func TestNpmManager(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/left-pad/1.1.0":
+			_, _ = w.Write([]byte(`{"name":"left-pad","version":"1.1.0","dependencies":{"repeat":"^1.2.0"},
+				"dist":{"tarball":"https://registry.example/left-pad/-/left-pad-1.1.0.tgz","integrity":"sha512-new"}}`))
+		case "/@scope%2Futil/2.2.0":
+			_, _ = w.Write([]byte(`{"name":"@scope/util","version":"2.2.0","dependencies":{"fresh":"^1.0.0"},
+				"dist":{"tarball":"https://registry.example/util-2.2.0.tgz","integrity":"sha512-util"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+
+	m, err := dependency.NewNpmDependencyManager(dependency.NpmConfig{
+		Registry: source.NpmRegistryConfig{URL: registry.URL},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte(packageJSON))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 dependencies, got %v", deps)
+	}
+
+	if m.GetManifestName() != "app" {
+		t.Errorf("unexpected manifest name %q", m.GetManifestName())
+	}
+
+	lock := map[string][]byte{"package-lock.json": []byte(packageLock)}
+
+	checked, err := m.CheckUpdates([]domain.Dependency{
+		{Name: "left-pad", Version: domain.MustParseVersion(domain.Semver, "1.1.0")},
+		{Name: "@scope/util", Version: domain.MustParseVersion(domain.Semver, "2.2.0")},
+	}, lock)
+	if err != nil {
+		t.Fatalf("CheckUpdates: %v", err)
+	}
+
+	// @scope/util now needs a package which is not locked, so only
+	// left-pad can be moved
+	if checked[0].Blocked != "" || !strings.Contains(checked[1].Blocked, "dependencies changed") {
+		t.Fatalf("unexpected checked updates %+v", checked)
+	}
+
+	if err := m.ApplyDependency(checked[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := m.UpdateCompanions(lock)
+	if err != nil {
+		t.Fatalf("UpdateCompanions: %v", err)
+	}
+
+	// @scope/util now needs a package which is not locked, so only
+	// left-pad can be moved
+	wantLock := strings.NewReplacer(
+		`"left-pad": "^1.0.0"`, `"left-pad": "^1.1.0"`,
+		`"version": "1.0.0"`, `"version": "1.1.0"`,
+		"left-pad-1.0.0.tgz", "left-pad-1.1.0.tgz",
+		"sha512-old", "sha512-new",
+		`"repeat": "^1.0.0"`, `"repeat": "^1.2.0"`,
+	).Replace(packageLock)
+
+	if got := string(updated["package-lock.json"]); got != wantLock {
+		t.Errorf("unexpected package-lock.json:\n%s\nwant:\n%s", got, wantLock)
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.NewReplacer(
+		`"^1.0.0"`, `"^1.1.0"`,
+		`"left-pad": "1.0.0"`, `"left-pad": "1.1.0"`,
+	).Replace(packageJSON)
+
+	if string(file) != want {
+		t.Errorf("unexpected package.json:\n%s\nwant:\n%s", file, want)
+	}
+}
+
+func TestNpmPartialVersions(t *testing.T) {
+	packuments := map[string]string{
+		"/caret":    `{"versions": {"1.2.0": {}, "1.2.7": {}, "1.5.0": {}}}`,
+		"/wildcard": `{"versions": {"1.0.0": {}, "1.9.0": {}, "2.1.0": {}}}`,
+		"/tilde":    `{"versions": {"1.2.3": {}, "1.2.4": {}}}`,
+	}
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := packuments[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+	defer registry.Close()
+
+	m, err := dependency.NewNpmDependencyManager(dependency.NpmConfig{
+		Registry: source.NpmRegistryConfig{URL: registry.URL},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := `{
+  "dependencies": {
+    "caret": "^1.2",
+    "wildcard": "1.x",
+    "tilde": "~1.2.3",
+    "range": ">=1.2.0 <2",
+    "deep": "1.2.3.x"
+  }
+}
+`
+
+	got := applyLatest(t, m, contents)
+
+	want := map[string]string{"caret": "1.2", "wildcard": "1", "tilde": "1.2.3"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	// Versions a partial version already covers would not change the spec
+	versions, err := m.GetVersionSource().FetchVersions(domain.Dependency{Name: "caret", Version: domain.MustParseVersion(domain.Semver, "1.2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range versions {
+		if (v.Excluded != "") != (v.Version.String() != "1.5.0") {
+			t.Errorf("unexpected exclusion of %s: %q", v.Version, v.Excluded)
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(`"^1.2"`, `"^1.5"`, `"1.x"`, `"2.x"`, `"~1.2.3"`, `"~1.2.4"`).Replace(contents)
+	if string(file) != expected {
+		t.Errorf("unexpected package.json:\n%s\nwant:\n%s", file, expected)
+	}
+}
@@ -0,0 +1,344 @@
+package dependency
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// Matches PEP 508 requirements pinned to a single version with ==, keeping
+// extras and environment markers out of the version
+var pythonPinRe = regexp.MustCompile(`^\s*([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*(?:\[[^\]]*\])?\s*==\s*([A-Za-z0-9._+!-]+)\s*(?:;.*)?$`)
+
+type PythonConfig struct {
+	VersionSource string            // Where versions are looked up, either registry (default) or depsdev
+	Index         source.PyPIConfig // Package index versions are looked up in
+}
+
+// Shared handling of the files listing Python requirements
+//
+// Only requirements pinned with == are reported. Ranges, wildcards and
+// direct references are left alone.
+type pythonManager struct {
+	Source  domain.VersionSource
+	content []byte
+	pins    []pythonPin
+	applied map[string]domain.Version // Applied updates by normalised project name
+}
+
+// Location of a pinned version in a requirements file
+type pythonPin struct {
+	Name  string // Normalised project name
+	Start int
+	End   int
+}
+
+func newPythonManager(config PythonConfig, cache *source.CacheStore) (pythonManager, error) {
+	versions, err := selectVersionSource("PyPI", config.VersionSource, source.NewPyPI(config.Index), source.SystemPyPI)
+	if err != nil {
+		return pythonManager{}, err
+	}
+
+	return pythonManager{Source: cache.Wrap("PyPI", versions)}, nil
+}
+
+func (*pythonManager) GetEcosystem() string {
+	return "PyPI"
+}
+
+func (p *pythonManager) reset(contents []byte) {
+	p.content = contents
+	p.pins = make([]pythonPin, 0)
+	p.applied = make(map[string]domain.Version)
+}
+
+// Record the requirement starting at offset if it is pinned. Returns false
+// if it is not.
+func (p *pythonManager) addPin(requirement string, offset int) (domain.Dependency, bool) {
+	m := pythonPinRe.FindStringSubmatchIndex(requirement)
+	if m == nil {
+		return domain.Dependency{}, false
+	}
+
+	name := source.NormalizePyPIName(requirement[m[2]:m[3]])
+
+	v, err := domain.ParseVersion(source.PEP440, requirement[m[4]:m[5]])
+	if err != nil {
+		slog.Warn(
+			"Skipping dependency with unrecognised version",
+			slog.String("dependency", name),
+			slog.Any("error", err),
+		)
+
+		return domain.Dependency{}, false
+	}
+
+	p.pins = append(p.pins, pythonPin{Name: name, Start: offset + m[4], End: offset + m[5]})
+
+	return domain.Dependency{Name: name, Version: v}, true
+}
+
+// Deduplicate dependencies pinned more than once, i.e. under several
+// markers, reporting the first pin
+func dedupeDependencies(deps []domain.Dependency) []domain.Dependency {
+	seen := make(map[string]bool, len(deps))
+	unique := make([]domain.Dependency, 0, len(deps))
+
+	for _, d := range deps {
+		if !seen[d.Name] {
+			seen[d.Name] = true
+			unique = append(unique, d)
+		}
+	}
+
+	return unique
+}
+
+func (p *pythonManager) GetVersionSource() domain.VersionSource {
+	return p.Source
+}
+
+func (*pythonManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+func (p *pythonManager) ApplyDependency(dependency domain.Dependency) error {
+	name := source.NormalizePyPIName(dependency.Name)
+
+	for _, pin := range p.pins {
+		if pin.Name == name {
+			p.applied[name] = dependency.Version
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+// Pins are rewritten in place, keeping extras, markers and comments
+func (p *pythonManager) GetFile() ([]byte, error) {
+	sort.Slice(p.pins, func(i, j int) bool { return p.pins[i].Start < p.pins[j].Start })
+
+	out := make([]byte, 0, len(p.content))
+	last := 0
+
+	for _, pin := range p.pins {
+		v, ok := p.applied[pin.Name]
+		if !ok {
+			continue
+		}
+
+		out = append(out, p.content[last:pin.Start]...)
+		out = append(out, v.String()...)
+		last = pin.End
+	}
+
+	return append(out, p.content[last:]...), nil
+}
+
+// Manager of pip requirements files, i.e. requirements.txt or
+// requirements-dev.txt
+type PipDependencyManager struct {
+	pythonManager
+}
+
+func NewPipDependencyManager(config PythonConfig, cache *source.CacheStore) (*PipDependencyManager, error) {
+	p, err := newPythonManager(config, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PipDependencyManager{pythonManager: p}, nil
+}
+
+func (*PipDependencyManager) GetName() string {
+	return "PipManager"
+}
+
+func (*PipDependencyManager) GetFileName() string {
+	return "requirements.txt"
+}
+
+func (*PipDependencyManager) MatchFile(p string) bool {
+	ok, _ := path.Match("requirements*.txt", path.Base(p))
+	return ok
+}
+
+// Requirements whose lines carry --hash options are skipped, as the hashes
+// can not be updated along with the pin.
+func (m *PipDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	m.reset(contents)
+
+	newDeps := make([]domain.Dependency, 0)
+	lines := strings.SplitAfter(string(contents), "\n")
+	offset := 0
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		start := offset
+		offset += len(line)
+
+		// Options, such as hashes, may continue a requirement over the
+		// following lines
+		logical := line
+		for continuesLine(lines[i]) && i+1 < len(lines) {
+			i++
+			logical += lines[i]
+			offset += len(lines[i])
+		}
+
+		requirement := strings.TrimRight(stripRequirementComment(line), "\\\r\n")
+		if strings.HasPrefix(strings.TrimSpace(requirement), "-") {
+			continue
+		}
+
+		if strings.Contains(logical, "--hash") {
+			if pythonPinRe.MatchString(requirement) {
+				slog.Warn("Skipping dependency pinned with hashes", slog.String("requirement", strings.TrimSpace(requirement)))
+			}
+
+			continue
+		}
+
+		if d, ok := m.addPin(requirement, start); ok {
+			newDeps = append(newDeps, d)
+		}
+	}
+
+	return dedupeDependencies(newDeps), nil
+}
+
+func continuesLine(line string) bool {
+	return strings.HasSuffix(strings.TrimRight(line, "\r\n"), "\\")
+}
+
+// Drop a trailing comment, which pip only recognises after whitespace
+func stripRequirementComment(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return ""
+	}
+
+	for i := 1; i < len(line); i++ {
+		if line[i] == '#' && (line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// Manager of PEP 621 project metadata in pyproject.toml, covering
+// `project.dependencies` and `project.optional-dependencies`
+type PyProjectDependencyManager struct {
+	pythonManager
+	name    string
+	members map[string]string // Projects of the repository, by normalised name
+}
+
+func NewPyProjectDependencyManager(config PythonConfig, cache *source.CacheStore) (*PyProjectDependencyManager, error) {
+	p, err := newPythonManager(config, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PyProjectDependencyManager{pythonManager: p}, nil
+}
+
+func (*PyProjectDependencyManager) GetName() string {
+	return "PyProjectManager"
+}
+
+func (*PyProjectDependencyManager) GetFileName() string {
+	return "pyproject.toml"
+}
+
+func (m *PyProjectDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	m.reset(contents)
+	m.name = ""
+
+	newDeps := make([]domain.Dependency, 0)
+
+	p := unstable.Parser{}
+	p.Reset(contents)
+
+	table := make([]string, 0)
+
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = tomlKey(expr.Key())
+		case unstable.KeyValue:
+			key := append(append([]string{}, table...), tomlKey(expr.Key())...)
+			value := expr.Value()
+
+			if strings.Join(key, ".") == "project.name" && value.Kind == unstable.String {
+				m.name = source.NormalizePyPIName(string(value.Data))
+			}
+
+			isRequirements := strings.Join(key, ".") == "project.dependencies" ||
+				(len(key) == 3 && key[0] == "project" && key[1] == "optional-dependencies")
+
+			if !isRequirements || value.Kind != unstable.Array {
+				continue
+			}
+
+			it := value.Children()
+			for it.Next() {
+				if d, ok := m.addStringPin(it.Node()); ok {
+					newDeps = append(newDeps, d)
+				}
+			}
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+
+	return dedupeDependencies(newDeps), nil
+}
+
+// Record the requirement held by a TOML string. Strings containing escape
+// sequences are skipped as their content does not map onto the file.
+func (m *PyProjectDependencyManager) addStringPin(n *unstable.Node) (domain.Dependency, bool) {
+	if n.Kind != unstable.String {
+		return domain.Dependency{}, false
+	}
+
+	raw := m.content[n.Raw.Offset : n.Raw.Offset+n.Raw.Length]
+
+	quote := 1
+	if bytes.HasPrefix(raw, []byte(`"""`)) || bytes.HasPrefix(raw, []byte("'''")) {
+		quote = 3
+	}
+
+	if len(raw) < 2*quote || !bytes.Equal(raw[quote:len(raw)-quote], n.Data) {
+		return domain.Dependency{}, false
+	}
+
+	return m.addPin(string(n.Data), int(n.Raw.Offset)+quote)
+}
+
+func (m *PyProjectDependencyManager) GetManifestName() string {
+	return m.name
+}
+
+func (m *PyProjectDependencyManager) SetWorkspace(_ string, members map[string]string, _ func(string) ([]byte, error)) {
+	m.members = members
+}
+
+// Projects of the same repository are referenced by path
+func (m *PyProjectDependencyManager) IsLocal(name string) bool {
+	_, ok := m.members[source.NormalizePyPIName(name)]
+	return ok
+}
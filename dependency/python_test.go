@@ -0,0 +1,138 @@
+package dependency_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const requirementsTxt = `# Web
+Django==4.2.7  # LTS
+requests[socks] == 2.31.0 ; python_version >= "3.8"
+-r requirements-base.txt
+urllib3>=2.0
+hashed==1.0.0 \
+    --hash=sha256:abc
+`
+
+func TestPipManager(t *testing.T) {
+	m, err := dependency.NewPipDependencyManager(dependency.PythonConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.MatchFile("services/api/requirements-dev.txt") || m.MatchFile("docs/requirements.md") {
+		t.Error("unexpected requirements file matching")
+	}
+
+	deps, err := m.ParseFile([]byte(requirementsTxt))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 || deps[0].Name != "django" || deps[1].Name != "requests" {
+		t.Fatalf("unexpected dependencies %v", deps)
+	}
+
+	for _, u := range []domain.Dependency{
+		{Name: "Django", Version: domain.MustParseVersion(source.PEP440, "4.2.8")},
+		{Name: "requests", Version: domain.MustParseVersion(source.PEP440, "2.32.0rc1")},
+	} {
+		if err := m.ApplyDependency(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.NewReplacer("4.2.7", "4.2.8", "2.31.0", "2.32.0rc1").Replace(requirementsTxt)
+	if string(file) != want {
+		t.Errorf("unexpected requirements.txt:\n%s\nwant:\n%s", file, want)
+	}
+}
+
+func TestPipManagerKeepsVersionText(t *testing.T) {
+	m, err := dependency.NewPipDependencyManager(dependency.PythonConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte("lib==1.0\nother==2.0\n"))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	// Versions are never padded to three segments
+	if len(deps) != 2 || deps[0].Version.String() != "1.0" {
+		t.Fatalf("expected lib 1.0 as written, got %v", deps)
+	}
+
+	if err := m.ApplyDependency(domain.Dependency{Name: "lib", Version: domain.MustParseVersion(source.PEP440, "1.1.post1")}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(file) != "lib==1.1.post1\nother==2.0\n" {
+		t.Errorf("unexpected requirements.txt:\n%s", file)
+	}
+}
+
+const pyprojectToml = `[project]
+name = "My_App"
+dependencies = [
+    "httpx==0.25.0",  # client
+    'rich>=13',
+]
+
+[project.optional-dependencies]
+test = ["pytest[testing]==7.4.3; sys_platform != 'win32'"]
+`
+
+func TestPyProjectManager(t *testing.T) {
+	m, err := dependency.NewPyProjectDependencyManager(dependency.PythonConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := m.ParseFile([]byte(pyprojectToml))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if len(deps) != 2 || deps[0].Name != "httpx" || deps[1].Name != "pytest" {
+		t.Fatalf("unexpected dependencies %v", deps)
+	}
+
+	if m.GetManifestName() != "my-app" {
+		t.Errorf("unexpected manifest name %q", m.GetManifestName())
+	}
+
+	for _, u := range []domain.Dependency{
+		{Name: "httpx", Version: domain.MustParseVersion(source.PEP440, "0.26.0")},
+		{Name: "pytest", Version: domain.MustParseVersion(source.PEP440, "8.0.0")},
+	} {
+		if err := m.ApplyDependency(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.NewReplacer("0.25.0", "0.26.0", "7.4.3", "8.0.0").Replace(pyprojectToml)
+	if string(file) != want {
+		t.Errorf("unexpected pyproject.toml:\n%s\nwant:\n%s", file, want)
+	}
+}
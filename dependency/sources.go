@@ -0,0 +1,36 @@
+package dependency
+
+import (
+	"fmt"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+// Names of the version sources selectable in config
+const (
+	VersionSourceRegistry = "registry"
+	VersionSourceDepsDev  = "depsdev"
+	VersionSourceGoProxy  = "goproxy"
+)
+
+// Pick the source versions are looked up in by its name in config. The
+// ecosystem's registry is the default, with deps.dev as the alternative for
+// the ecosystems it indexes: Go, npm, Cargo, PyPI and Maven. Docker images,
+// Helm charts, Terraform providers and CI references are only found in their
+// registries, so their managers offer no choice.
+func selectVersionSource(
+	ecosystem string,
+	name string,
+	registry domain.VersionSource,
+	system string,
+) (domain.VersionSource, error) {
+	switch name {
+	case VersionSourceRegistry, "":
+		return registry, nil
+	case VersionSourceDepsDev:
+		return source.NewDepsDev(system), nil
+	default:
+		return nil, fmt.Errorf("unknown %s version source %s", ecosystem, name)
+	}
+}
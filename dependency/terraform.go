@@ -0,0 +1,438 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const terraformLockFile = ".terraform.lock.hcl"
+
+// Matches version constraints dependy can update: a single version,
+// optionally preceded by the = or ~> operator which is kept on update along
+// with the precision of the version, i.e. ~> 5.0 moves to ~> 5.31
+var terraformSpecRe = regexp.MustCompile(`^(\s*(?:=|~>)?\s*)v?([0-9]+(?:\.[0-9]+){0,2}(?:-[0-9A-Za-z.-]+)?)(\s*)$`)
+
+type TerraformConfig struct {
+	Registry      source.TerraformRegistryConfig // Registry providers and modules are looked up in
+	LockPlatforms []string                       // Platforms, i.e. linux_amd64, whose h1: hashes are added to the lock file. Defaults to linux_amd64
+	SkipLockFile  bool                           // Leave .terraform.lock.hcl untouched
+}
+
+func NewTerraformDependencyManager(config TerraformConfig, cache *source.CacheStore) *TerraformDependencyManager {
+	registry := source.NewTerraformRegistry(config.Registry)
+
+	t := &TerraformDependencyManager{
+		Registry:  registry,
+		platforms: config.LockPlatforms,
+		skipLock:  config.SkipLockFile,
+	}
+	t.Source = &precisionVersionSource{VersionSource: cache.Wrap(t.GetEcosystem(), registry)}
+
+	return t
+}
+
+// Manager of the provider and module versions of a Terraform configuration
+//
+// Every .tf file is handled on its own. Providers are updated within
+// required_providers and modules when sourced from a registry.
+type TerraformDependencyManager struct {
+	Registry  *source.TerraformRegistry
+	Source    domain.VersionSource
+	platforms []string
+	skipLock  bool
+	content   []byte
+	specs     []terraformSpec
+	applied   map[string]domain.Version // Applied updates by source address
+}
+
+// Location of an updatable version constraint
+type terraformSpec struct {
+	Source   string
+	Provider bool
+	Old      string // Constraint as written
+	Prefix   string // Operator and surrounding whitespace kept on update
+	Suffix   string
+	Segments int // Precision of the version kept on update
+	Start    int
+	End      int
+}
+
+func (*TerraformDependencyManager) GetName() string {
+	return "TerraformManager"
+}
+
+func (*TerraformDependencyManager) GetEcosystem() string {
+	return "Terraform"
+}
+
+func (*TerraformDependencyManager) GetFileName() string {
+	return "main.tf"
+}
+
+func (*TerraformDependencyManager) MatchFile(p string) bool {
+	return path.Ext(p) == ".tf"
+}
+
+func (t *TerraformDependencyManager) ParseFile(contents []byte) ([]domain.Dependency, error) {
+	file, diags := hclsyntax.ParseConfig(contents, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	t.content = contents
+	t.specs = make([]terraformSpec, 0)
+	t.applied = make(map[string]domain.Version)
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("unexpected HCL body")
+	}
+
+	newDeps := make([]domain.Dependency, 0)
+	seen := make(map[string]bool)
+
+	add := func(src string, provider bool, version hclsyntax.Expression) {
+		d, ok := t.addSpec(src, provider, version)
+		if ok && !seen[d.Name] {
+			seen[d.Name] = true
+			newDeps = append(newDeps, d)
+		}
+	}
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "terraform":
+			for _, b := range block.Body.Blocks {
+				if b.Type != "required_providers" {
+					continue
+				}
+
+				for _, attr := range sortedAttributes(b.Body) {
+					src, version := requiredProvider(contents, attr.Name, attr.Expr)
+					if version != nil {
+						add(src, true, version)
+					}
+				}
+			}
+		case "module":
+			src, srcOk := block.Body.Attributes["source"]
+			version, versionOk := block.Body.Attributes["version"]
+
+			if !srcOk || !versionOk {
+				continue
+			}
+
+			if s, _, _, ok := hclString(contents, src.Expr); ok {
+				add(s, false, version.Expr)
+			}
+		}
+	}
+
+	return newDeps, nil
+}
+
+// Attributes in file order, as ranging over a map would shuffle them
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, a := range body.Attributes {
+		attrs = append(attrs, a)
+	}
+
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte })
+
+	return attrs
+}
+
+// Read the source and version constraint of a provider requirement, given
+// either as an object or, in the legacy form, as a bare version constraint.
+// Providers without a source belong to the hashicorp namespace.
+func requiredProvider(contents []byte, name string, expr hclsyntax.Expression) (string, hclsyntax.Expression) {
+	src := "hashicorp/" + name
+
+	obj, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return src, expr
+	}
+
+	var version hclsyntax.Expression
+
+	for _, item := range obj.Items {
+		key := hcl.ExprAsKeyword(item.KeyExpr)
+		if key == "" {
+			key, _, _, _ = hclString(contents, item.KeyExpr)
+		}
+
+		switch key {
+		case "source":
+			if s, _, _, ok := hclString(contents, item.ValueExpr); ok {
+				src = s
+			}
+		case "version":
+			version = item.ValueExpr
+		}
+	}
+
+	return src, version
+}
+
+// Read a string literal written without escapes or interpolation, along
+// with the offsets of its content
+func hclString(contents []byte, expr hcl.Expression) (string, int, int, bool) {
+	tmpl, ok := hcl.UnwrapExpression(expr).(*hclsyntax.TemplateExpr)
+	if !ok || len(tmpl.Parts) != 1 {
+		return "", 0, 0, false
+	}
+
+	lit, ok := tmpl.Parts[0].(*hclsyntax.LiteralValueExpr)
+	if !ok || lit.Val.Type() != cty.String {
+		return "", 0, 0, false
+	}
+
+	value := lit.Val.AsString()
+	start, end := tmpl.SrcRange.Start.Byte+1, tmpl.SrcRange.End.Byte-1
+
+	if start > end || end > len(contents) || string(contents[start:end]) != value {
+		return "", 0, 0, false
+	}
+
+	return value, start, end, true
+}
+
+// Record a version constraint if it can be updated
+func (t *TerraformDependencyManager) addSpec(src string, provider bool, expr hclsyntax.Expression) (domain.Dependency, bool) {
+	addr, err := t.Registry.ParseAddress(src)
+	if err != nil || addr.IsModule() == provider {
+		slog.Debug("Skipping source not in a registry", slog.String("source", src))
+		return domain.Dependency{}, false
+	}
+
+	spec, start, end, ok := hclString(t.content, expr)
+	if !ok {
+		return domain.Dependency{}, false
+	}
+
+	m := terraformSpecRe.FindStringSubmatch(spec)
+	if m == nil {
+		slog.Debug(
+			"Skipping dependency with unsupported version constraint",
+			slog.String("dependency", src),
+			slog.String("version", spec),
+		)
+
+		return domain.Dependency{}, false
+	}
+
+	v, err := domain.ParseVersion(domain.Semver, m[2])
+	if err != nil {
+		return domain.Dependency{}, false
+	}
+
+	release, _, _ := strings.Cut(m[2], "-")
+
+	t.specs = append(t.specs, terraformSpec{
+		Source:   src,
+		Provider: provider,
+		Old:      spec,
+		Prefix:   m[1],
+		Suffix:   m[3],
+		Segments: strings.Count(release, ".") + 1,
+		Start:    start,
+		End:      end,
+	})
+
+	return domain.Dependency{Name: src, Version: v}, true
+}
+
+// Format a version at the precision of the constraint it replaces
+func (s terraformSpec) format(v domain.Version) string {
+	if s.Segments == 3 || v.Prerelease() {
+		return s.Prefix + v.String() + s.Suffix
+	}
+
+	parts := []uint64{v.Major(), v.Minor(), v.Patch()}[:s.Segments]
+	text := make([]string, len(parts))
+
+	for i, p := range parts {
+		text[i] = strconv.FormatUint(p, 10)
+	}
+
+	return s.Prefix + strings.Join(text, ".") + s.Suffix
+}
+
+func (t *TerraformDependencyManager) GetVersionSource() domain.VersionSource {
+	return t.Source
+}
+
+func (*TerraformDependencyManager) FetchDeprecation(domain.Dependency) (*domain.Deprecation, error) {
+	return nil, nil
+}
+
+func (t *TerraformDependencyManager) ApplyDependency(dependency domain.Dependency) error {
+	for _, s := range t.specs {
+		if s.Source == dependency.Name {
+			t.applied[dependency.Name] = dependency.Version
+			return nil
+		}
+	}
+
+	return errors.New("dependency not found in file")
+}
+
+func (t *TerraformDependencyManager) GetFile() ([]byte, error) {
+	edits := make([]textEdit, 0)
+
+	for _, s := range t.specs {
+		if v, ok := t.applied[s.Source]; ok {
+			edits = append(edits, textEdit{Start: s.Start, End: s.End, Text: s.format(v)})
+		}
+	}
+
+	return applyEdits(t.content, edits), nil
+}
+
+func (t *TerraformDependencyManager) GetCompanionFiles() []string {
+	if t.skipLock {
+		return nil
+	}
+
+	return []string{terraformLockFile}
+}
+
+// Move every updated provider locked in .terraform.lock.hcl to its new
+// version along with its constraint and package hashes
+//
+// The zh: hashes of every platform are taken from the checksums published
+// with the provider, while h1: hashes require downloading the package of
+// each configured platform, linux_amd64 unless configured otherwise. The h1:
+// hashes of the previous version are dropped, as installation methods only
+// checking those would reject the new package. Providers which can not be
+// hashed are left alone and logged, to be locked by running terraform init.
+func (t *TerraformDependencyManager) UpdateCompanions(current map[string][]byte) (map[string][]byte, error) {
+	content := current[terraformLockFile]
+	if content == nil || len(t.applied) == 0 {
+		return nil, nil
+	}
+
+	lock, diags := hclwrite.ParseConfig(content, terraformLockFile, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("%s: %w", terraformLockFile, diags)
+	}
+
+	changed := false
+
+	for _, s := range t.specs {
+		v, ok := t.applied[s.Source]
+		if !ok || !s.Provider {
+			continue
+		}
+
+		addr, err := t.Registry.ParseAddress(s.Source)
+		if err != nil {
+			continue
+		}
+
+		block := lock.Body().FirstMatchingBlock("provider", []string{addr.String()})
+		if block == nil {
+			continue
+		}
+
+		hashes, err := t.packageHashes(addr, v.String())
+		if err != nil {
+			slog.Warn(
+				terraformLockFile+" can not be updated, run terraform init -upgrade to update it",
+				slog.String("dependency", s.Source),
+				slog.Any("error", err),
+			)
+
+			continue
+		}
+
+		body := block.Body()
+		body.SetAttributeValue("version", cty.StringVal(v.String()))
+
+		if c := body.GetAttribute("constraints"); c != nil && strings.TrimSpace(string(c.Expr().BuildTokens(nil).Bytes())) == strconv.Quote(s.Old) {
+			body.SetAttributeValue("constraints", cty.StringVal(s.format(v)))
+		}
+
+		body.SetAttributeRaw("hashes", hashListTokens(hashes))
+
+		changed = true
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	return map[string][]byte{terraformLockFile: hclwrite.Format(lock.Bytes())}, nil
+}
+
+// Collect the hashes of a provider version, h1: hashes first as Terraform
+// orders them
+func (t *TerraformDependencyManager) packageHashes(addr source.TerraformAddress, version string) ([]string, error) {
+	platforms := t.platforms
+	if len(platforms) == 0 {
+		platforms = []string{"linux_amd64"}
+	}
+
+	pkg, err := t.Registry.ProviderPackage(addr, version, platforms[0])
+	if err != nil {
+		return nil, err
+	}
+
+	zh, err := t.Registry.PackageHashes(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	h1 := make([]string, 0, len(platforms))
+
+	for _, platform := range platforms {
+		if platform != platforms[0] {
+			if pkg, err = t.Registry.ProviderPackage(addr, version, platform); err != nil {
+				return nil, err
+			}
+		}
+
+		h, err := t.Registry.PackageContentHash(pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		h1 = append(h1, h)
+	}
+
+	sort.Strings(h1)
+
+	return append(h1, zh...), nil
+}
+
+// Lay out a list of hashes one per line, as Terraform writes them
+func hashListTokens(hashes []string) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+
+	for _, h := range hashes {
+		tokens = append(tokens, hclwrite.TokensForValue(cty.StringVal(h))...)
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+}
@@ -0,0 +1,248 @@
+package dependency_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const terraformFile = `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0" # major version pinned
+    }
+    random = "3.5.1"
+    local = {
+      source  = "hashicorp/local"
+      version = ">= 2.0"
+    }
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.2"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+`
+
+const terraformLock = `# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.0.1"
+  constraints = "~> 5.0"
+  hashes = [
+    "h1:old",
+    "zh:old",
+  ]
+}
+
+provider "registry.terraform.io/hashicorp/random" {
+  version = "3.5.1"
+  hashes = [
+    "h1:random",
+  ]
+}
+`
+
+func TestTerraformManager(t *testing.T) {
+	var pkg bytes.Buffer
+
+	zw := zip.NewWriter(&pkg)
+	w, _ := zw.Create("terraform-provider-aws_v5.31.0")
+	_, _ = w.Write([]byte("provider"))
+	_ = zw.Close()
+
+	sum := sha256.Sum256(pkg.Bytes())
+	shasum := hex.EncodeToString(sum[:])
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/terraform.json":
+			_, _ = w.Write([]byte(`{"providers.v1": "/v1/providers/", "modules.v1": "/v1/modules/"}`))
+		case "/v1/providers/hashicorp/aws/versions":
+			_, _ = w.Write([]byte(`{"versions": [{"version": "5.0.1"}, {"version": "5.31.0"}, {"version": "6.0.0-beta1"}]}`))
+		case "/v1/providers/hashicorp/random/versions":
+			_, _ = w.Write([]byte(`{"versions": [{"version": "3.5.1"}, {"version": "3.6.0"}]}`))
+		case "/v1/modules/terraform-aws-modules/vpc/aws/versions":
+			_, _ = w.Write([]byte(`{"modules": [{"versions": [{"version": "5.1.2"}, {"version": "5.5.1"}]}]}`))
+		case "/v1/providers/hashicorp/aws/5.31.0/download/linux/amd64":
+			_, _ = w.Write([]byte(`{
+				"filename": "terraform-provider-aws_5.31.0_linux_amd64.zip",
+				"download_url": "` + server.URL + `/files/aws_linux_amd64.zip",
+				"shasum": "` + shasum + `",
+				"shasums_url": "` + server.URL + `/files/SHA256SUMS"
+			}`))
+		case "/files/SHA256SUMS":
+			_, _ = w.Write([]byte(shasum + "  terraform-provider-aws_5.31.0_linux_amd64.zip\n" +
+				"0123  terraform-provider-aws_5.31.0_darwin_arm64.zip\n" +
+				"4567  terraform-provider-aws_5.31.0_manifest.json\n"))
+		case "/files/aws_linux_amd64.zip":
+			_, _ = w.Write(pkg.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := dependency.NewTerraformDependencyManager(dependency.TerraformConfig{
+		Registry:      source.TerraformRegistryConfig{URL: server.URL},
+		LockPlatforms: []string{"linux_amd64"},
+	}, nil)
+
+	deps, err := m.ParseFile([]byte(terraformFile))
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, d := range deps {
+		got[d.Name] = d.Version.String()
+	}
+
+	want := map[string]string{"hashicorp/aws": "5.0", "hashicorp/random": "3.5.1", "terraform-aws-modules/vpc/aws": "5.1.2"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("expected %s %s, got %q", name, v, got[name])
+		}
+	}
+
+	for _, d := range deps {
+		versions, err := m.GetVersionSource().FetchVersions(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		latest, ok := versions.Latest(nil)
+		if !ok {
+			t.Fatalf("no versions of %s", d.Name)
+		}
+
+		if d.Name == "hashicorp/random" {
+			continue
+		}
+
+		if err := m.ApplyDependency(domain.Dependency{Name: d.Name, Version: latest.Version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	companions, err := m.UpdateCompanions(map[string][]byte{".terraform.lock.hcl": []byte(terraformLock)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock := string(companions[".terraform.lock.hcl"])
+
+	for _, s := range []string{
+		`version     = "5.31.0"`,
+		`constraints = "~> 5.31"`,
+		`"zh:0123",`,
+		`"zh:` + shasum + `",`,
+		`"h1:random",`,
+	} {
+		if !strings.Contains(lock, s) {
+			t.Errorf("expected lock file to contain %s:\n%s", s, lock)
+		}
+	}
+
+	if strings.Contains(lock, "h1:old") || strings.Contains(lock, "4567") || strings.Count(lock, `"h1:`) != 2 {
+		t.Errorf("unexpected hashes in lock file:\n%s", lock)
+	}
+
+	file, err := m.GetFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.NewReplacer(`"~> 5.0"`, `"~> 5.31"`, `"5.1.2"`, `"5.5.1"`).Replace(terraformFile)
+
+	if string(file) != expected {
+		t.Errorf("unexpected main.tf:\n%s\nwant:\n%s", file, expected)
+	}
+}
+
+func TestTerraformLockWithoutPlatforms(t *testing.T) {
+	var pkg bytes.Buffer
+
+	zw := zip.NewWriter(&pkg)
+	w, _ := zw.Create("terraform-provider-aws_v5.31.0")
+	_, _ = w.Write([]byte("provider"))
+	_ = zw.Close()
+
+	sum := sha256.Sum256(pkg.Bytes())
+	shasum := hex.EncodeToString(sum[:])
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/terraform.json":
+			_, _ = w.Write([]byte(`{"providers.v1": "/v1/providers/"}`))
+		case "/v1/providers/hashicorp/aws/5.31.0/download/linux/amd64":
+			_, _ = w.Write([]byte(`{
+				"filename": "terraform-provider-aws_5.31.0_linux_amd64.zip",
+				"download_url": "` + server.URL + `/files/aws_linux_amd64.zip",
+				"shasum": "` + shasum + `",
+				"shasums_url": "` + server.URL + `/files/SHA256SUMS"
+			}`))
+		case "/files/SHA256SUMS":
+			_, _ = w.Write([]byte(shasum + "  terraform-provider-aws_5.31.0_linux_amd64.zip\n"))
+		case "/files/aws_linux_amd64.zip":
+			_, _ = w.Write(pkg.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	m := dependency.NewTerraformDependencyManager(dependency.TerraformConfig{
+		Registry: source.TerraformRegistryConfig{URL: server.URL},
+	}, nil)
+
+	if _, err := m.ParseFile([]byte(terraformFile)); err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	if err := m.ApplyDependency(domain.Dependency{Name: "hashicorp/aws", Version: domain.MustParseVersion(domain.Semver, "5.31.0")}); err != nil {
+		t.Fatal(err)
+	}
+
+	companions, err := m.UpdateCompanions(map[string][]byte{".terraform.lock.hcl": []byte(terraformLock)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock := string(companions[".terraform.lock.hcl"])
+
+	// The h1: hash is computed for linux_amd64 in place of the previous one
+	for _, s := range []string{`version     = "5.31.0"`, `"zh:` + shasum + `",`} {
+		if !strings.Contains(lock, s) {
+			t.Errorf("expected lock file to contain %s:\n%s", s, lock)
+		}
+	}
+
+	if strings.Contains(lock, "h1:old") || strings.Contains(lock, "zh:old") || strings.Count(lock, `"h1:`) != 2 {
+		t.Errorf("unexpected hashes of the previous version:\n%s", lock)
+	}
+}
@@ -0,0 +1,34 @@
+package dependency
+
+import "sort"
+
+// A replacement of the bytes between Start and End
+type textEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Apply non-overlapping edits. Edits repeated or overlapping an earlier one,
+// such as those of Dockerfile stages sharing a build argument, are dropped.
+func applyEdits(content []byte, edits []textEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+
+	out := make([]byte, 0, len(content))
+	last := 0
+	seen := make(map[textEdit]bool)
+
+	for _, e := range edits {
+		if (e.Start == e.End && e.Text == "") || seen[e] || e.Start < last {
+			continue
+		}
+
+		seen[e] = true
+
+		out = append(out, content[last:e.Start]...)
+		out = append(out, e.Text...)
+		last = e.End
+	}
+
+	return append(out, content[last:]...)
+}
@@ -0,0 +1,188 @@
+package dependency
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+const (
+	ToolchainPolicyPatch  = "patch"  // Latest patch of the current minor release
+	ToolchainPolicyLatest = "latest" // Latest release
+
+	// Prefix of policies following the newest minor release at a distance,
+	// i.e. n-1 for the latest patch of the previous minor release
+	toolchainPolicyLagPrefix = "n-"
+)
+
+const (
+	goDirective        = "go"
+	toolchainDirective = "toolchain"
+)
+
+// First Go release understanding the toolchain directive and patch versions
+// in the go directive
+var go121 = domain.MustParseVersion(source.GoRelease, "1.21")
+
+type ToolchainConfig struct {
+	Policy      string // Release to move to: patch, latest or n-<k>. Empty disables toolchain updates
+	Releases    string // Go release list in go.dev/dl JSON format. file:// URLs read a local mirror
+	GoDirective bool   // Also raise the go directive, the minimum version required of every consumer of the module
+}
+
+// Find the release the toolchain should move to according to the configured
+// policy
+//
+// The toolchain directive is kept at the target release. The go directive
+// is only raised when configured to, as it forces the new version onto
+// everyone depending on the module. Toolchains are never downgraded.
+func (g *GoLangDependencyManager) FetchToolchainUpdates() ([]domain.Dependency, error) {
+	if g.toolchain.Policy == "" || g.ModFile.Go == nil {
+		return nil, nil
+	}
+
+	goVersion, err := source.ParseGoVersion(g.ModFile.Go.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	current := goVersion
+
+	if g.ModFile.Toolchain != nil {
+		if v, err := source.ParseGoVersion(g.ModFile.Toolchain.Name); err == nil && v.GreaterThan(current) {
+			current = v
+		}
+	}
+
+	versions, err := g.releases.FetchVersions(domain.Dependency{Name: goDirective})
+	if err != nil {
+		return nil, err
+	}
+
+	target, ok, err := selectRelease(g.toolchain.Policy, current, versions)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	// Raising the go directive makes a toolchain directive redundant
+	if g.toolchain.GoDirective && target.GreaterThan(goVersion) && formatGoDirective(target) != g.ModFile.Go.Version {
+		return []domain.Dependency{{Name: goDirective, Version: target}}, nil
+	}
+
+	if !target.GreaterThan(current) {
+		return nil, nil
+	}
+
+	if goVersion.LessThan(go121) {
+		slog.Debug("Toolchain directive requires go 1.21 or later", slog.String("go", g.ModFile.Go.Version))
+		return nil, nil
+	}
+
+	return []domain.Dependency{{Name: toolchainDirective, Version: target}}, nil
+}
+
+func (g *GoLangDependencyManager) ApplyToolchain(update domain.Dependency) error {
+	switch update.Name {
+	case goDirective:
+		if err := g.ModFile.AddGoStmt(formatGoDirective(update.Version)); err != nil {
+			return err
+		}
+
+		// A toolchain no newer than the go directive is redundant
+		if g.ModFile.Toolchain != nil {
+			if v, err := source.ParseGoVersion(g.ModFile.Toolchain.Name); err == nil && !v.GreaterThan(update.Version) {
+				g.ModFile.DropToolchainStmt()
+			}
+		}
+
+		return nil
+	case toolchainDirective:
+		return g.ModFile.AddToolchainStmt("go" + source.GoReleaseName(update.Version))
+	default:
+		return fmt.Errorf("unknown toolchain directive %s", update.Name)
+	}
+}
+
+// Releases before go 1.21 are referred to by their minor version alone
+func formatGoDirective(v domain.Version) string {
+	if v.LessThan(go121) {
+		return fmt.Sprintf("%d.%d", v.Major(), v.Minor())
+	}
+
+	return source.GoReleaseName(v)
+}
+
+// Pick the release a toolchain policy targets. Returns false if no release
+// qualifies.
+func selectRelease(policy string, current domain.Version, versions domain.Versions) (domain.Version, bool, error) {
+	switch {
+	case policy == ToolchainPolicyPatch:
+		c, err := domain.NewSemverConstraint(fmt.Sprintf("~%d.%d.0", current.Major(), current.Minor()))
+		if err != nil {
+			return domain.Version{}, false, err
+		}
+
+		latest, ok := versions.Latest(c)
+
+		return latest.Version, ok, nil
+	case policy == ToolchainPolicyLatest:
+		latest, ok := versions.Latest(nil)
+		return latest.Version, ok, nil
+	case strings.HasPrefix(policy, toolchainPolicyLagPrefix):
+		lag, err := strconv.Atoi(strings.TrimPrefix(policy, toolchainPolicyLagPrefix))
+		if err != nil || lag < 0 {
+			return domain.Version{}, false, fmt.Errorf("invalid toolchain policy %s", policy)
+		}
+
+		minors := releasedMinors(versions)
+		if lag >= len(minors) {
+			return domain.Version{}, false, nil
+		}
+
+		latest, ok := versions.Latest(minors[lag])
+
+		return latest.Version, ok, nil
+	default:
+		return domain.Version{}, false, fmt.Errorf("unknown toolchain policy %s", policy)
+	}
+}
+
+// Get a constraint matching each minor release line with a stable release,
+// newest first
+func releasedMinors(versions domain.Versions) []domain.Constraint {
+	seen := make(map[[2]uint64]bool)
+	lines := make([][2]uint64, 0)
+
+	for _, v := range versions {
+		if v.Prerelease {
+			continue
+		}
+
+		line := [2]uint64{v.Version.Major(), v.Version.Minor()}
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i][0] != lines[j][0] {
+			return lines[i][0] > lines[j][0]
+		}
+
+		return lines[i][1] > lines[j][1]
+	})
+
+	constraints := make([]domain.Constraint, len(lines))
+
+	for i, l := range lines {
+		constraints[i], _ = domain.NewSemverConstraint(fmt.Sprintf("~%d.%d.0", l[0], l[1]))
+	}
+
+	return constraints
+}
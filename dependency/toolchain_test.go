@@ -0,0 +1,112 @@
+package dependency_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/dependency"
+)
+
+const releaseList = `[
+	{"version": "go1.23rc1", "stable": false},
+	{"version": "go1.22.4", "stable": true},
+	{"version": "go1.22.3", "stable": true},
+	{"version": "go1.21.11", "stable": true},
+	{"version": "go1.21.10", "stable": true},
+	{"version": "go1.20", "stable": true}
+]`
+
+func TestToolchainUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "releases.json")
+	if err := os.WriteFile(path, []byte(releaseList), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  dependency.ToolchainConfig
+		modFile string
+		want    string // Expected directive line, empty if no update
+	}{
+		{
+			name:    "patch",
+			config:  dependency.ToolchainConfig{Policy: dependency.ToolchainPolicyPatch},
+			modFile: "module example.com/app\n\ngo 1.21\n\ntoolchain go1.21.10\n",
+			want:    "toolchain go1.21.11",
+		},
+		{
+			name:    "previous minor",
+			config:  dependency.ToolchainConfig{Policy: "n-1"},
+			modFile: "module example.com/app\n\ngo 1.21.0\n",
+			want:    "toolchain go1.21.11",
+		},
+		{
+			name:    "latest go directive",
+			config:  dependency.ToolchainConfig{Policy: dependency.ToolchainPolicyLatest, GoDirective: true},
+			modFile: "module example.com/app\n\ngo 1.21.0\n\ntoolchain go1.22.3\n",
+			want:    "go 1.22.4",
+		},
+		{
+			name:    "never downgrade",
+			config:  dependency.ToolchainConfig{Policy: "n-1"},
+			modFile: "module example.com/app\n\ngo 1.22.0\n",
+		},
+		{
+			name:    "no toolchain directive before go 1.21",
+			config:  dependency.ToolchainConfig{Policy: dependency.ToolchainPolicyLatest},
+			modFile: "module example.com/app\n\ngo 1.20\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Releases = "file://" + path
+
+			m, err := dependency.NewGoLangDependencyManager(dependency.GoConfig{Toolchain: tt.config}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := m.ParseFile([]byte(tt.modFile)); err != nil {
+				t.Fatalf("ParseFile: %v", err)
+			}
+
+			updates, err := m.FetchToolchainUpdates()
+			if err != nil {
+				t.Fatalf("FetchToolchainUpdates: %v", err)
+			}
+
+			if tt.want == "" {
+				if len(updates) != 0 {
+					t.Errorf("expected no update, got %+v", updates)
+				}
+
+				return
+			}
+
+			if len(updates) != 1 {
+				t.Fatalf("expected 1 update, got %+v", updates)
+			}
+
+			if err := m.ApplyToolchain(updates[0]); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := m.GetFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(out), tt.want+"\n") {
+				t.Errorf("expected %q, got:\n%s", tt.want, out)
+			}
+
+			// Raising the go directive past the toolchain drops the toolchain
+			if tt.config.GoDirective && strings.Contains(string(out), "toolchain") {
+				t.Errorf("expected redundant toolchain directive to be dropped, got:\n%s", out)
+			}
+		})
+	}
+}
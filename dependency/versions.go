@@ -0,0 +1,181 @@
+package dependency
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+const incompatibleSuffix = "+incompatible"
+
+const excludedReason = "excluded in go.mod"
+
+// Applies Go module semantics on top of a generic version source
+//
+//   - Versions retracted by the module are marked. Retractions are declared in
+//     the go.mod of the module's latest version, which registries such as
+//     deps.dev do not expose.
+//   - `+incompatible` versions are excluded unless the dependency is already
+//     on one, matching how the go command resolves `@latest`.
+//   - Versions named by an `exclude` directive of the main module are
+//     excluded.
+//   - Dependencies on a pseudo-version only move to tagged releases published
+//     after the pseudo-version's commit.
+//   - Dependencies configured to track a branch only see the branch's latest
+//     commit.
+type goVersionSource struct {
+	domain.VersionSource
+	manager *GoLangDependencyManager
+}
+
+func (r *goVersionSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	if branch, ok := r.manager.track[dep.Name]; ok {
+		return r.branchHead(dep, branch)
+	}
+
+	versions, err := r.VersionSource.FetchVersions(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	retractions := r.retractions(dep)
+	current := source.GoVersion(dep.Version)
+	compatible := !strings.HasSuffix(current, incompatibleSuffix)
+
+	for i := range versions {
+		raw := source.GoVersion(versions[i].Version)
+		versions[i].Retracted = isRetracted(raw, retractions)
+
+		switch {
+		case r.manager.excluded(module.Version{Path: dep.Name, Version: raw}):
+			versions[i].Excluded = excludedReason
+		case compatible && strings.HasSuffix(raw, incompatibleSuffix):
+			versions[i].Excluded = "incompatible major version"
+		}
+	}
+
+	if module.IsPseudoVersion(current) {
+		r.excludeOlderReleases(dep, versions)
+	}
+
+	return versions, nil
+}
+
+func (r *goVersionSource) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	info, err := r.VersionSource.FetchVersion(dep, version)
+	if err != nil {
+		return info, err
+	}
+
+	raw := source.GoVersion(version)
+	info.Retracted = isRetracted(raw, r.retractions(dep))
+
+	if r.manager.excluded(module.Version{Path: dep.Name, Version: raw}) {
+		info.Excluded = excludedReason
+	}
+
+	return info, nil
+}
+
+// Semver orders any tagged release above a v0.0.0 pseudo-version, even one
+// tagged years before the commit the dependency is on. Exclude releases
+// which would move the dependency back in time.
+func (r *goVersionSource) excludeOlderReleases(dep domain.Dependency, versions domain.Versions) {
+	commit, err := module.PseudoVersionTime(source.GoVersion(dep.Version))
+	if err != nil {
+		return
+	}
+
+	for i := range versions {
+		v := &versions[i]
+		if v.Prerelease || v.Retracted || v.Excluded != "" || !v.Version.GreaterThan(dep.Version) {
+			continue
+		}
+
+		// Not every source lists publish times
+		if v.PublishedAt.IsZero() {
+			info, err := r.VersionSource.FetchVersion(dep, v.Version)
+			if err != nil {
+				slog.Debug("Could not fetch publish time", slog.String("dependency", dep.Name), slog.Any("error", err))
+				continue
+			}
+
+			v.PublishedAt = info.PublishedAt
+		}
+
+		if !v.PublishedAt.IsZero() && v.PublishedAt.Before(commit) {
+			v.Excluded = "tagged before the current pseudo-version commit"
+		}
+	}
+}
+
+// The pseudo-version of a branch's latest commit is reported as a release so
+// policies treat it as the update candidate.
+func (r *goVersionSource) branchHead(dep domain.Dependency, branch string) (domain.Versions, error) {
+	info, err := r.manager.Proxy.Info(dep.Name, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := domain.ParseVersion(domain.Semver, info.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.Versions{{Version: v, PublishedAt: info.Time}}, nil
+}
+
+// Failing to read retractions is not fatal, versions are then assumed to
+// be valid
+func (r *goVersionSource) retractions(dep domain.Dependency) []*modfile.Retract {
+	retractions, err := r.manager.fetchRetractions(dep.Name)
+	if err != nil {
+		slog.Warn("Could not read retractions", slog.String("dependency", dep.Name), slog.Any("error", err))
+	}
+
+	return retractions
+}
+
+func isRetracted(version string, retractions []*modfile.Retract) bool {
+	for _, r := range retractions {
+		if modsemver.Compare(r.Low, version) <= 0 && modsemver.Compare(version, r.High) <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Versions which would not change a constraint written at a lower
+// precision, i.e. 5.0.3 for ~> 5.0 or ^5.0, are not proposed
+type precisionVersionSource struct {
+	domain.VersionSource
+}
+
+func (r *precisionVersionSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	versions, err := r.VersionSource.FetchVersions(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	release, _, _ := strings.Cut(dep.Version.String(), "-")
+	segments := strings.Count(release, ".") + 1
+
+	if segments >= 3 {
+		return versions, nil
+	}
+
+	for i := range versions {
+		v := versions[i].Version
+		if v.Major() == dep.Version.Major() && (segments == 1 || v.Minor() == dep.Version.Minor()) {
+			versions[i].Excluded = "within the current constraint"
+		}
+	}
+
+	return versions, nil
+}
@@ -2,13 +2,16 @@ package domain
 
 // Global configuration attributes
 type GlobalConfig struct {
-	DebugLevel         string   // Debug Level
-	DependencyManagers []string // List of names of dependency managers to enable
-	DefaultPolicy      string   // Default update policy use when one is not specified by the repository
-	RemoteGitProvider  string   // Name of the remote GIT provider
-	TitlePrefix        string   // Prefix to use for merge request titles
-	RemoveSourceBranch bool     // Whether to delete the dependy branch after successfully merging to main branch
-	RunInterval        int
-	SquashCommits      bool // Whether to squash all commits of the dependy branch before merging
-	FilterTag          string
+	DebugLevel          string   // Debug Level
+	DependencyManagers  []string // List of names of dependency managers to enable
+	DefaultPolicy       string   // Default update policy use when one is not specified by the repository
+	IndirectPolicy      string   // Update policy for indirect dependencies, when managers report them. Defaults to DefaultPolicy
+	RemoteGitProvider   string   // Name of the remote GIT provider
+	TitlePrefix         string   // Prefix to use for merge request titles
+	RemoveSourceBranch  bool     // Whether to delete the dependy branch after successfully merging to main branch
+	RunInterval         int      // Minimum number of seconds between two runs against the same repository
+	SquashCommits       bool     // Whether to squash all commits of the dependy branch before merging
+	FilterTag           string
+	SuggestReplacements bool // Whether to suggest the replacement named in a dependency's deprecation notice. The suggestion is only written in the merge request or issue, the replacement is never applied
+	MajorUpgrades       bool // Whether to raise separate merge requests migrating dependencies to new major versions
 }
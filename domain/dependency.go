@@ -1,25 +1,31 @@
 package domain
 
-import "github.com/Masterminds/semver/v3"
-
 // Represents abstraction for ecosystem specific dependency management
 //
 // Every ecosystem manager (i.e) Go, Cargo, PyPi, NPM etc. should implement this
 // to handle the parsing and extraction of *relevant* dependencies from
-// the given file. As well as providing a mechanism for fetching the latest
-// version of a given dependency from an appropriate data source.
+// the given file. As well as providing the source of version information
+// appropriate for the ecosystem.
 type DependencyManager interface {
 	// Get the name of the manager
 	GetName() string
 
+	// Get the name of the ecosystem as used by OSV i.e. Go, npm, crates.io
+	GetEcosystem() string
+
 	// Get the name of the file this manager supports
 	GetFileName() string
 
 	// Reads a dependency file and extracts candidate dependencies
 	ParseFile(content []byte) ([]Dependency, error)
 
-	// Fetch latest non pre-release version
-	FetchLatestVersion(dep Dependency) (semver.Version, error)
+	// Get the source used to look up versions of dependencies
+	GetVersionSource() VersionSource
+
+	// Fetch the deprecation notice of a dependency. Returns nil if the
+	// dependency is not deprecated, which is always the case in ecosystems
+	// without a way of marking dependencies deprecated
+	FetchDeprecation(dep Dependency) (*Deprecation, error)
 
 	// Replace existing dependencies in file with the one given
 	ApplyDependency(dependency Dependency) error
@@ -29,6 +35,108 @@ type DependencyManager interface {
 }
 
 type Dependency struct {
-	Name    string
-	Version semver.Version
+	Name       string
+	Version    Version
+	Constraint Constraint // Optional bound candidate versions must satisfy. nil allows any version
+	Advisories []Advisory // Known vulnerabilities fixed by moving to Version
+	Blocked    string     // Reason a proposed update was withheld. Blocked updates are reported but never applied
+	Replaces   string     // Name of the dependency this update replaces, when it moves to a new name such as a new major version module path
+	Indirect   bool       // Only required through other dependencies
+}
+
+// Implemented by managers whose dependency files can not be recognised by
+// their name alone, i.e. `*.Dockerfile` or workflows under `.github/`
+type FileMatcher interface {
+	// Whether the file at path, relative to the repository root, is one of
+	// the manager's dependency files
+	MatchFile(path string) bool
+}
+
+// Implemented by managers of ecosystems which publish new major versions
+// under a different name, i.e. Go modules (`example.com/foo/v2`)
+//
+// Moving to a new name requires every reference in the source code to be
+// rewritten, so such upgrades are raised separately from routine updates.
+type MajorUpgrader interface {
+	// Find the newest major version of a dependency. Returns nil if there is
+	// none. The returned dependency Replaces the given one
+	FetchMajorUpgrade(dep Dependency) (*Dependency, error)
+
+	// Whether the file may contain references to dependencies
+	IsSourceFile(name string) bool
+
+	// Rewrite references to replaced dependencies in a source file. Returns
+	// nil if the file does not need changing
+	RewriteSource(name string, content []byte, updates []Dependency) ([]byte, error)
+}
+
+// Implemented by managers whose dependency file also pins the version of the
+// language or toolchain, i.e. the `go` and `toolchain` directives of go.mod
+//
+// Toolchain updates affect every developer and CI job of a repository, so
+// they are raised separately from dependency updates.
+type ToolchainUpdater interface {
+	// Find the toolchain versions the file should move to. Each update is
+	// named after the directive it changes
+	FetchToolchainUpdates() ([]Dependency, error)
+
+	ApplyToolchain(update Dependency) error
+}
+
+// Implemented by managers of ecosystems where dependency files in the same
+// repository can depend on each other, i.e. Go modules of a monorepo tied
+// together by replace directives or a go.work file
+type WorkspaceMember interface {
+	// Get the name other dependency files refer to the parsed file by.
+	// Empty if it has none
+	GetManifestName() string
+
+	// Declare where the parsed file lives and the path of every dependency
+	// file of the same ecosystem in the repository, by name. Files are read
+	// through open
+	SetWorkspace(path string, members map[string]string, open func(path string) ([]byte, error))
+
+	// Whether a dependency is resolved from the repository rather than a
+	// registry, so it is never updated. Called after SetWorkspace
+	IsLocal(name string) bool
+}
+
+// Implemented by managers whose dependency file is accompanied by files
+// which must be kept consistent with it, i.e. go.sum or lock files
+type CompanionUpdater interface {
+	// Get the names of the companion files, relative to the dependency file
+	GetCompanionFiles() []string
+
+	// Bring the companion files in line with the applied updates. current
+	// holds the existing content of each companion file, nil if missing.
+	// Called before GetFile, which reflects any further changes needed for
+	// consistency. Returns the files which changed
+	UpdateCompanions(current map[string][]byte) (map[string][]byte, error)
+}
+
+// Implemented by managers which can tell ahead of time that some updates can
+// not be applied as they are, i.e. lock files which can only take an update
+// by resolving packages anew
+//
+// Such updates are reported as blocked rather than applied to the dependency
+// file alone.
+type UpdateChecker interface {
+	// Set Blocked on every update which can not be applied. companions holds
+	// the content of each companion file as passed to UpdateCompanions, nil
+	// for managers without any
+	CheckUpdates(updates []Dependency, companions map[string][]byte) ([]Dependency, error)
+}
+
+// Deprecation notice published by the maintainers of a dependency
+type Deprecation struct {
+	Message     string
+	Replacement string // Name of the dependency suggested as a replacement, if one could be identified
+}
+
+// A known vulnerability affecting a dependency
+type Advisory struct {
+	ID       string   // Identifier in the originating database i.e. GO-2024-2687
+	Aliases  []string // Identifiers of the same advisory in other databases i.e. CVE or GHSA IDs
+	Summary  string
+	Severity string
 }
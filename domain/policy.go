@@ -15,3 +15,22 @@ type Policy interface {
 	// changed.
 	GetNextDependencies(current []Dependency, manager DependencyManager) ([]Dependency, error)
 }
+
+// Policy proposing updates that fix known vulnerabilities
+//
+// Security updates are raised separately from routine updates so they can be
+// allowed past an already open dependy merge request and outside schedule
+// windows.
+type SecurityPolicy interface {
+	Policy
+
+	// Whether updates should be raised while a dependy merge request is open
+	BypassLimits() bool
+
+	// Whether updates should be raised outside the schedule windows of the
+	// repository
+	OverrideSchedule() bool
+
+	// Whether security updates are to be raised for the repository
+	AppliesTo(repo Repository) bool
+}
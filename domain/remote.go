@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // Handler for interacting with a remote GIT provider
 //
 // Responsible for interacting with relevant interfaces exposed by a GIT
@@ -15,10 +17,35 @@ type RemoteHandler interface {
 	GetRepositories() ([]Repository, error)
 
 	// Create equivalent of a merge request in remote to merge dependy branch with main branch
-	CreateMergeRequest(repo Repository, sourceBranch string, targetBranch string) error
+	CreateMergeRequest(repo Repository, mr MergeRequest) error
 
 	// Check if there is already an active merge request in the repository
-	CheckMRExists(repo Repository) (bool, error)
+	// matching the search term
+	CheckMRExists(repo Repository, search string) (bool, error)
+}
+
+// Merge request to be raised by dependy
+type MergeRequest struct {
+	SourceBranch string
+	TargetBranch string
+	Title        string
+	Description  string   // Markdown body describing the proposed changes
+	Labels       []string // Labels to attach, if supported by the remote
+}
+
+// Implemented by remotes which can raise issues, used to report proposed
+// updates which need attention but did not make it into a merge request
+type IssueReporter interface {
+	// Open an issue in the repository unless one with the same title is
+	// already open
+	ReportIssue(repo Repository, issue Issue) error
+}
+
+// Issue to be raised by dependy
+type Issue struct {
+	Title       string
+	Description string   // Markdown body
+	Labels      []string // Labels to attach, if supported by the remote
 }
 
 // A Git Repository provided by a remote provider
@@ -28,3 +55,17 @@ type Repository struct {
 	URL    string // **HTTPS** remote URL for Repository
 	Branch string // Name of the designated main branch
 }
+
+// Implemented by remotes which can list the tags of the repositories they
+// host, so dependencies referring to them by tag can be updated
+type TagLister interface {
+	// List the tags of a repository given by its path, i.e. geektype/dependy
+	ListTags(project string) ([]Tag, error)
+}
+
+// A tag of a Git repository
+type Tag struct {
+	Name   string
+	Commit string    // Full SHA of the commit the tag points to
+	Date   time.Time // Date of the tagged commit. Zero if unknown
+}
@@ -0,0 +1,342 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Versioning scheme of an ecosystem, i.e. semantic versioning or PEP 440
+//
+// Schemes only determine how versions are ordered. The text of a version is
+// always kept as it was written or published, so it round-trips exactly.
+type VersionScheme interface {
+	// Get the name of the scheme, used when versions are persisted
+	GetName() string
+
+	// Parse a version into a form which can be ordered
+	Parse(raw string) (ParsedVersion, error)
+}
+
+// Scheme specific form of a version
+type ParsedVersion interface {
+	// Order the version relative to another one of the same scheme.
+	// Negative if it is lower, zero if equal and positive if higher
+	Compare(other ParsedVersion) int
+
+	// Whether the version is a pre-release
+	Prerelease() bool
+
+	// Numeric release segments i.e. [1 21 5] for 1.21.5
+	Release() []uint64
+}
+
+// A version of a dependency in the scheme of its ecosystem
+//
+// The zero value is lower than any other version.
+type Version struct {
+	raw    string
+	scheme VersionScheme
+	parsed ParsedVersion
+}
+
+func ParseVersion(scheme VersionScheme, raw string) (Version, error) {
+	parsed, err := scheme.Parse(raw)
+	if err != nil {
+		return Version{}, err
+	}
+
+	return Version{raw: raw, scheme: scheme, parsed: parsed}, nil
+}
+
+// Like ParseVersion but panics on invalid versions. Meant for constants and
+// tests
+func MustParseVersion(scheme VersionScheme, raw string) Version {
+	v, err := ParseVersion(scheme, raw)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// Get the version exactly as it was parsed
+func (v Version) String() string {
+	return v.raw
+}
+
+func (v Version) Scheme() VersionScheme {
+	return v.scheme
+}
+
+func (v Version) IsZero() bool {
+	return v.parsed == nil
+}
+
+// Order v relative to o: negative if v is lower, zero if equal and positive
+// if higher. Versions of different schemes can only be compared when each
+// is valid in the scheme of the other and both schemes order them alike, so
+// the result does not depend on which one is compared with the other.
+func (v Version) Compare(o Version) (int, error) {
+	switch {
+	case v.IsZero() && o.IsZero():
+		return 0, nil
+	case v.IsZero():
+		return -1, nil
+	case o.IsZero():
+		return 1, nil
+	}
+
+	if o.scheme == v.scheme {
+		return v.parsed.Compare(o.parsed), nil
+	}
+
+	incomparable := fmt.Errorf("%s version %s can not be compared with %s version %s", v.scheme.GetName(), v.raw, o.scheme.GetName(), o.raw)
+
+	inV, err := v.scheme.Parse(o.raw)
+	if err != nil {
+		return 0, incomparable
+	}
+
+	inO, err := o.scheme.Parse(v.raw)
+	if err != nil {
+		return 0, incomparable
+	}
+
+	c := sign(v.parsed.Compare(inV))
+	if c != sign(inO.Compare(o.parsed)) {
+		return 0, incomparable
+	}
+
+	return c, nil
+}
+
+func sign(c int) int {
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	}
+
+	return 0
+}
+
+// Versions which can not be compared are neither lower, higher nor equal
+func (v Version) LessThan(o Version) bool {
+	c, err := v.Compare(o)
+	return err == nil && c < 0
+}
+
+func (v Version) GreaterThan(o Version) bool {
+	c, err := v.Compare(o)
+	return err == nil && c > 0
+}
+
+func (v Version) Equal(o Version) bool {
+	c, err := v.Compare(o)
+	return err == nil && c == 0
+}
+
+func (v Version) Prerelease() bool {
+	return !v.IsZero() && v.parsed.Prerelease()
+}
+
+func (v Version) Major() uint64 {
+	return v.segment(0)
+}
+
+func (v Version) Minor() uint64 {
+	return v.segment(1)
+}
+
+func (v Version) Patch() uint64 {
+	return v.segment(2)
+}
+
+func (v Version) segment(i int) uint64 {
+	if v.IsZero() {
+		return 0
+	}
+
+	if release := v.parsed.Release(); i < len(release) {
+		return release[i]
+	}
+
+	return 0
+}
+
+// Get the semantic version equivalent of v, for code which only deals with
+// semantic versions. Returns false if there is none.
+func (v Version) Semver() (*semver.Version, bool) {
+	if s, ok := v.parsed.(semverVersion); ok {
+		return s.v, true
+	}
+
+	s, err := semver.NewVersion(v.raw)
+
+	return s, err == nil
+}
+
+// Versions are persisted along with the name of their scheme, which must be
+// registered with RegisterVersionScheme to be read back
+type versionJSON struct {
+	Scheme  string
+	Version string
+}
+
+func (v Version) MarshalJSON() ([]byte, error) {
+	if v.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(versionJSON{Scheme: v.scheme.GetName(), Version: v.raw})
+}
+
+func (v *Version) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = Version{}
+		return nil
+	}
+
+	var j versionJSON
+
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	schemesMu.RLock()
+	scheme, ok := schemes[j.Scheme]
+	schemesMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("unknown version scheme %s", j.Scheme)
+	}
+
+	parsed, err := ParseVersion(scheme, j.Version)
+	if err != nil {
+		return err
+	}
+
+	*v = parsed
+
+	return nil
+}
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[string]VersionScheme{Semver.GetName(): Semver}
+)
+
+// Make a scheme known by name so persisted versions can be read back
+func RegisterVersionScheme(scheme VersionScheme) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+
+	schemes[scheme.GetName()] = scheme
+}
+
+// Bound on the versions a dependency may move to
+type Constraint interface {
+	Check(v Version) bool
+	String() string
+}
+
+// Semantic versioning as used by Go modules, npm and Cargo. A leading v and
+// missing minor or patch numbers are accepted.
+var Semver VersionScheme = semverScheme{}
+
+type semverScheme struct{}
+
+func (semverScheme) GetName() string {
+	return "semver"
+}
+
+func (semverScheme) Parse(raw string) (ParsedVersion, error) {
+	v, err := semver.NewVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return semverVersion{v: v}, nil
+}
+
+type semverVersion struct {
+	v *semver.Version
+}
+
+func (s semverVersion) Compare(other ParsedVersion) int {
+	if o, ok := other.(semverVersion); ok {
+		return s.v.Compare(o.v)
+	}
+
+	return compareReleases(s, other)
+}
+
+// Order versions of different schemes by their release segments, a
+// pre-release coming before its release
+func compareReleases(a, b ParsedVersion) int {
+	ra, rb := a.Release(), b.Release()
+
+	for i := 0; i < len(ra) || i < len(rb); i++ {
+		var x, y uint64
+
+		if i < len(ra) {
+			x = ra[i]
+		}
+
+		if i < len(rb) {
+			y = rb[i]
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	switch {
+	case a.Prerelease() && !b.Prerelease():
+		return -1
+	case !a.Prerelease() && b.Prerelease():
+		return 1
+	}
+
+	return 0
+}
+
+func (s semverVersion) Prerelease() bool {
+	return s.v.Prerelease() != ""
+}
+
+func (s semverVersion) Release() []uint64 {
+	return []uint64{s.v.Major(), s.v.Minor(), s.v.Patch()}
+}
+
+// Parse a semantic versioning constraint such as `~1.2` or `>=1.0, <2.0`.
+// Versions without a semantic version equivalent never satisfy it.
+func NewSemverConstraint(expr string) (Constraint, error) {
+	c, err := semver.NewConstraint(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return semverConstraint{c: c}, nil
+}
+
+type semverConstraint struct {
+	c *semver.Constraints
+}
+
+func (s semverConstraint) Check(v Version) bool {
+	sv, ok := v.Semver()
+	return ok && s.c.Check(sv)
+}
+
+func (s semverConstraint) String() string {
+	return s.c.String()
+}
@@ -0,0 +1,206 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Scheme ordering versions by their only number, i.e. build numbers
+type buildScheme struct{}
+
+func (buildScheme) GetName() string {
+	return "build"
+}
+
+func (buildScheme) Parse(raw string) (domain.ParsedVersion, error) {
+	n, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildNumber(n), nil
+}
+
+type buildNumber uint64
+
+func (b buildNumber) Compare(other domain.ParsedVersion) int {
+	o := other.(buildNumber)
+
+	switch {
+	case b < o:
+		return -1
+	case b > o:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (buildNumber) Prerelease() bool {
+	return false
+}
+
+func (b buildNumber) Release() []uint64 {
+	return []uint64{uint64(b)}
+}
+
+func TestSemverParse(t *testing.T) {
+	tests := []struct {
+		raw        string
+		valid      bool
+		prerelease bool
+		release    [3]uint64
+	}{
+		{"1.2.3", true, false, [3]uint64{1, 2, 3}},
+		{"v1.2.3", true, false, [3]uint64{1, 2, 3}},
+		{"1.2", true, false, [3]uint64{1, 2, 0}},
+		{"v2", true, false, [3]uint64{2, 0, 0}},
+		{"1.0.0-rc.1", true, true, [3]uint64{1, 0, 0}},
+		{"1.0.0+build.5", true, false, [3]uint64{1, 0, 0}},
+		{"v0.0.0-20240101000000-123456789abc", true, true, [3]uint64{0, 0, 0}},
+		{"latest", false, false, [3]uint64{}},
+		{"", false, false, [3]uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			v, err := domain.ParseVersion(domain.Semver, tt.raw)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected %q to be rejected", tt.raw)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.String() != tt.raw {
+				t.Errorf("expected %q to be kept as written, got %q", tt.raw, v.String())
+			}
+
+			if v.Prerelease() != tt.prerelease {
+				t.Errorf("expected prerelease %v, got %v", tt.prerelease, v.Prerelease())
+			}
+
+			if got := [3]uint64{v.Major(), v.Minor(), v.Patch()}; got != tt.release {
+				t.Errorf("expected release %v, got %v", tt.release, got)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b domain.Version
+		want int
+	}{
+		{"lower", domain.MustParseVersion(domain.Semver, "1.2.3"), domain.MustParseVersion(domain.Semver, "1.10.0"), -1},
+		{"equal with v prefix", domain.MustParseVersion(domain.Semver, "v1.2.0"), domain.MustParseVersion(domain.Semver, "1.2"), 0},
+		{"pre-release first", domain.MustParseVersion(domain.Semver, "1.0.0-rc.1"), domain.MustParseVersion(domain.Semver, "1.0.0"), -1},
+		{"build metadata ignored", domain.MustParseVersion(domain.Semver, "1.0.0+a"), domain.MustParseVersion(domain.Semver, "1.0.0+b"), 0},
+		{"zero value lowest", domain.Version{}, domain.MustParseVersion(domain.Semver, "0.0.0"), -1},
+		{"zero values equal", domain.Version{}, domain.Version{}, 0},
+		{"schemes valid in each other", domain.MustParseVersion(buildScheme{}, "12"), domain.MustParseVersion(domain.Semver, "13"), -1},
+		{"custom scheme", domain.MustParseVersion(buildScheme{}, "9"), domain.MustParseVersion(buildScheme{}, "10"), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Compare(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+
+			if back, _ := tt.b.Compare(tt.a); back != -tt.want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, back, -tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionCompareAcrossSchemes(t *testing.T) {
+	build := domain.MustParseVersion(buildScheme{}, "12")
+	semver := domain.MustParseVersion(domain.Semver, "1.2.3")
+
+	if _, err := build.Compare(semver); err == nil {
+		t.Error("expected 1.2.3 not to be comparable with a build number")
+	}
+
+	// 12 is a valid semantic version, but the comparison must not depend on
+	// which version is compared
+	if _, err := semver.Compare(build); err == nil {
+		t.Error("expected a build number not to be comparable with 1.2.3")
+	}
+
+	if build.LessThan(semver) || build.GreaterThan(semver) || build.Equal(semver) {
+		t.Error("expected incomparable versions to be neither lower, higher nor equal")
+	}
+}
+
+func TestSemverConstraint(t *testing.T) {
+	tests := []struct {
+		expr    string
+		version domain.Version
+		want    bool
+	}{
+		{"~1.2", domain.MustParseVersion(domain.Semver, "1.2.9"), true},
+		{"~1.2", domain.MustParseVersion(domain.Semver, "1.3.0"), false},
+		{">=1.0, <2.0", domain.MustParseVersion(domain.Semver, "v1.5.0"), true},
+		{"<=v1.8", domain.MustParseVersion(domain.Semver, "1.9.0"), false},
+		{"^0.3", domain.MustParseVersion(domain.Semver, "0.3.4"), true},
+		{"^0.3", domain.MustParseVersion(domain.Semver, "0.4.0"), false},
+		{">=1", domain.MustParseVersion(buildScheme{}, "5"), true},
+		{">=1", domain.Version{}, false},
+	}
+
+	for _, tt := range tests {
+		c, err := domain.NewSemverConstraint(tt.expr)
+		if err != nil {
+			t.Fatalf("NewSemverConstraint(%s): %v", tt.expr, err)
+		}
+
+		if got := c.Check(tt.version); got != tt.want {
+			t.Errorf("%s satisfies %s: got %v, want %v", tt.version, tt.expr, got, tt.want)
+		}
+	}
+
+	if _, err := domain.NewSemverConstraint("not a constraint"); err == nil {
+		t.Error("expected invalid constraint to be rejected")
+	}
+}
+
+func TestVersionJSON(t *testing.T) {
+	domain.RegisterVersionScheme(buildScheme{})
+
+	for _, v := range []domain.Version{
+		domain.MustParseVersion(domain.Semver, "v1.2"),
+		domain.MustParseVersion(buildScheme{}, "42"),
+		{},
+	} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var back domain.Version
+
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+
+		if back.String() != v.String() || back.IsZero() != v.IsZero() || (!v.IsZero() && back.Scheme().GetName() != v.Scheme().GetName()) {
+			t.Errorf("expected %q to round-trip, got %q", v, back)
+		}
+	}
+}
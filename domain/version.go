@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// Source of version information for the dependencies of an ecosystem
+//
+// Decouples version lookup from the parsing and editing of dependency files
+// so registries (i.e. deps.dev, a Go module proxy or the npm registry) can be
+// swapped per ecosystem and mocked in tests.
+type VersionSource interface {
+	// Get the name of the source
+	GetName() string
+
+	// Fetch every published version of a dependency. Implementations may
+	// leave out metadata which is expensive to obtain, such as licenses.
+	FetchVersions(dep Dependency) (Versions, error)
+
+	// Fetch all available metadata about a single version of a dependency
+	FetchVersion(dep Dependency, version Version) (VersionInfo, error)
+}
+
+// A published version of a dependency along with its metadata
+type VersionInfo struct {
+	Version     Version
+	PublishedAt time.Time // Zero if unknown
+	Prerelease  bool
+	Retracted   bool     // Withdrawn by the maintainers and should never be proposed
+	Excluded    string   // Reason the version must not be proposed for the dependency, if any
+	Licenses    []string // SPDX identifiers. nil if unknown
+}
+
+type Versions []VersionInfo
+
+// Get the highest release version which has been neither retracted nor
+// excluded and, if given, satisfies the constraint. Returns false if there is
+// none.
+func (v Versions) Latest(constraint Constraint) (VersionInfo, bool) {
+	var (
+		latest VersionInfo
+		found  bool
+	)
+
+	for _, info := range v {
+		if info.Prerelease || info.Retracted || info.Excluded != "" {
+			continue
+		}
+
+		if constraint != nil && !constraint.Check(info.Version) {
+			continue
+		}
+
+		if !found || info.Version.GreaterThan(latest.Version) {
+			latest = info
+			found = true
+		}
+	}
+
+	return latest, found
+}
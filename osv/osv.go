@@ -0,0 +1,324 @@
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Vulnerability entry in the OSV schema
+//
+// Only the subset of https://ossf.github.io/osv-schema/ required to decide
+// whether a version is affected and which version fixes it is modelled.
+type Vulnerability struct {
+	ID               string     `json:"id"`
+	Aliases          []string   `json:"aliases"`
+	Summary          string     `json:"summary"`
+	Withdrawn        string     `json:"withdrawn"`
+	Severity         []Severity `json:"severity"`
+	Affected         []Affected `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type Affected struct {
+	Package  Package  `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// A single range event. Exactly one of the fields is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// Human friendly severity of the vulnerability
+//
+// Prefers the qualitative rating supplied by the originating database
+// (i.e. GHSA) and falls back to the first scored severity.
+func (v Vulnerability) GetSeverity() string {
+	if v.DatabaseSpecific.Severity != "" {
+		return strings.ToUpper(v.DatabaseSpecific.Severity)
+	}
+
+	if len(v.Severity) > 0 {
+		return v.Severity[0].Type + " " + v.Severity[0].Score
+	}
+
+	return "UNKNOWN"
+}
+
+// In-memory index of OSV advisories keyed by ecosystem and package name
+type Database struct {
+	vulns map[Package][]Vulnerability
+}
+
+// Load an OSV database from disk
+//
+// The path can either point at a zip archive as published by osv.dev
+// (i.e. `Go/all.zip`) or at a mirror directory, in which case every `.json`
+// file beneath it is read.
+func Load(path string) (*Database, error) {
+	db := &Database{vulns: make(map[Package][]Vulnerability)}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		err = db.loadDir(path)
+	} else {
+		err = db.loadZip(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *Database) loadDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return db.add(path, f)
+	})
+}
+
+func (db *Database) loadZip(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, zf := range r.File {
+		if zf.FileInfo().IsDir() || filepath.Ext(zf.Name) != ".json" {
+			continue
+		}
+
+		f, err := zf.Open()
+		if err != nil {
+			return err
+		}
+
+		err = db.add(zf.Name, f)
+		f.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) add(name string, r io.Reader) error {
+	var v Vulnerability
+
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	if v.Withdrawn != "" {
+		return nil
+	}
+
+	seen := make(map[Package]bool)
+
+	for _, a := range v.Affected {
+		if seen[a.Package] {
+			continue
+		}
+
+		seen[a.Package] = true
+		db.vulns[a.Package] = append(db.vulns[a.Package], v)
+	}
+
+	return nil
+}
+
+// Get all vulnerabilities affecting the given version of a package
+func (db *Database) Query(ecosystem string, name string, version domain.Version) []Vulnerability {
+	pkg := Package{Ecosystem: ecosystem, Name: name}
+	affecting := make([]Vulnerability, 0)
+
+	for _, v := range db.vulns[pkg] {
+		for _, a := range v.Affected {
+			if a.Package == pkg && a.affects(version) {
+				affecting = append(affecting, v)
+				break
+			}
+		}
+	}
+
+	return affecting
+}
+
+// Get the lowest version greater than `version` which fixes the given
+// vulnerability for the package. Returns false if no fix has been published.
+func (v Vulnerability) MinimalFix(pkg Package, version domain.Version) (domain.Version, bool) {
+	var (
+		best  domain.Version
+		found bool
+	)
+
+	for _, a := range v.Affected {
+		if a.Package != pkg {
+			continue
+		}
+
+		for _, r := range a.Ranges {
+			if !r.comparable() {
+				continue
+			}
+
+			for _, e := range r.Events {
+				if e.Fixed == "" {
+					continue
+				}
+
+				fixed, err := domain.ParseVersion(r.scheme(version), e.Fixed)
+				if err != nil || !fixed.GreaterThan(version) {
+					continue
+				}
+
+				if !found || fixed.LessThan(best) {
+					best = fixed
+					found = true
+				}
+			}
+		}
+	}
+
+	return best, found
+}
+
+func (a Affected) affects(version domain.Version) bool {
+	for _, raw := range a.Versions {
+		v, err := domain.ParseVersion(schemeOf(version), raw)
+		if err == nil && v.Equal(version) {
+			return true
+		}
+	}
+
+	for _, r := range a.Ranges {
+		if r.comparable() && r.affects(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Only SEMVER and ECOSYSTEM ranges can be evaluated without access to the
+// source repository
+func (r Range) comparable() bool {
+	return r.Type == "SEMVER" || r.Type == "ECOSYSTEM"
+}
+
+// Get the scheme the range's versions are written in. ECOSYSTEM ranges use
+// the scheme of the ecosystem, which is that of the version being checked.
+func (r Range) scheme(version domain.Version) domain.VersionScheme {
+	if r.Type == "SEMVER" {
+		return domain.Semver
+	}
+
+	return schemeOf(version)
+}
+
+func schemeOf(version domain.Version) domain.VersionScheme {
+	if version.Scheme() == nil {
+		return domain.Semver
+	}
+
+	return version.Scheme()
+}
+
+func (r Range) affects(version domain.Version) bool {
+	type point struct {
+		version domain.Version
+		event   Event
+	}
+
+	points := make([]point, 0, len(r.Events))
+
+	for _, e := range r.Events {
+		raw := e.Introduced + e.Fixed + e.LastAffected
+		if e.Introduced == "0" {
+			raw = "0.0.0"
+		}
+
+		v, err := domain.ParseVersion(r.scheme(version), raw)
+		if err != nil {
+			continue
+		}
+
+		points = append(points, point{version: v, event: e})
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].version.LessThan(points[j].version)
+	})
+
+	affected := false
+
+	for _, p := range points {
+		switch {
+		case p.event.Introduced != "":
+			if !version.LessThan(p.version) {
+				affected = true
+			}
+		case p.event.Fixed != "":
+			if !version.LessThan(p.version) {
+				affected = false
+			}
+		case p.event.LastAffected != "":
+			if version.GreaterThan(p.version) {
+				affected = false
+			}
+		}
+	}
+
+	return affected
+}
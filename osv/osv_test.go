@@ -0,0 +1,74 @@
+package osv_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/osv"
+)
+
+const advisory = `{
+  "id": "GO-2024-0001",
+  "aliases": ["CVE-2024-0001"],
+  "database_specific": {"severity": "HIGH"},
+  "affected": [{
+    "package": {"ecosystem": "Go", "name": "example.com/lib"},
+    "ranges": [{
+      "type": "SEMVER",
+      "events": [
+        {"introduced": "0"}, {"fixed": "1.2.1"},
+        {"introduced": "2.0.0"}, {"fixed": "2.3.0"}
+      ]
+    }]
+  }]
+}`
+
+func TestQueryAndMinimalFix(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "GO-2024-0001.json"), []byte(advisory), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := osv.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	pkg := osv.Package{Ecosystem: "Go", Name: "example.com/lib"}
+
+	tests := []struct {
+		version  string
+		affected bool
+		fix      string
+	}{
+		{"1.0.0", true, "1.2.1"},
+		{"1.5.0", false, ""},
+		{"2.1.0", true, "2.3.0"},
+		{"2.3.0", false, ""},
+	}
+
+	for _, tt := range tests {
+		v := domain.MustParseVersion(domain.Semver, tt.version)
+
+		vulns := db.Query(pkg.Ecosystem, pkg.Name, v)
+		if (len(vulns) > 0) != tt.affected {
+			t.Errorf("%s: expected affected=%t, got %d vulnerabilities", tt.version, tt.affected, len(vulns))
+			continue
+		}
+
+		if !tt.affected {
+			continue
+		}
+
+		if vulns[0].GetSeverity() != "HIGH" {
+			t.Errorf("expected HIGH severity, got %s", vulns[0].GetSeverity())
+		}
+
+		fix, ok := vulns[0].MinimalFix(pkg, v)
+		if !ok || fix.String() != tt.fix {
+			t.Errorf("%s: expected fix %s, got %s", tt.version, tt.fix, fix.String())
+		}
+	}
+}
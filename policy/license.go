@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+type LicenseConfig struct {
+	Allow []string // SPDX identifiers permitted. Empty allows any license not denied
+	Deny  []string // SPDX identifiers which are never permitted
+}
+
+func NewLicenseGate(config LicenseConfig) *LicenseGate {
+	return &LicenseGate{
+		allow: toSet(config.Allow),
+		deny:  toSet(config.Deny),
+	}
+}
+
+// License compliance gate for proposed updates
+//
+// Checks the license of every proposed version against the configured
+// allow and deny lists. Licenses are SPDX expressions: a choice such as
+// `MIT OR Apache-2.0` is permitted when any of its licenses is, while every
+// license of `MIT AND BSD-3-Clause` has to be. An exception, as in
+// `GPL-2.0 WITH Classpath-exception-2.0`, is matched with its license first
+// and falls back to the license alone. Updates where the license differs
+// from the one of the current version are always blocked, as a relicense
+// (i.e. MIT to BSL) warrants a human decision rather than a routine bump.
+// A current version without license information can not be compared, so
+// it is not taken as a change.
+type LicenseGate struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// Mark updates which fail the license checks as blocked
+//
+// Failing to look up the license of a proposed version also blocks the
+// update, since compliance can not be established.
+func (l *LicenseGate) Check(
+	current []domain.Dependency,
+	updated []domain.Dependency,
+	manager domain.DependencyManager,
+) []domain.Dependency {
+	currentByName := make(map[string]domain.Dependency, len(current))
+	for _, d := range current {
+		currentByName[d.Name] = d
+	}
+
+	checked := make([]domain.Dependency, len(updated))
+
+	for i, dep := range updated {
+		checked[i] = dep
+
+		if dep.Blocked != "" {
+			continue
+		}
+
+		name := dep.Name
+		if dep.Replaces != "" {
+			name = dep.Replaces
+		}
+
+		reason, err := l.check(currentByName[name], dep, manager)
+		if err != nil {
+			slog.Warn("Could not determine license", slog.String("dependency", dep.Name), slog.Any("error", err))
+			reason = "license could not be determined"
+		}
+
+		checked[i].Blocked = reason
+	}
+
+	return checked
+}
+
+func (l *LicenseGate) check(
+	current domain.Dependency,
+	next domain.Dependency,
+	manager domain.DependencyManager,
+) (string, error) {
+	versions := manager.GetVersionSource()
+
+	nextInfo, err := versions.FetchVersion(next, next.Version)
+	if err != nil {
+		return "", err
+	}
+
+	nextLicenses := nextInfo.Licenses
+
+	if len(nextLicenses) == 0 && len(l.allow) > 0 {
+		return "no license information available", nil
+	}
+
+	for _, lic := range nextLicenses {
+		if reason := l.evaluate(toLicenseExpr(lic)); reason != "" {
+			return reason, nil
+		}
+	}
+
+	if current.Name == "" {
+		return "", nil
+	}
+
+	currentInfo, err := versions.FetchVersion(current, current.Version)
+	if err != nil {
+		return "", err
+	}
+
+	currentLicenses := currentInfo.Licenses
+
+	if len(currentLicenses) == 0 {
+		slog.Debug("License change not determinable, the current version has no license information", slog.String("dependency", current.Name))
+		return "", nil
+	}
+
+	if licenseKey(currentLicenses) != licenseKey(nextLicenses) {
+		return fmt.Sprintf(
+			"license changed from %s to %s",
+			strings.Join(currentLicenses, ", "),
+			strings.Join(nextLicenses, ", "),
+		), nil
+	}
+
+	return "", nil
+}
+
+// Get the reason the expression is not permitted, empty if it is
+func (l *LicenseGate) evaluate(e licenseExpr) string {
+	switch e.Op {
+	case "AND":
+		for _, o := range e.Operands {
+			if reason := l.evaluate(o); reason != "" {
+				return reason
+			}
+		}
+
+		return ""
+	case "OR":
+		for _, o := range e.Operands {
+			if l.evaluate(o) == "" {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("none of the licenses of %s is allowed", e)
+	}
+
+	full, base := e.key(), normalise(e.License)
+
+	if l.deny[full] || (l.deny[base] && !l.allow[full]) {
+		return fmt.Sprintf("license %s is denied", e)
+	}
+
+	if len(l.allow) > 0 && !l.allow[full] && !l.allow[base] {
+		return fmt.Sprintf("license %s is not allowed", e)
+	}
+
+	return ""
+}
+
+// Parse a license as reported by the version source. Licenses which are not
+// valid SPDX expressions are taken as a single opaque identifier.
+func toLicenseExpr(license string) licenseExpr {
+	e, err := parseLicenseExpr(license)
+	if err != nil {
+		slog.Debug("License is not an SPDX expression", slog.String("license", license), slog.Any("error", err))
+		return licenseExpr{License: strings.TrimSpace(license)}
+	}
+
+	return e
+}
+
+func normalise(license string) string {
+	return strings.ToLower(strings.TrimSpace(license))
+}
+
+func toSet(licenses []string) map[string]bool {
+	set := make(map[string]bool, len(licenses))
+	for _, l := range licenses {
+		set[toLicenseExpr(l).key()] = true
+	}
+
+	return set
+}
+
+func licenseKey(licenses []string) string {
+	n := make([]string, len(licenses))
+	for i, l := range licenses {
+		n[i] = toLicenseExpr(l).key()
+	}
+
+	sort.Strings(n)
+
+	return strings.Join(n, ",")
+}
@@ -0,0 +1,142 @@
+package policy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/policy"
+)
+
+// Version source serving licenses by version of a single dependency
+type licenseSource map[string][]string
+
+func (licenseSource) GetName() string {
+	return "LicenseSource"
+}
+
+func (licenseSource) FetchVersions(domain.Dependency) (domain.Versions, error) {
+	return nil, nil
+}
+
+func (s licenseSource) FetchVersion(_ domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	licenses, ok := s[version.String()]
+	if !ok {
+		return domain.VersionInfo{}, errors.New("version not found")
+	}
+
+	return domain.VersionInfo{Version: version, Licenses: licenses}, nil
+}
+
+// Manager exposing nothing but its version source
+type licenseManager struct {
+	domain.DependencyManager
+	source licenseSource
+}
+
+func (m licenseManager) GetVersionSource() domain.VersionSource {
+	return m.source
+}
+
+func TestLicenseGate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  policy.LicenseConfig
+		current string // License of v1.0.0
+		next    string // License of v2.0.0
+		blocked string // Expected part of the reason, empty if not blocked
+	}{
+		{"allowed", policy.LicenseConfig{Allow: []string{"MIT"}}, "MIT", "MIT", ""},
+		{"allowed in any case", policy.LicenseConfig{Allow: []string{"mit"}}, "MIT", "MIT", ""},
+		{"not allowed", policy.LicenseConfig{Allow: []string{"MIT"}}, "GPL-3.0", "GPL-3.0", "license GPL-3.0 is not allowed"},
+		{"denied", policy.LicenseConfig{Deny: []string{"GPL-3.0"}}, "GPL-3.0", "GPL-3.0", "license GPL-3.0 is denied"},
+		{"not denied", policy.LicenseConfig{Deny: []string{"GPL-3.0"}}, "MIT", "MIT", ""},
+		{"license change", policy.LicenseConfig{}, "MIT", "BSL-1.1", "license changed from MIT to BSL-1.1"},
+		{"denied before license change", policy.LicenseConfig{Deny: []string{"BSL-1.1"}}, "MIT", "BSL-1.1", "denied"},
+		{"choice with an allowed license", policy.LicenseConfig{Allow: []string{"MIT"}}, "MIT OR Apache-2.0", "MIT OR Apache-2.0", ""},
+		{"choice with a denied license", policy.LicenseConfig{Deny: []string{"GPL-3.0"}}, "MIT OR GPL-3.0", "MIT OR GPL-3.0", ""},
+		{"choice without allowed license", policy.LicenseConfig{Allow: []string{"MIT"}}, "GPL-3.0 OR BSL-1.1", "GPL-3.0 OR BSL-1.1", "none of the licenses of GPL-3.0 OR BSL-1.1 is allowed"},
+		{"conjunction with a denied license", policy.LicenseConfig{Deny: []string{"GPL-3.0"}}, "MIT AND GPL-3.0", "MIT AND GPL-3.0", "license GPL-3.0 is denied"},
+		{"nested expression", policy.LicenseConfig{Allow: []string{"MIT", "BSD-3-Clause"}}, "BSD-3-Clause AND (MIT OR GPL-3.0)", "bsd-3-clause and (GPL-3.0 or MIT)", ""},
+		{"reordered choice", policy.LicenseConfig{}, "MIT OR Apache-2.0", "Apache-2.0 OR MIT", ""},
+		{"narrowed choice", policy.LicenseConfig{}, "MIT OR Apache-2.0", "Apache-2.0", "license changed"},
+		{"exception of a denied license", policy.LicenseConfig{Deny: []string{"GPL-2.0"}}, "GPL-2.0 WITH Classpath-exception-2.0", "GPL-2.0 WITH Classpath-exception-2.0", "license GPL-2.0 WITH Classpath-exception-2.0 is denied"},
+		{"allowed exception of a denied license", policy.LicenseConfig{Allow: []string{"GPL-2.0 WITH Classpath-exception-2.0"}, Deny: []string{"GPL-2.0"}}, "GPL-2.0 WITH Classpath-exception-2.0", "GPL-2.0 WITH Classpath-exception-2.0", ""},
+		{"malformed expression", policy.LicenseConfig{Allow: []string{"MIT"}}, "MIT OR", "MIT OR", "license MIT OR is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := licenseManager{source: licenseSource{
+				"v1.0.0": {tt.current},
+				"v2.0.0": {tt.next},
+			}}
+
+			current := []domain.Dependency{{Name: "example.com/lib", Version: domain.MustParseVersion(domain.Semver, "v1.0.0")}}
+			updated := []domain.Dependency{{Name: "example.com/lib", Version: domain.MustParseVersion(domain.Semver, "v2.0.0")}}
+
+			checked := policy.NewLicenseGate(tt.config).Check(current, updated, manager)
+
+			if len(checked) != 1 {
+				t.Fatalf("expected 1 update, got %d", len(checked))
+			}
+
+			reason := checked[0].Blocked
+
+			switch {
+			case tt.blocked == "" && reason != "":
+				t.Errorf("expected update to pass, got blocked: %s", reason)
+			case tt.blocked != "" && !strings.Contains(reason, tt.blocked):
+				t.Errorf("expected blocked with %q, got %q", tt.blocked, reason)
+			}
+		})
+	}
+}
+
+func TestLicenseGateUnknownLicense(t *testing.T) {
+	manager := licenseManager{source: licenseSource{}}
+
+	updated := []domain.Dependency{{Name: "example.com/lib", Version: domain.MustParseVersion(domain.Semver, "v2.0.0")}}
+
+	checked := policy.NewLicenseGate(policy.LicenseConfig{}).Check(nil, updated, manager)
+	if checked[0].Blocked != "license could not be determined" {
+		t.Errorf("expected update without license to be blocked, got %q", checked[0].Blocked)
+	}
+
+	// Updates already blocked keep their reason
+	updated[0].Blocked = "held back"
+
+	checked = policy.NewLicenseGate(policy.LicenseConfig{}).Check(nil, updated, manager)
+	if checked[0].Blocked != "held back" {
+		t.Errorf("expected existing reason to be kept, got %q", checked[0].Blocked)
+	}
+}
+
+func TestLicenseGateCurrentVersion(t *testing.T) {
+	manager := licenseManager{source: licenseSource{
+		"v1.0.0": {"MIT"},
+		"v1.1.0": nil,
+		"v2.0.0": {"BSL-1.1"},
+	}}
+
+	gate := policy.NewLicenseGate(policy.LicenseConfig{})
+
+	// Major version migrations are compared with the module they replace
+	current := []domain.Dependency{{Name: "example.com/lib", Version: domain.MustParseVersion(domain.Semver, "v1.0.0")}}
+	updated := []domain.Dependency{{Name: "example.com/lib/v2", Version: domain.MustParseVersion(domain.Semver, "v2.0.0"), Replaces: "example.com/lib"}}
+
+	checked := gate.Check(current, updated, manager)
+	if !strings.Contains(checked[0].Blocked, "license changed from MIT to BSL-1.1") {
+		t.Errorf("expected license change of the replaced module to block, got %q", checked[0].Blocked)
+	}
+
+	// Without a license for the current version, a change can not be told
+	current[0].Version = domain.MustParseVersion(domain.Semver, "v1.1.0")
+	updated = []domain.Dependency{{Name: "example.com/lib", Version: domain.MustParseVersion(domain.Semver, "v2.0.0")}}
+
+	checked = gate.Check(current, updated, manager)
+	if checked[0].Blocked != "" {
+		t.Errorf("expected update from a version without license to pass, got blocked: %s", checked[0].Blocked)
+	}
+}
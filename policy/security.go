@@ -0,0 +1,219 @@
+package policy
+
+import (
+	"log/slog"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/osv"
+)
+
+type SecurityConfig struct {
+	Database         string   // Path to an OSV database zip dump or mirror directory
+	BypassLimits     bool     // Raise security updates even when a routine dependy merge request is open
+	OverrideSchedule bool     // Raise security updates outside the schedule windows of the repository
+	Repositories     []string // Names of the repositories security updates are raised for. Every repository when empty
+	Ecosystems       []string // Ecosystems security updates are raised for, i.e. Go or npm. Every ecosystem when empty
+}
+
+func NewSecurityUpdatePolicy(config SecurityConfig) (*SecurityUpdatePolicy, error) {
+	db, err := osv.Load(config.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecurityUpdatePolicy{
+		Database:     db,
+		Limits:       config.BypassLimits,
+		Schedule:     config.OverrideSchedule,
+		Repositories: nameSet(config.Repositories),
+		Ecosystems:   nameSet(config.Ecosystems),
+	}, nil
+}
+
+func nameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+
+	for _, n := range names {
+		set[n] = true
+	}
+
+	return set
+}
+
+// Security Update Policy
+//
+// Only proposes updates for dependencies affected by known vulnerabilities.
+// The dependency is moved to the lowest version which is not affected by any
+// advisory in the OSV database, rather than the latest version, to keep the
+// change as small as possible.
+//
+// The policy can be scoped to some repositories and ecosystems, in which case
+// dependencies of other ecosystems are left alone.
+type SecurityUpdatePolicy struct {
+	Database     *osv.Database
+	Limits       bool            // Whether updates bypass the open merge request limit
+	Schedule     bool            // Whether updates override schedule windows
+	Repositories map[string]bool // Repositories the policy applies to. Every repository when empty
+	Ecosystems   map[string]bool // Ecosystems the policy applies to. Every ecosystem when empty
+}
+
+// Upper bound on the number of fixed versions tried for a single dependency
+const maxFixAttempts = 10
+
+func (*SecurityUpdatePolicy) GetName() string {
+	return "SecurityPolicy"
+}
+
+func (s *SecurityUpdatePolicy) BypassLimits() bool {
+	return s.Limits
+}
+
+func (s *SecurityUpdatePolicy) OverrideSchedule() bool {
+	return s.Schedule
+}
+
+func (s *SecurityUpdatePolicy) AppliesTo(repo domain.Repository) bool {
+	return len(s.Repositories) == 0 || s.Repositories[repo.Name]
+}
+
+func (s *SecurityUpdatePolicy) GetNextDependencies(
+	current []domain.Dependency,
+	manager domain.DependencyManager,
+) ([]domain.Dependency, error) {
+	newDeps := make([]domain.Dependency, 0)
+	ecosystem := manager.GetEcosystem()
+
+	if len(s.Ecosystems) > 0 && !s.Ecosystems[ecosystem] {
+		return newDeps, nil
+	}
+
+	for _, dep := range current {
+		pkg := osv.Package{Ecosystem: ecosystem, Name: dep.Name}
+
+		vulns := s.Database.Query(ecosystem, dep.Name, dep.Version)
+		if len(vulns) == 0 {
+			continue
+		}
+
+		// Versions which must never be proposed are only known to the source
+		// of the dependency. Without them fixes are taken as they are.
+		versions, err := manager.GetVersionSource().FetchVersions(dep)
+		if err != nil {
+			slog.Warn("Could not fetch versions", slog.String("dependency", dep.Name), slog.Any("error", err))
+		}
+
+		// A fixed version may itself be affected by a different advisory, so
+		// keep moving forward until a clean version is found.
+		candidate := dep.Version
+		remaining := vulns
+		fixed := false
+
+		for i := 0; i < maxFixAttempts && len(remaining) > 0; i++ {
+			next, ok := highestMinimalFix(remaining, pkg, candidate)
+			if !ok {
+				break
+			}
+
+			next, ok = nextUsable(versions, next)
+			if !ok {
+				break
+			}
+
+			candidate = next
+			remaining = s.Database.Query(ecosystem, dep.Name, candidate)
+			fixed = len(remaining) == 0
+		}
+
+		if !fixed {
+			slog.Warn("No fixed version available for vulnerable dependency", slog.String("dependency", dep.Name))
+			continue
+		}
+
+		if dep.Constraint != nil && !dep.Constraint.Check(candidate) {
+			slog.Warn(
+				"Fixed version is outside pinned constraint",
+				slog.String("dependency", dep.Name),
+				slog.String("version", candidate.String()),
+			)
+
+			continue
+		}
+
+		newDeps = append(newDeps, domain.Dependency{
+			Name:       dep.Name,
+			Version:    candidate,
+			Advisories: toAdvisories(vulns),
+		})
+	}
+
+	return newDeps, nil
+}
+
+func highestMinimalFix(vulns []osv.Vulnerability, pkg osv.Package, current domain.Version) (domain.Version, bool) {
+	var (
+		highest domain.Version
+		found   bool
+	)
+
+	for _, v := range vulns {
+		fix, ok := v.MinimalFix(pkg, current)
+		if !ok {
+			return highest, false
+		}
+
+		if !found || fix.GreaterThan(highest) {
+			highest = fix
+			found = true
+		}
+	}
+
+	return highest, found
+}
+
+// Get the lowest version from v onwards which has been neither retracted nor
+// excluded. v is returned as is if it is not among the known versions.
+func nextUsable(versions domain.Versions, v domain.Version) (domain.Version, bool) {
+	var (
+		next  domain.Version
+		found bool
+		known bool
+	)
+
+	for _, info := range versions {
+		if info.Version.Equal(v) {
+			known = true
+		}
+
+		// A pre-release is only acceptable when it is the fix itself
+		if info.Version.LessThan(v) || info.Retracted || info.Excluded != "" ||
+			(info.Prerelease && !info.Version.Equal(v)) {
+			continue
+		}
+
+		if !found || info.Version.LessThan(next) {
+			next = info.Version
+			found = true
+		}
+	}
+
+	if !known {
+		return v, true
+	}
+
+	return next, found
+}
+
+func toAdvisories(vulns []osv.Vulnerability) []domain.Advisory {
+	advisories := make([]domain.Advisory, len(vulns))
+
+	for i, v := range vulns {
+		advisories[i] = domain.Advisory{
+			ID:       v.ID,
+			Aliases:  v.Aliases,
+			Summary:  v.Summary,
+			Severity: v.GetSeverity(),
+		}
+	}
+
+	return advisories
+}
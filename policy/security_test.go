@@ -0,0 +1,128 @@
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/policy"
+)
+
+// Advisories of example.com/lib, where the fix of the first is affected by
+// the second
+var securityAdvisories = map[string]string{
+	"GO-2024-0001": `{
+  "id": "GO-2024-0001",
+  "database_specific": {"severity": "HIGH"},
+  "affected": [{
+    "package": {"ecosystem": "Go", "name": "example.com/lib"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.2.0"}]}]
+  }]
+}`,
+	"GO-2024-0002": `{
+  "id": "GO-2024-0002",
+  "affected": [{
+    "package": {"ecosystem": "Go", "name": "example.com/lib"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.2.0"}, {"fixed": "1.3.0"}]}]
+  }]
+}`,
+}
+
+// Version source serving fixed versions of any dependency
+type versionList domain.Versions
+
+func (versionList) GetName() string {
+	return "VersionList"
+}
+
+func (l versionList) FetchVersions(domain.Dependency) (domain.Versions, error) {
+	return domain.Versions(l), nil
+}
+
+func (versionList) FetchVersion(_ domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	return domain.VersionInfo{Version: version}, nil
+}
+
+// Go manager exposing nothing but its version source
+type goVersionsManager struct {
+	domain.DependencyManager
+	versions versionList
+}
+
+func (goVersionsManager) GetEcosystem() string {
+	return "Go"
+}
+
+func (m goVersionsManager) GetVersionSource() domain.VersionSource {
+	return m.versions
+}
+
+func newSecurityPolicy(t *testing.T, config policy.SecurityConfig) *policy.SecurityUpdatePolicy {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for id, advisory := range securityAdvisories {
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(advisory), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config.Database = dir
+
+	p, err := policy.NewSecurityUpdatePolicy(config)
+	if err != nil {
+		t.Fatalf("NewSecurityUpdatePolicy: %v", err)
+	}
+
+	return p
+}
+
+func TestSecurityUpdatePolicy(t *testing.T) {
+	release := func(v string) domain.VersionInfo {
+		return domain.VersionInfo{Version: domain.MustParseVersion(domain.Semver, v)}
+	}
+
+	retracted := release("1.3.0")
+	retracted.Retracted = true
+
+	manager := goVersionsManager{versions: versionList{
+		release("1.0.0"), release("1.2.0"), retracted, release("1.3.1"), release("1.4.0"),
+	}}
+
+	current := []domain.Dependency{
+		{Name: "example.com/lib", Version: domain.MustParseVersion(domain.Semver, "1.0.0")},
+		{Name: "example.com/other", Version: domain.MustParseVersion(domain.Semver, "1.0.0")},
+	}
+
+	updated, err := newSecurityPolicy(t, policy.SecurityConfig{}).GetNextDependencies(current, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updated) != 1 {
+		t.Fatalf("expected only the vulnerable dependency to be updated, got %v", updated)
+	}
+
+	// 1.2.0 fixes the first advisory but is affected by the second, whose
+	// fix is retracted, so the lowest usable version after it is proposed
+	// rather than the latest
+	if updated[0].Version.String() != "1.3.1" {
+		t.Errorf("expected the lowest clean version 1.3.1, got %s", updated[0].Version)
+	}
+
+	if len(updated[0].Advisories) != 1 || updated[0].Advisories[0].ID != "GO-2024-0001" || updated[0].Advisories[0].Severity != "HIGH" {
+		t.Errorf("unexpected advisories %+v", updated[0].Advisories)
+	}
+
+	// Dependencies of ecosystems the policy is not scoped to are left alone
+	updated, err = newSecurityPolicy(t, policy.SecurityConfig{Ecosystems: []string{"npm"}}).GetNextDependencies(current, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updated) != 0 {
+		t.Errorf("expected no updates outside the configured ecosystems, got %v", updated)
+	}
+}
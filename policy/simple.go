@@ -4,7 +4,7 @@ import "github.com/geektype/dependy/domain"
 
 // Simple Update Policy
 //
-// Compares latest non-pre-release version to current version and if newer
+// Compares latest non-pre-release, non-retracted version to current version and if newer
 // version is behind by 1 minor version, the dependency is updated to latest
 // version, including latest patch version.
 type SimpleUpdatePolicy struct{}
@@ -20,15 +20,21 @@ func (SimpleUpdatePolicy) GetNextDependencies(
 	newDeps := make([]domain.Dependency, 0)
 
 	for _, dep := range current {
-		newVer, err := manager.FetchLatestVersion(dep)
+		versions, err := manager.GetVersionSource().FetchVersions(dep)
 		if err != nil {
 			return nil, err
 		}
+
+		latest, ok := versions.Latest(dep.Constraint)
+		if !ok {
+			continue
+		}
+
 		// TODO check if greater than 1 minor version
-		if dep.Version.LessThan(&newVer) {
+		if dep.Version.LessThan(latest.Version) {
 			newDeps = append(newDeps, domain.Dependency{
 				Name:    dep.Name,
-				Version: newVer,
+				Version: latest.Version,
 			})
 		}
 	}
@@ -0,0 +1,186 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parsed SPDX license expression, i.e. `MIT OR Apache-2.0` or
+// `GPL-2.0-or-later WITH Classpath-exception-2.0`
+//
+// A node is either a single license, optionally with an exception, or the
+// conjunction or disjunction of its operands.
+type licenseExpr struct {
+	Op        string // "AND", "OR" or empty for a single license
+	License   string // License identifier as written
+	Exception string // Exception following WITH as written, if any
+	Operands  []licenseExpr
+}
+
+// Parse an SPDX license expression. Operators are accepted in any case and
+// AND binds tighter than OR.
+func parseLicenseExpr(expr string) (licenseExpr, error) {
+	p := &licenseParser{tokens: tokenizeLicense(expr)}
+
+	if len(p.tokens) == 0 {
+		return licenseExpr{}, errors.New("empty license expression")
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return licenseExpr{}, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return licenseExpr{}, fmt.Errorf("unexpected %q in license expression %q", p.tokens[p.pos], expr)
+	}
+
+	return e, nil
+}
+
+func tokenizeLicense(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+// Consume the next token if it is the given operator
+func (p *licenseParser) accept(op string) bool {
+	if strings.EqualFold(p.peek(), op) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *licenseParser) parseOr() (licenseExpr, error) {
+	return p.parseBinary("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (licenseExpr, error) {
+	return p.parseBinary("AND", p.parseTerm)
+}
+
+func (p *licenseParser) parseBinary(op string, operand func() (licenseExpr, error)) (licenseExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return licenseExpr{}, err
+	}
+
+	operands := []licenseExpr{first}
+
+	for p.accept(op) {
+		next, err := operand()
+		if err != nil {
+			return licenseExpr{}, err
+		}
+
+		operands = append(operands, next)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+
+	return licenseExpr{Op: op, Operands: operands}, nil
+}
+
+func (p *licenseParser) parseTerm() (licenseExpr, error) {
+	tok := p.peek()
+
+	switch {
+	case tok == "":
+		return licenseExpr{}, errors.New("license expression ends unexpectedly")
+	case tok == "(":
+		p.pos++
+
+		e, err := p.parseOr()
+		if err != nil {
+			return licenseExpr{}, err
+		}
+
+		if !p.accept(")") {
+			return licenseExpr{}, errors.New("missing closing parenthesis in license expression")
+		}
+
+		return e, nil
+	case tok == ")" || isLicenseOperator(tok):
+		return licenseExpr{}, fmt.Errorf("unexpected %q in license expression", tok)
+	}
+
+	p.pos++
+
+	e := licenseExpr{License: tok}
+
+	if p.accept("WITH") {
+		exception := p.peek()
+		if exception == "" || exception == "(" || exception == ")" || isLicenseOperator(exception) {
+			return licenseExpr{}, errors.New("missing exception after WITH in license expression")
+		}
+
+		p.pos++
+		e.Exception = exception
+	}
+
+	return e, nil
+}
+
+func isLicenseOperator(tok string) bool {
+	return strings.EqualFold(tok, "AND") || strings.EqualFold(tok, "OR") || strings.EqualFold(tok, "WITH")
+}
+
+func (e licenseExpr) String() string {
+	return e.format(licenseExpr.String, false)
+}
+
+// Canonical form of the expression, in which identifiers are normalised and
+// operands sorted so expressions differing only in case or order compare
+// equal
+func (e licenseExpr) key() string {
+	return e.format(licenseExpr.key, true)
+}
+
+func (e licenseExpr) format(operand func(licenseExpr) string, canonical bool) string {
+	if e.Op == "" {
+		s := e.License
+		if e.Exception != "" {
+			s += " WITH " + e.Exception
+		}
+
+		if canonical {
+			return normalise(s)
+		}
+
+		return s
+	}
+
+	operands := make([]string, len(e.Operands))
+
+	for i, o := range e.Operands {
+		operands[i] = operand(o)
+		if o.Op != "" {
+			operands[i] = "(" + operands[i] + ")"
+		}
+	}
+
+	if canonical {
+		sort.Strings(operands)
+	}
+
+	return strings.Join(operands, " "+e.Op+" ")
+}
@@ -44,10 +44,10 @@ func (g *GitlabRemoteHandler) GetName() string {
 	return "GitlabRemoteHandler"
 }
 
-func (g *GitlabRemoteHandler) CheckMRExists(repo domain.Repository) (bool, error) {
+func (g *GitlabRemoteHandler) CheckMRExists(repo domain.Repository, search string) (bool, error) {
 	opt := &gitlab.ListProjectMergeRequestsOptions{
 		State:  gitlab.Ptr("opened"),
-		Search: gitlab.Ptr("Dependy"),
+		Search: &search,
 	}
 
 	mergeRequests, _, err := g.GitlabClient.MergeRequests.ListProjectMergeRequests(repo.ID, opt)
@@ -83,19 +83,21 @@ func (g *GitlabRemoteHandler) GetRepositories() ([]domain.Repository, error) {
 	return repos, nil
 }
 
-func (g *GitlabRemoteHandler) CreateMergeRequest(
-	repo domain.Repository,
-	sourceBranch string,
-	targetBranch string,
-) error {
+func (g *GitlabRemoteHandler) CreateMergeRequest(repo domain.Repository, mr domain.MergeRequest) error {
 	mrOpts := &gitlab.CreateMergeRequestOptions{
-		Title:              gitlab.Ptr("[Dependy] Dependency Update"),
-		SourceBranch:       &sourceBranch,
-		TargetBranch:       &targetBranch,
+		Title:              &mr.Title,
+		Description:        &mr.Description,
+		SourceBranch:       &mr.SourceBranch,
+		TargetBranch:       &mr.TargetBranch,
 		RemoveSourceBranch: &g.RemoveSourceBranch,
 		Squash:             &g.SquashCommits,
 	}
 
+	if len(mr.Labels) > 0 {
+		labels := gitlab.LabelOptions(mr.Labels)
+		mrOpts.Labels = &labels
+	}
+
 	_, _, err := g.GitlabClient.MergeRequests.CreateMergeRequest(repo.ID, mrOpts)
 	if err != nil {
 		return err
@@ -103,3 +105,68 @@ func (g *GitlabRemoteHandler) CreateMergeRequest(
 
 	return nil
 }
+
+func (g *GitlabRemoteHandler) ReportIssue(repo domain.Repository, issue domain.Issue) error {
+	opt := &gitlab.ListProjectIssuesOptions{
+		State:  gitlab.Ptr("opened"),
+		Search: &issue.Title,
+		In:     gitlab.Ptr("title"),
+	}
+
+	issues, _, err := g.GitlabClient.Issues.ListProjectIssues(repo.ID, opt)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range issues {
+		if i.Title == issue.Title {
+			return nil
+		}
+	}
+
+	issueOpts := &gitlab.CreateIssueOptions{
+		Title:       &issue.Title,
+		Description: &issue.Description,
+	}
+
+	if len(issue.Labels) > 0 {
+		labels := gitlab.LabelOptions(issue.Labels)
+		issueOpts.Labels = &labels
+	}
+
+	_, _, err = g.GitlabClient.Issues.CreateIssue(repo.ID, issueOpts)
+
+	return err
+}
+
+func (g *GitlabRemoteHandler) ListTags(project string) ([]domain.Tag, error) {
+	opt := &gitlab.ListTagsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	tags := make([]domain.Tag, 0)
+
+	for {
+		page, resp, err := g.GitlabClient.Tags.ListTags(project, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range page {
+			tag := domain.Tag{Name: t.Name}
+
+			if t.Commit != nil {
+				tag.Commit = t.Commit.ID
+
+				if t.Commit.CommittedDate != nil {
+					tag.Date = *t.Commit.CommittedDate
+				}
+			}
+
+			tags = append(tags, tag)
+		}
+
+		if resp.NextPage == 0 {
+			return tags, nil
+		}
+
+		opt.Page = resp.NextPage
+	}
+}
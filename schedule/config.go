@@ -0,0 +1,49 @@
+package schedule
+
+type Config struct {
+	Windows      []string           // Windows applying to every repository without its own
+	Repositories []RepositoryConfig // Per repository overrides
+}
+
+type RepositoryConfig struct {
+	Name    string   // Repository name of the format <namespace>/<repo_name>
+	Windows []string // Windows replacing the global ones for this repository
+}
+
+func NewSchedules(config Config) (*Schedules, error) {
+	def, err := ParseAll(config.Windows)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make(map[string]Schedule, len(config.Repositories))
+
+	for _, r := range config.Repositories {
+		s, err := ParseAll(r.Windows)
+		if err != nil {
+			return nil, err
+		}
+
+		repos[r.Name] = s
+	}
+
+	return &Schedules{
+		Default:      def,
+		Repositories: repos,
+	}, nil
+}
+
+// Global schedule along with per repository overrides
+type Schedules struct {
+	Default      Schedule
+	Repositories map[string]Schedule
+}
+
+// Get the schedule applying to the named repository
+func (s *Schedules) For(repo string) Schedule {
+	if r, ok := s.Repositories[repo]; ok {
+		return r
+	}
+
+	return s.Default
+}
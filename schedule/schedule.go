@@ -0,0 +1,276 @@
+package schedule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// How far ahead to look for the next opening of a window. Any valid window
+// opens at least once a month.
+const searchDays = 400
+
+var (
+	timeRangeRe  = regexp.MustCompile(`^(\d{1,2}):(\d{2})-(\d{1,2}):(\d{2})$`)
+	rangeSpaceRe = regexp.MustCompile(`\s*[-–]\s*`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Ordinal occurrence of a weekday within a month. -1 is the last occurrence
+var ordinals = map[string]int{
+	"first":  1,
+	"second": 2,
+	"third":  3,
+	"fourth": 4,
+	"last":   -1,
+}
+
+// A recurring window of time in which updates may be raised
+//
+// Windows are written in a human friendly form consisting of a day
+// specification, an optional time range and an optional IANA time zone:
+//
+//	weekdays 06:00-10:00 Europe/London
+//	first Monday of the month
+//	mon,wed,fri 22:00-02:00 UTC
+//
+// Day specifications are `daily`, `weekdays`, `weekends`, a comma separated
+// list of weekdays or `<first|second|third|fourth|last> <weekday> of the month`.
+// Without a time range the window spans the whole day. Without a time zone
+// UTC is assumed. A range ending before it starts runs past midnight.
+type Window struct {
+	days     [7]bool
+	ordinal  int // Non zero restricts days to the nth occurrence in the month
+	start    int // Minute of day the window opens
+	end      int // Minute of day the window closes
+	location *time.Location
+	spec     string
+}
+
+func Parse(spec string) (Window, error) {
+	w := Window{
+		start:    0,
+		end:      minutesPerDay,
+		location: time.UTC,
+		spec:     spec,
+	}
+
+	tokens := strings.Fields(rangeSpaceRe.ReplaceAllString(strings.TrimSpace(spec), "-"))
+	dayTokens := make([]string, 0, len(tokens))
+
+	for _, tok := range tokens {
+		if m := timeRangeRe.FindStringSubmatch(tok); m != nil {
+			start, err := minuteOfDay(m[1], m[2])
+			if err != nil {
+				return w, fmt.Errorf("window %q: %w", spec, err)
+			}
+
+			end, err := minuteOfDay(m[3], m[4])
+			if err != nil {
+				return w, fmt.Errorf("window %q: %w", spec, err)
+			}
+
+			w.start, w.end = start, end
+
+			continue
+		}
+
+		if strings.Contains(tok, "/") || tok == "UTC" || tok == "Local" {
+			loc, err := time.LoadLocation(tok)
+			if err != nil {
+				return w, fmt.Errorf("window %q: %w", spec, err)
+			}
+
+			w.location = loc
+
+			continue
+		}
+
+		dayTokens = append(dayTokens, strings.ToLower(tok))
+	}
+
+	if err := w.parseDays(dayTokens); err != nil {
+		return w, fmt.Errorf("window %q: %w", spec, err)
+	}
+
+	return w, nil
+}
+
+func (w *Window) parseDays(tokens []string) error {
+	switch strings.Join(tokens, " ") {
+	case "", "daily", "everyday":
+		w.days = [7]bool{true, true, true, true, true, true, true}
+		return nil
+	case "weekdays":
+		w.days = [7]bool{false, true, true, true, true, true, false}
+		return nil
+	case "weekends":
+		w.days = [7]bool{true, false, false, false, false, false, true}
+		return nil
+	}
+
+	if n, ok := ordinals[tokens[0]]; ok {
+		rest := strings.Join(tokens[1:], " ")
+		rest = strings.TrimSuffix(rest, " of the month")
+		rest = strings.TrimSuffix(rest, " of month")
+
+		day, ok := weekdays[rest]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", rest)
+		}
+
+		w.ordinal = n
+		w.days[day] = true
+
+		return nil
+	}
+
+	for _, name := range strings.Split(strings.Join(tokens, ""), ",") {
+		day, ok := weekdays[name]
+		if !ok {
+			return fmt.Errorf("unknown day specification %q", name)
+		}
+
+		w.days[day] = true
+	}
+
+	return nil
+}
+
+func minuteOfDay(hour string, minute string) (int, error) {
+	var h, m int
+
+	if _, err := fmt.Sscanf(hour+":"+minute, "%d:%d", &h, &m); err != nil {
+		return 0, err
+	}
+
+	if h > 24 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %s:%s", hour, minute)
+	}
+
+	return h*60 + m, nil
+}
+
+func (w Window) String() string {
+	return w.spec
+}
+
+// Whether the window opens on the given calendar day
+func (w Window) dayMatches(t time.Time) bool {
+	if !w.days[t.Weekday()] {
+		return false
+	}
+
+	switch {
+	case w.ordinal > 0:
+		return (t.Day()-1)/7+1 == w.ordinal
+	case w.ordinal < 0:
+		return t.AddDate(0, 0, 7).Month() != t.Month()
+	default:
+		return true
+	}
+}
+
+// Whether t falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	local := t.In(w.location)
+	minute := local.Hour()*60 + local.Minute()
+
+	if w.start < w.end {
+		return w.dayMatches(local) && minute >= w.start && minute < w.end
+	}
+
+	// Window runs past midnight, so the early hours belong to the window
+	// which opened the previous day
+	if minute >= w.start && w.dayMatches(local) {
+		return true
+	}
+
+	return minute < w.end && w.dayMatches(local.AddDate(0, 0, -1))
+}
+
+// Get the earliest time at or after t which falls inside the window
+func (w Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	local := t.In(w.location)
+	y, m, d := local.Date()
+
+	for i := 0; i <= searchDays; i++ {
+		open := time.Date(y, m, d+i, w.start/60, w.start%60, 0, 0, w.location)
+		if open.After(t) && w.dayMatches(open) {
+			return open
+		}
+	}
+
+	return time.Time{}
+}
+
+// A set of windows. Updates may be raised when any of the windows is open.
+// An empty schedule is always open.
+type Schedule []Window
+
+func ParseAll(specs []string) (Schedule, error) {
+	s := make(Schedule, 0, len(specs))
+
+	for _, spec := range specs {
+		w, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		s = append(s, w)
+	}
+
+	return s, nil
+}
+
+func (s Schedule) Contains(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	for _, w := range s {
+		if w.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get the earliest time at or after t at which the schedule is open
+func (s Schedule) Next(t time.Time) time.Time {
+	if len(s) == 0 {
+		return t
+	}
+
+	var next time.Time
+
+	for _, w := range s {
+		n := w.Next(t)
+		if n.IsZero() {
+			continue
+		}
+
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	return next
+}
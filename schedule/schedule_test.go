@@ -0,0 +1,95 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geektype/dependy/schedule"
+)
+
+func mustParse(t *testing.T, spec string) schedule.Window {
+	t.Helper()
+
+	w, err := schedule.Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", spec, err)
+	}
+
+	return w
+}
+
+func TestWindowNext(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			spec: "weekdays 06:00–10:00 Europe/London",
+			from: time.Date(2024, 6, 7, 8, 30, 0, 0, london), // Friday, inside window
+			want: time.Date(2024, 6, 7, 8, 30, 0, 0, london),
+		},
+		{
+			spec: "weekdays 06:00-10:00 Europe/London",
+			from: time.Date(2024, 6, 7, 11, 0, 0, 0, london), // Friday, after window
+			want: time.Date(2024, 6, 10, 6, 0, 0, 0, london),
+		},
+		{
+			spec: "first Monday of the month",
+			from: time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "last fri",
+			from: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 6, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "sat,sun 22:00-02:00 UTC",
+			from: time.Date(2024, 6, 10, 1, 0, 0, 0, time.UTC), // Monday early hours, Sunday's window
+			want: time.Date(2024, 6, 10, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		got := mustParse(t, tt.spec).Next(tt.from)
+		if !got.Equal(tt.want) {
+			t.Errorf("%q from %s: expected %s, got %s", tt.spec, tt.from, tt.want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"someday", "first blursday of the month", "weekdays 25:00-26:00"} {
+		if _, err := schedule.Parse(spec); err == nil {
+			t.Errorf("expected error parsing %q", spec)
+		}
+	}
+}
+
+func TestSchedulesFor(t *testing.T) {
+	s, err := schedule.NewSchedules(schedule.Config{
+		Windows: []string{"weekdays"},
+		Repositories: []schedule.RepositoryConfig{
+			{Name: "geektype/dependy", Windows: []string{"weekends"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saturday := time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)
+
+	if s.For("geektype/other").Contains(saturday) {
+		t.Error("expected default schedule to be closed on Saturday")
+	}
+
+	if !s.For("geektype/dependy").Contains(saturday) {
+		t.Error("expected repository schedule to be open on Saturday")
+	}
+}
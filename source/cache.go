@@ -0,0 +1,207 @@
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const defaultCacheTTL = time.Hour
+
+type CacheConfig struct {
+	TTL  int    // Number of seconds a lookup is reused for. Defaults to one hour
+	Path string // File the cache is persisted to between runs. Empty keeps the cache in memory only
+}
+
+type cacheEntry struct {
+	Versions domain.Versions     `json:",omitempty"`
+	Version  *domain.VersionInfo `json:",omitempty"`
+	Expires  time.Time
+}
+
+// A lookup currently being performed. Concurrent lookups of the same key
+// wait for it rather than querying the source again.
+type cacheCall struct {
+	wg    sync.WaitGroup
+	entry cacheEntry
+	err   error
+}
+
+func NewCacheStore(config CacheConfig) (*CacheStore, error) {
+	ttl := time.Duration(config.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	c := &CacheStore{
+		ttl:      ttl,
+		path:     config.Path,
+		entries:  make(map[string]cacheEntry),
+		inflight: make(map[string]*cacheCall),
+	}
+
+	if c.path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Caches written by older releases are discarded rather than migrated
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		slog.Warn("Discarding unreadable version cache", slog.String("path", c.path), slog.Any("error", err))
+		c.entries = make(map[string]cacheEntry)
+	}
+
+	return c, nil
+}
+
+// Version lookup cache shared by every repository
+//
+// Popular dependencies appear in most repositories, so lookups are cached
+// by ecosystem and package for the lifetime of the process and, when a path
+// is configured, between runs. Failed lookups are never cached.
+type CacheStore struct {
+	ttl      time.Duration
+	path     string
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*cacheCall
+}
+
+// Wrap a version source so its lookups go through the cache. A nil store
+// returns the source unchanged.
+func (c *CacheStore) Wrap(ecosystem string, src domain.VersionSource) domain.VersionSource {
+	if c == nil {
+		return src
+	}
+
+	return &cachedSource{
+		VersionSource: src,
+		store:         c,
+		prefix:        ecosystem + "/" + src.GetName() + "/",
+	}
+}
+
+func (c *CacheStore) do(key string, fetch func() (cacheEntry, error)) (cacheEntry, error) {
+	c.mu.Lock()
+
+	if e, ok := c.entries[key]; ok && time.Now().Before(e.Expires) {
+		c.mu.Unlock()
+		return e, nil
+	}
+
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+
+		return call.entry, call.err
+	}
+
+	call := &cacheCall{}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.entry, call.err = fetch()
+
+	c.mu.Lock()
+
+	if call.err == nil {
+		call.entry.Expires = time.Now().Add(c.ttl)
+		c.entries[key] = call.entry
+	}
+
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	call.wg.Done()
+
+	return call.entry, call.err
+}
+
+// Persist unexpired entries to disk. Does nothing if no path is configured.
+func (c *CacheStore) Save() error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+
+	now := time.Now()
+	live := make(map[string]cacheEntry, len(c.entries))
+
+	for k, e := range c.entries {
+		if now.Before(e.Expires) {
+			live[k] = e
+		}
+	}
+
+	c.mu.Unlock()
+
+	data, err := json.Marshal(live)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted save never leaves a
+	// truncated cache behind
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+type cachedSource struct {
+	domain.VersionSource
+	store  *CacheStore
+	prefix string
+}
+
+func (c *cachedSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	e, err := c.store.do(c.prefix+dep.Name, func() (cacheEntry, error) {
+		versions, err := c.VersionSource.FetchVersions(dep)
+		return cacheEntry{Versions: versions}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers get their own copy so the cached entry can not be modified
+	return append(domain.Versions(nil), e.Versions...), nil
+}
+
+func (c *cachedSource) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	e, err := c.store.do(c.prefix+dep.Name+"@"+version.String(), func() (cacheEntry, error) {
+		info, err := c.VersionSource.FetchVersion(dep, version)
+		return cacheEntry{Version: &info}, err
+	})
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	return *e.Version, nil
+}
@@ -0,0 +1,99 @@
+package source_test
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+type countingSource struct {
+	calls atomic.Int32
+}
+
+func (*countingSource) GetName() string {
+	return "Counting"
+}
+
+func (c *countingSource) FetchVersions(domain.Dependency) (domain.Versions, error) {
+	c.calls.Add(1)
+	time.Sleep(10 * time.Millisecond)
+
+	return domain.Versions{{Version: domain.MustParseVersion(domain.Semver, "1.2.3")}}, nil
+}
+
+func (c *countingSource) FetchVersion(_ domain.Dependency, v domain.Version) (domain.VersionInfo, error) {
+	c.calls.Add(1)
+
+	return domain.VersionInfo{Version: v, Licenses: []string{"MIT"}}, nil
+}
+
+func TestCacheDeduplicatesLookups(t *testing.T) {
+	store, err := source.NewCacheStore(source.CacheConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &countingSource{}
+	cached := store.Wrap("Go", src)
+	dep := domain.Dependency{Name: "example.com/lib"}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := cached.FetchVersions(dep); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := src.calls.Load(); n != 1 {
+		t.Errorf("expected a single lookup, got %d", n)
+	}
+}
+
+func TestCachePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.json")
+
+	store, err := source.NewCacheStore(source.CacheConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dep := domain.Dependency{Name: "example.com/lib"}
+
+	if _, err := store.Wrap("Go", &countingSource{}).FetchVersion(dep, domain.MustParseVersion(domain.Semver, "1.2.3")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := source.NewCacheStore(source.CacheConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &countingSource{}
+
+	info, err := reloaded.Wrap("Go", src).FetchVersion(dep, domain.MustParseVersion(domain.Semver, "1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if src.calls.Load() != 0 || len(info.Licenses) != 1 {
+		t.Errorf("expected lookup to be served from persisted cache, got %d calls", src.calls.Load())
+	}
+}
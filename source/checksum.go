@@ -0,0 +1,246 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+const defaultSumDB = "sum.golang.org"
+
+// Verifier keys of the checksum databases known to the go command
+var knownSumDBs = map[string]string{
+	"sum.golang.org":       "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ctvb7c7vjjYy2/0x",
+	"sum.golang.google.cn": "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ctvb7c7vjjYy2/0x",
+}
+
+func NewChecksums(config GoProxyConfig, proxy *GoProxy) (*Checksums, error) {
+	noSumDB := config.GoNoSumDB
+	if noSumDB == "" {
+		noSumDB = config.GoPrivate
+	}
+
+	c := &Checksums{proxy: proxy, noSumDB: noSumDB}
+
+	spec := strings.TrimSpace(config.GoSumDB)
+	if spec == "" {
+		spec = defaultSumDB
+	}
+
+	if spec == "off" {
+		return c, nil
+	}
+
+	ops, err := newSumDBOps(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	c.db = sumdb.NewClient(ops)
+
+	return c, nil
+}
+
+// Provides the go.sum hashes of module versions
+//
+// Hashes are looked up in the checksum database, which verifies them against
+// its signed transparency log. Modules excluded from the database, such as
+// private ones, or every module when the database is turned off, are hashed
+// from the content served by the module proxy instead.
+type Checksums struct {
+	proxy   *GoProxy
+	db      *sumdb.Client // nil if the checksum database is turned off
+	noSumDB string
+}
+
+// Get the hash of the go.mod file of a module version
+func (c *Checksums) GoModSum(path string, version string) (string, error) {
+	if c.useDB(path) {
+		return c.lookup(path, version+"/go.mod")
+	}
+
+	data, err := c.proxy.GoMod(path, version)
+	if err != nil {
+		return "", err
+	}
+
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// Get the hash of the full content of a module version
+func (c *Checksums) ZipSum(path string, version string) (string, error) {
+	if c.useDB(path) {
+		return c.lookup(path, version)
+	}
+
+	data, err := c.proxy.Zip(path, version)
+	if err != nil {
+		return "", err
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("invalid zip for %s@%s: %w", path, version, err)
+	}
+
+	files := make([]string, 0, len(z.File))
+	entries := make(map[string]*zip.File, len(z.File))
+
+	for _, f := range z.File {
+		files = append(files, f.Name)
+		entries[f.Name] = f
+	}
+
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return entries[name].Open()
+	})
+}
+
+func (c *Checksums) useDB(path string) bool {
+	return c.db != nil && !module.MatchPrefixPatterns(c.noSumDB, path)
+}
+
+func (c *Checksums) lookup(path string, version string) (string, error) {
+	lines, err := c.db.Lookup(path, version)
+	if err != nil {
+		return "", err
+	}
+
+	for _, l := range lines {
+		if f := strings.Fields(l); len(f) == 3 {
+			return f[2], nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum found for %s@%s", path, version)
+}
+
+// Client side operations of the checksum database protocol
+//
+// The latest signed tree and fetched tiles are only kept in memory, so the
+// consistency of the log is verified for the lifetime of the process.
+type sumDBOps struct {
+	name   string
+	key    string
+	url    string
+	client *http.Client
+
+	mu     sync.Mutex
+	config map[string][]byte
+	cache  map[string][]byte
+}
+
+// Parse a GOSUMDB value: a known database name, or a verifier key optionally
+// followed by the URL of the database
+func newSumDBOps(spec string) (*sumDBOps, error) {
+	fields := strings.Fields(spec)
+	if len(fields) > 2 {
+		return nil, fmt.Errorf("invalid GoSumDB %q", spec)
+	}
+
+	key := fields[0]
+
+	if known, ok := knownSumDBs[key]; ok {
+		if len(fields) == 1 {
+			fields = append(fields, "https://"+key)
+		}
+
+		key = known
+	}
+
+	name, _, ok := strings.Cut(key, "+")
+	if !ok {
+		return nil, fmt.Errorf("unknown checksum database %s", key)
+	}
+
+	url := "https://" + name
+	if len(fields) == 2 {
+		url = fields[1]
+	}
+
+	return &sumDBOps{
+		name:   name,
+		key:    key,
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: proxyTimeout},
+		config: make(map[string][]byte),
+		cache:  make(map[string][]byte),
+	}, nil
+}
+
+func (o *sumDBOps) ReadRemote(path string) ([]byte, error) {
+	resp, err := o.client.Get(o.url + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s%s: %s", o.url, path, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxProxyResponse))
+}
+
+func (o *sumDBOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(o.key), nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.config[file], nil
+}
+
+func (o *sumDBOps) WriteConfig(file string, old []byte, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !bytes.Equal(o.config[file], old) {
+		return sumdb.ErrWriteConflict
+	}
+
+	o.config[file] = new
+
+	return nil
+}
+
+func (o *sumDBOps) ReadCache(file string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	data, ok := o.cache[file]
+	if !ok {
+		return nil, errors.New("not cached")
+	}
+
+	return data, nil
+}
+
+func (o *sumDBOps) WriteCache(file string, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.cache[file] = data
+}
+
+func (o *sumDBOps) Log(msg string) {
+	slog.Debug(msg, slog.String("sumdb", o.name))
+}
+
+func (o *sumDBOps) SecurityError(msg string) {
+	slog.Error(msg, slog.String("sumdb", o.name))
+}
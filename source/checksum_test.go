@@ -0,0 +1,63 @@
+package source_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geektype/dependy/source"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+func TestChecksumsFromSumDB(t *testing.T) {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sumdb.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sumdb.NewTestServer(skey, func(path, vers string) ([]byte, error) {
+		return []byte(fmt.Sprintf("%s %s h1:zip=\n%s %s/go.mod h1:mod=\n", path, vers, path, vers)), nil
+	})
+
+	srv := httptest.NewServer(sumdb.NewServer(db))
+	defer srv.Close()
+
+	c, err := source.NewChecksums(source.GoProxyConfig{GoSumDB: vkey + " " + srv.URL}, source.NewGoProxy(source.GoProxyConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := c.ZipSum("example.com/lib", "v1.2.0")
+	if err != nil || sum != "h1:zip=" {
+		t.Errorf("expected h1:zip=, got %q (%v)", sum, err)
+	}
+
+	sum, err = c.GoModSum("example.com/lib", "v1.2.0")
+	if err != nil || sum != "h1:mod=" {
+		t.Errorf("expected h1:mod=, got %q (%v)", sum, err)
+	}
+}
+
+func TestChecksumsFromProxy(t *testing.T) {
+	proxy := newProxyServer(t, "", map[string]string{
+		"/example.com/lib/@v/v1.2.0.mod": "module example.com/lib\n",
+	})
+
+	// Private modules are never looked up in the checksum database
+	c, err := source.NewChecksums(source.GoProxyConfig{GoNoSumDB: "example.com"}, source.NewGoProxy(source.GoProxyConfig{GoProxy: proxy.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := c.GoModSum("example.com/lib", "v1.2.0")
+	if err != nil {
+		t.Fatalf("GoModSum: %v", err)
+	}
+
+	// Hash as recorded in go.sum by the go command for this go.mod
+	if sum != "h1:4OAnUP7RpKJ0hg7ydNi+A/luktLxx7xSrw0c+FeAtlc=" {
+		t.Errorf("unexpected go.mod hash %s", sum)
+	}
+}
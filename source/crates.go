@@ -0,0 +1,158 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const defaultCratesIndex = "https://index.crates.io"
+
+type CratesIndexConfig struct {
+	URL   string // Sparse index base URL, without the sparse+ prefix. Defaults to index.crates.io
+	Token string // Sent as is in the Authorization header, as cargo does for private registries
+}
+
+// Entry of a crate's index file, one per published version
+type crateIndexEntry struct {
+	Name   string `json:"name"`
+	Vers   string `json:"vers"`
+	Yanked bool   `json:"yanked"`
+}
+
+func NewCratesIndex(config CratesIndexConfig) *CratesIndex {
+	base := config.URL
+	if base == "" {
+		base = defaultCratesIndex
+	}
+
+	return &CratesIndex{
+		url:    strings.TrimSuffix(strings.TrimPrefix(base, "sparse+"), "/"),
+		token:  config.Token,
+		client: &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for the sparse protocol of crates.io compatible registries
+//
+// The index only records versions and whether they were yanked, so publish
+// times and licenses are left unknown. Yanked versions are treated as
+// retracted.
+type CratesIndex struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (*CratesIndex) GetName() string {
+	return "CratesIndex"
+}
+
+func (c *CratesIndex) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	entries, err := c.entries(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(entries))
+
+	for _, e := range entries {
+		v, err := domain.ParseVersion(domain.Semver, e.Vers)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{
+			Version:    v,
+			Prerelease: v.Prerelease(),
+			Retracted:  e.Yanked,
+		})
+	}
+
+	return versions, nil
+}
+
+func (c *CratesIndex) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	versions, err := c.FetchVersions(dep)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	for _, v := range versions {
+		if v.Version.Equal(version) {
+			return v, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("%s %s not found in index", dep.Name, version.String())
+}
+
+func (c *CratesIndex) entries(name string) ([]crateIndexEntry, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url+"/"+CrateIndexPath(name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyResponse))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]crateIndexEntry, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var e crateIndexEntry
+
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid index entry for %s: %w", name, err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
+
+// Get the path of a crate's file within the index, which shards crates by
+// the first characters of their lowercased name
+func CrateIndexPath(name string) string {
+	name = strings.ToLower(name)
+
+	switch len(name) {
+	case 1:
+		return "1/" + name
+	case 2:
+		return "2/" + name
+	case 3:
+		return "3/" + name[:1] + "/" + name
+	default:
+		return name[:2] + "/" + name[2:4] + "/" + name
+	}
+}
@@ -0,0 +1,59 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func TestCrateIndexPath(t *testing.T) {
+	for name, want := range map[string]string{
+		"a":          "1/a",
+		"xz":         "2/xz",
+		"Log":        "3/l/log",
+		"serde_json": "se/rd/serde_json",
+	} {
+		if got := source.CrateIndexPath(name); got != want {
+			t.Errorf("CrateIndexPath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCratesIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/3/l/log" || r.Header.Get("Authorization") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"name":"log","vers":"0.4.20","yanked":false}
+{"name":"log","vers":"0.4.21","yanked":true}
+{"name":"log","vers":"0.5.0-alpha.1","yanked":false}
+`))
+	}))
+	defer server.Close()
+
+	index := source.NewCratesIndex(source.CratesIndexConfig{URL: "sparse+" + server.URL + "/", Token: "secret"})
+
+	versions, err := index.FetchVersions(domain.Dependency{Name: "log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest, ok := versions.Latest(nil)
+	if !ok || latest.Version.String() != "0.4.20" {
+		t.Errorf("expected yanked and prerelease versions to be skipped, got %v", latest.Version)
+	}
+
+	info, err := index.FetchVersion(domain.Dependency{Name: "log"}, domain.MustParseVersion(domain.Semver, "0.4.21"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.Retracted {
+		t.Error("expected yanked version to be retracted")
+	}
+}
@@ -0,0 +1,95 @@
+package source
+
+import (
+	"github.com/edoardottt/depsdev/pkg/depsdev"
+	"github.com/geektype/dependy/domain"
+)
+
+// deps.dev package system names
+const (
+	SystemGo    = "go"
+	SystemNPM   = "npm"
+	SystemCargo = "cargo"
+	SystemPyPI  = "pypi"
+	SystemMaven = "maven"
+	SystemNuGet = "nuget"
+)
+
+// Versioning schemes of the package systems which do not use semantic
+// versioning
+var depsDevSchemes = map[string]domain.VersionScheme{
+	SystemPyPI:  PEP440,
+	SystemMaven: Maven,
+}
+
+func NewDepsDev(system string) *DepsDev {
+	scheme, ok := depsDevSchemes[system]
+	if !ok {
+		scheme = domain.Semver
+	}
+
+	return &DepsDev{
+		System:    system,
+		Scheme:    scheme,
+		APIClient: depsdev.NewAPI(),
+	}
+}
+
+// Version source backed by the public deps.dev API
+//
+// Covers every ecosystem deps.dev indexes but can not see private packages.
+type DepsDev struct {
+	System    string // deps.dev package system i.e. go, npm, cargo
+	Scheme    domain.VersionScheme
+	APIClient *depsdev.API
+}
+
+func (*DepsDev) GetName() string {
+	return "DepsDev"
+}
+
+func (d *DepsDev) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	info, err := d.APIClient.GetInfo(d.System, dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(info.Versions))
+
+	for _, v := range info.Versions {
+		ver, err := domain.ParseVersion(d.Scheme, v.VersionKey.Version)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{
+			Version:     ver,
+			PublishedAt: v.PublishedAt,
+			Prerelease:  ver.Prerelease(),
+		})
+	}
+
+	return versions, nil
+}
+
+func (d *DepsDev) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	info, err := d.APIClient.GetVersion(d.System, dep.Name, d.format(version))
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	return domain.VersionInfo{
+		Version:     version,
+		PublishedAt: info.PublishedAt,
+		Prerelease:  version.Prerelease(),
+		Licenses:    info.Licenses,
+	}, nil
+}
+
+func (d *DepsDev) format(version domain.Version) string {
+	if d.System == SystemGo {
+		return GoVersion(version)
+	}
+
+	return version.String()
+}
@@ -0,0 +1,110 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Matches image tags starting with a version, i.e. 1.23.1, v2.4 or
+// 3.12-slim-bookworm, splitting off the variant suffix
+var dockerTagRe = regexp.MustCompile(`^(v?)([0-9]+(?:\.[0-9]+)*)(.*)$`)
+
+// Matches variant suffixes marking pre-releases, i.e. -rc1 or beta2-alpine
+var dockerPrereleaseRe = regexp.MustCompile(`(?i)^[-._]?(alpha|beta|rc|pre|preview|dev)[0-9.]*(?:$|[-_])`)
+
+// Version scheme of container image tags
+//
+// Tags have no common format. Those starting with a version are ordered by
+// their numeric release, while whatever follows it is treated as the variant
+// of the image, i.e. -alpine or -slim-bookworm. Tags such as latest or
+// bookworm can not be ordered and fail to parse.
+var DockerTag domain.VersionScheme = dockerTagScheme{}
+
+func init() {
+	domain.RegisterVersionScheme(DockerTag)
+}
+
+type dockerTagScheme struct{}
+
+func (dockerTagScheme) GetName() string {
+	return "docker"
+}
+
+func (dockerTagScheme) Parse(raw string) (domain.ParsedVersion, error) {
+	m := dockerTagRe.FindStringSubmatch(raw)
+	if m == nil {
+		return nil, fmt.Errorf("tag %q does not start with a version", raw)
+	}
+
+	t := dockerTag{prefix: m[1], suffix: m[3]}
+
+	for _, s := range strings.Split(m[2], ".") {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		t.release = append(t.release, n)
+	}
+
+	return t, nil
+}
+
+type dockerTag struct {
+	prefix  string // v, if the tag has one
+	release []uint64
+	suffix  string // Variant following the release, i.e. -alpine
+}
+
+func (t dockerTag) Prerelease() bool {
+	return dockerPrereleaseRe.MatchString(t.suffix)
+}
+
+func (t dockerTag) Release() []uint64 {
+	return t.release
+}
+
+// Tags of different variants are ordered by their suffix once their releases
+// are equal, so that the order is total
+func (t dockerTag) Compare(other domain.ParsedVersion) int {
+	o := other.(dockerTag)
+
+	for i := 0; i < len(t.release) || i < len(o.release); i++ {
+		if c := compareUint(segmentAt(t.release, i), segmentAt(o.release, i)); c != 0 {
+			return c
+		}
+	}
+
+	if c := compareUint(uint64(len(t.release)), uint64(len(o.release))); c != 0 {
+		return c
+	}
+
+	return strings.Compare(t.suffix, o.suffix)
+}
+
+// Whether two tags belong to the same variant of an image, which they do
+// when they share their suffix and the precision of their release. 1.23.1
+// may move to 1.23.2 but neither to 1.24, which floats over patch releases,
+// nor to 1.23.2-alpine.
+func SameDockerVariant(a, b domain.Version) bool {
+	at, aOk := dockerTagOf(a)
+	bt, bOk := dockerTagOf(b)
+
+	return aOk && bOk &&
+		at.prefix == bt.prefix &&
+		at.suffix == bt.suffix &&
+		len(at.release) == len(bt.release)
+}
+
+func dockerTagOf(v domain.Version) (dockerTag, bool) {
+	parsed, err := DockerTag.Parse(v.String())
+	if err != nil {
+		return dockerTag{}, false
+	}
+
+	return parsed.(dockerTag), true
+}
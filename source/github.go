@@ -0,0 +1,103 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const defaultGitHubAPI = "https://api.github.com"
+
+type GitHubConfig struct {
+	URL   string // API base URL, i.e. https://github.example.com/api/v3 for GitHub Enterprise Server. Defaults to https://api.github.com
+	Token string // Token sent to the API, raising its rate limit
+}
+
+func NewGitHub(config GitHubConfig) *GitHub {
+	base := config.URL
+	if base == "" {
+		base = defaultGitHubAPI
+	}
+
+	return &GitHub{
+		url:    strings.TrimSuffix(base, "/"),
+		token:  config.Token,
+		client: &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for the tags of repositories hosted on GitHub
+//
+// The tags API does not tell when tags were created, so their dates are
+// left unknown.
+type GitHub struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (g *GitHub) ListTags(project string) ([]domain.Tag, error) {
+	owner, repo, ok := strings.Cut(project, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid GitHub repository %q", project)
+	}
+
+	next := g.url + "/repos/" + owner + "/" + repo + "/tags?per_page=100"
+	seen := make(map[string]bool)
+	tags := make([]domain.Tag, 0)
+
+	for next != "" && !seen[next] {
+		seen[next] = true
+
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Accept", "application/vnd.github+json")
+
+		if g.token != "" {
+			req.Header.Set("Authorization", "Bearer "+g.token)
+		}
+
+		resp, err := g.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+		}
+
+		var page []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(&page)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag list of %s: %w", project, err)
+		}
+
+		for _, t := range page {
+			tags = append(tags, domain.Tag{Name: t.Name, Commit: t.Commit.SHA})
+		}
+
+		next = ""
+
+		if m := nextLinkRe.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			next = m[1]
+		}
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,58 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func TestGitHubTags(t *testing.T) {
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/actions/checkout/tags" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<`+srv.URL+`/repos/actions/checkout/tags?per_page=100&page=2>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"name": "v4", "commit": {"sha": "aaa"}}, {"name": "v4.1.1", "commit": {"sha": "aaa"}}]`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`[{"name": "v4.0.0", "commit": {"sha": "bbb"}}, {"name": "releases/v1", "commit": {"sha": "ccc"}}]`))
+	}))
+	defer srv.Close()
+
+	tags := source.NewTagSource("GitHubTags", source.NewGitHub(source.GitHubConfig{URL: srv.URL}))
+
+	dep := domain.Dependency{Name: "actions/checkout", Version: domain.MustParseVersion(domain.Semver, "v4")}
+
+	versions, err := tags.FetchVersions(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("expected the 3 version tags of both pages, got %d", len(versions))
+	}
+
+	// v4 and v4.0.0 are equal versions, but different tags
+	info, err := tags.FetchVersion(dep, domain.MustParseVersion(domain.Semver, "v4.0.0"))
+	if err != nil || info.Version.String() != "v4.0.0" {
+		t.Errorf("expected tag v4.0.0, got %v (%v)", info.Version, err)
+	}
+
+	if commit, err := tags.Commit("actions/checkout", "v4.0.0"); err != nil || commit != "bbb" {
+		t.Errorf("expected commit bbb, got %q (%v)", commit, err)
+	}
+
+	if _, err := tags.FetchVersions(domain.Dependency{Name: "checkout"}); err == nil {
+		t.Error("expected a name without an owner to be rejected")
+	}
+}
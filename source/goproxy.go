@@ -0,0 +1,357 @@
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+const defaultGoProxy = "https://proxy.golang.org"
+
+// Maximum size of a response accepted from a module proxy
+const maxProxyResponse = 16 << 20
+
+// Maximum size of a module zip, matching the limit enforced by the go command
+const maxModuleZip = 500 << 20
+
+const proxyTimeout = 30 * time.Second
+
+// Returned when a proxy does not know about a module or version. The next
+// proxy in a comma separated list is only tried on this error.
+var errProxyNotFound = errors.New("not found")
+
+type GoProxyConfig struct {
+	GoProxy      string      // Proxy list using GOPROXY syntax. Defaults to proxy.golang.org
+	GoPrivate    string      // Glob patterns of private module paths using GOPRIVATE syntax
+	GoNoProxy    string      // Glob patterns of modules not fetched from GoProxy. Defaults to GoPrivate
+	PrivateProxy string      // Proxy list, using GOPROXY syntax, used for modules matching GoNoProxy
+	GoSumDB      string      // Checksum database using GOSUMDB syntax. Defaults to sum.golang.org
+	GoNoSumDB    string      // Glob patterns of modules not looked up in GoSumDB. Defaults to GoPrivate
+	Auth         []ProxyAuth // Credentials for proxies requiring authentication
+}
+
+// Credentials for a single proxy host. Token takes precedence over basic auth
+type ProxyAuth struct {
+	Host     string
+	Username string
+	Password string
+	Token    string
+}
+
+// Response of the `@latest` and `@v/<version>.info` GOPROXY endpoints
+type ModuleInfo struct {
+	Version string
+	Time    time.Time
+}
+
+type proxyEntry struct {
+	URL      string
+	Fallback bool // Try the next proxy on any error rather than only when not found
+}
+
+func NewGoProxy(config GoProxyConfig) *GoProxy {
+	public := config.GoProxy
+	if public == "" {
+		public = defaultGoProxy
+	}
+
+	noProxy := config.GoNoProxy
+	if noProxy == "" {
+		noProxy = config.GoPrivate
+	}
+
+	auth := make(map[string]ProxyAuth, len(config.Auth))
+	for _, a := range config.Auth {
+		auth[a.Host] = a
+	}
+
+	return &GoProxy{
+		public:  parseProxyList(public),
+		private: parseProxyList(config.PrivateProxy),
+		noProxy: noProxy,
+		auth:    auth,
+		client:  &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for the GOPROXY protocol
+//
+// Follows the semantics of the go command: proxies are tried in order, a
+// comma moves to the next proxy only when a module is not found while a pipe
+// moves on after any error. Modules matching GONOPROXY are never sent to the
+// public proxies. As dependy can not fetch modules directly from version
+// control, they are resolved through PrivateProxy instead. `file://` URLs
+// are read from disk, allowing a module cache download directory to be used.
+type GoProxy struct {
+	public  []proxyEntry
+	private []proxyEntry
+	noProxy string
+	auth    map[string]ProxyAuth
+	client  *http.Client
+}
+
+func parseProxyList(list string) []proxyEntry {
+	entries := make([]proxyEntry, 0)
+
+	for list != "" {
+		i := strings.IndexAny(list, ",|")
+
+		var entry proxyEntry
+
+		if i < 0 {
+			entry.URL, list = list, ""
+		} else {
+			entry.URL, entry.Fallback, list = list[:i], list[i] == '|', list[i+1:]
+		}
+
+		entry.URL = strings.TrimSpace(entry.URL)
+		if entry.URL != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (*GoProxy) GetName() string {
+	return "GoProxy"
+}
+
+// Fetch every tagged version of a module. Publish times are left out as they
+// require a request per version. Modules without any tagged version fall
+// back to the proxy's view of the latest version.
+func (g *GoProxy) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	raw, err := g.List(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		info, err := g.Latest(dep.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = []string{info.Version}
+	}
+
+	versions := make(domain.Versions, 0, len(raw))
+
+	for _, r := range raw {
+		v, err := domain.ParseVersion(domain.Semver, r)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{
+			Version:    v,
+			Prerelease: modsemver.Prerelease(r) != "",
+		})
+	}
+
+	return versions, nil
+}
+
+func (g *GoProxy) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	raw := GoVersion(version)
+
+	info, err := g.Info(dep.Name, raw)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	return domain.VersionInfo{
+		Version:     version,
+		PublishedAt: info.Time,
+		Prerelease:  modsemver.Prerelease(raw) != "",
+	}, nil
+}
+
+// Format a version the way the go command expects it, with a leading v
+func GoVersion(v domain.Version) string {
+	if s := v.String(); !strings.HasPrefix(s, "v") {
+		return "v" + s
+	}
+
+	return v.String()
+}
+
+// Get all known versions of a module. Pseudo-versions are not included.
+func (g *GoProxy) List(path string) ([]string, error) {
+	body, err := g.get(path, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(body)), nil
+}
+
+// Get the version the proxy considers latest
+//
+// Proxies without an `@latest` endpoint, such as a module cache directory,
+// fall back to the highest listed version like the go command does.
+func (g *GoProxy) Latest(path string) (ModuleInfo, error) {
+	var info ModuleInfo
+
+	body, err := g.get(path, "@latest")
+	if errors.Is(err, errProxyNotFound) {
+		versions, listErr := g.List(path)
+		if listErr != nil || len(versions) == 0 {
+			return info, err
+		}
+
+		modsemver.Sort(versions)
+
+		return g.Info(path, versions[len(versions)-1])
+	}
+
+	if err != nil {
+		return info, err
+	}
+
+	if err := json.Unmarshal(body, &info); err != nil {
+		return info, fmt.Errorf("invalid @latest response for %s: %w", path, err)
+	}
+
+	return info, nil
+}
+
+// Get metadata about a specific version of a module
+func (g *GoProxy) Info(path string, version string) (ModuleInfo, error) {
+	var info ModuleInfo
+
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return info, err
+	}
+
+	body, err := g.get(path, "@v/"+escaped+".info")
+	if err != nil {
+		return info, err
+	}
+
+	if err := json.Unmarshal(body, &info); err != nil {
+		return info, fmt.Errorf("invalid info response for %s@%s: %w", path, version, err)
+	}
+
+	return info, nil
+}
+
+// Get the go.mod of a specific version of a module
+func (g *GoProxy) GoMod(path string, version string) ([]byte, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.get(path, "@v/"+escaped+".mod")
+}
+
+// Get the zip archive of a specific version of a module
+func (g *GoProxy) Zip(path string, version string) ([]byte, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.get(path, "@v/"+escaped+".zip")
+}
+
+func (g *GoProxy) get(path string, endpoint string) ([]byte, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := g.public
+	if module.MatchPrefixPatterns(g.noProxy, path) {
+		entries = g.private
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no proxy configured for %s", path)
+	}
+
+	var lastErr error
+
+	for _, e := range entries {
+		switch e.URL {
+		case "off":
+			return nil, fmt.Errorf("module lookup disabled by GOPROXY=off for %s", path)
+		case "direct":
+			return nil, fmt.Errorf("direct module fetching is not supported for %s", path)
+		}
+
+		body, err := g.fetch(e.URL, escaped+"/"+endpoint)
+		if err == nil {
+			return body, nil
+		}
+
+		lastErr = err
+
+		if !e.Fallback && !errors.Is(err, errProxyNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (g *GoProxy) fetch(base string, rel string) ([]byte, error) {
+	if dir, ok := strings.CutPrefix(base, "file://"); ok {
+		body, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), filepath.FromSlash(rel)))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s/%s: %w", base, rel, errProxyNotFound)
+		}
+
+		return body, err
+	}
+
+	target := strings.TrimSuffix(base, "/") + "/" + rel
+
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if u, err := url.Parse(base); err == nil {
+		if a, ok := g.auth[u.Host]; ok {
+			if a.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+a.Token)
+			} else {
+				req.SetBasicAuth(a.Username, a.Password)
+			}
+		}
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	limit := int64(maxProxyResponse)
+	if strings.HasSuffix(rel, ".zip") {
+		limit = maxModuleZip
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(io.LimitReader(resp.Body, limit))
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("GET %s: %w", req.URL.Redacted(), errProxyNotFound)
+	default:
+		return nil, fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+	}
+}
@@ -0,0 +1,123 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func newProxyServer(t *testing.T, token string, files map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestGoProxyFetchVersions(t *testing.T) {
+	files := map[string]string{
+		"/example.com/lib/@v/list": "v1.0.0\nv1.1.0\nv1.3.0-rc.1\n",
+	}
+
+	empty := newProxyServer(t, "", nil)
+	proxy := newProxyServer(t, "", files)
+
+	p := source.NewGoProxy(source.GoProxyConfig{GoProxy: empty.URL + "," + proxy.URL})
+
+	versions, err := p.FetchVersions(domain.Dependency{Name: "example.com/lib"})
+	if err != nil {
+		t.Fatalf("FetchVersions: %v", err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(versions))
+	}
+
+	latest, ok := versions.Latest(nil)
+	if !ok || latest.Version.String() != "v1.1.0" {
+		t.Errorf("expected latest release v1.1.0, got %s", latest.Version.String())
+	}
+}
+
+func TestGoProxyPrivateModules(t *testing.T) {
+	files := map[string]string{
+		"/corp.example.com/svc/@v/list": "v0.1.0\nv0.2.0\n",
+	}
+
+	public := newProxyServer(t, "", files)
+	private := newProxyServer(t, "secret", files)
+
+	p := source.NewGoProxy(source.GoProxyConfig{
+		GoProxy:      public.URL,
+		GoPrivate:    "corp.example.com",
+		PrivateProxy: private.URL,
+	})
+
+	if _, err := p.List("corp.example.com/svc"); err == nil {
+		t.Fatal("expected private proxy to reject unauthenticated request")
+	}
+
+	p = source.NewGoProxy(source.GoProxyConfig{
+		GoProxy:      public.URL,
+		GoPrivate:    "corp.example.com",
+		PrivateProxy: private.URL,
+		Auth:         []source.ProxyAuth{{Host: private.Listener.Addr().String(), Token: "secret"}},
+	})
+
+	versions, err := p.List("corp.example.com/svc")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if len(versions) != 2 {
+		t.Errorf("expected 2 versions, got %v", versions)
+	}
+}
+
+func TestGoProxyFileCache(t *testing.T) {
+	dir := t.TempDir()
+	// Upper case letters are escaped with ! in module cache paths
+	modDir := filepath.Join(dir, "github.com", "!example", "lib", "@v")
+
+	if err := os.MkdirAll(modDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(modDir, "list"), []byte("v1.0.0\nv1.4.2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(modDir, "v1.4.2.info"), []byte(`{"Version":"v1.4.2"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := source.NewGoProxy(source.GoProxyConfig{GoProxy: "file://" + dir})
+
+	info, err := p.Latest("github.com/Example/lib")
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+
+	if info.Version != "v1.4.2" {
+		t.Errorf("expected v1.4.2, got %s", info.Version)
+	}
+}
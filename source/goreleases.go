@@ -0,0 +1,161 @@
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const defaultGoReleases = "https://go.dev/dl/?mode=json&include=all"
+
+// Matches Go release versions such as 1.21, 1.21.5 and 1.22rc1, with or
+// without the go prefix used by toolchain names
+var goReleaseRe = regexp.MustCompile(`^(?:go)?([1-9][0-9]*)\.(0|[1-9][0-9]*)(?:\.(0|[1-9][0-9]*))?(?:(alpha|beta|rc)([0-9]+))?$`)
+
+// Entry of the go.dev/dl JSON release list
+type goRelease struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// Releases are read from url, which defaults to the go.dev download page.
+// file:// URLs are read from disk, allowing a local mirror to be used.
+func NewGoReleases(url string) *GoReleases {
+	if url == "" {
+		url = defaultGoReleases
+	}
+
+	return &GoReleases{
+		url:    url,
+		client: &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Source of Go toolchain releases using the go.dev/dl JSON format
+//
+// The list does not depend on the dependency being looked up.
+type GoReleases struct {
+	url    string
+	client *http.Client
+}
+
+func (*GoReleases) GetName() string {
+	return "GoReleases"
+}
+
+func (r *GoReleases) FetchVersions(domain.Dependency) (domain.Versions, error) {
+	body, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []goRelease
+
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return nil, fmt.Errorf("invalid Go release list: %w", err)
+	}
+
+	versions := make(domain.Versions, 0, len(releases))
+
+	for _, rel := range releases {
+		v, err := ParseGoVersion(rel.Version)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{
+			Version:    v,
+			Prerelease: !rel.Stable || v.Prerelease(),
+		})
+	}
+
+	return versions, nil
+}
+
+func (r *GoReleases) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	versions, err := r.FetchVersions(dep)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	for _, v := range versions {
+		if v.Version.Equal(version) {
+			return v, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("go%s not found in release list", GoReleaseName(version))
+}
+
+func (r *GoReleases) read() ([]byte, error) {
+	if path, ok := strings.CutPrefix(r.url, "file://"); ok {
+		return os.ReadFile(path)
+	}
+
+	resp, err := r.client.Get(r.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", r.url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxProxyResponse))
+}
+
+// Version scheme of Go releases and toolchain names, i.e. 1.21, 1.21.5,
+// 1.22rc1 or go1.22.0
+//
+// Releases without a patch number are treated as the first release of the
+// minor and pre-releases such as 1.22rc1 order before it. Versions are
+// ordered as their semantic version equivalent, so semantic versioning
+// constraints apply to them.
+var GoRelease domain.VersionScheme = goReleaseScheme{}
+
+func init() {
+	domain.RegisterVersionScheme(GoRelease)
+}
+
+type goReleaseScheme struct{}
+
+func (goReleaseScheme) GetName() string {
+	return "go"
+}
+
+func (goReleaseScheme) Parse(raw string) (domain.ParsedVersion, error) {
+	m := goReleaseRe.FindStringSubmatch(raw)
+	if m == nil {
+		return nil, errors.New("invalid Go version " + raw)
+	}
+
+	patch := m[3]
+	if patch == "" {
+		patch = "0"
+	}
+
+	v := m[1] + "." + m[2] + "." + patch
+	if m[4] != "" {
+		v += "-" + m[4] + "." + m[5]
+	}
+
+	return domain.Semver.Parse(v)
+}
+
+// Parse a Go release version or toolchain name, keeping it as written
+func ParseGoVersion(s string) (domain.Version, error) {
+	return domain.ParseVersion(GoRelease, s)
+}
+
+// Get a Go version as used by go directives, i.e. 1.22.3 for go1.22.3
+func GoReleaseName(v domain.Version) string {
+	return strings.TrimPrefix(v.String(), "go")
+}
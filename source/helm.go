@@ -0,0 +1,229 @@
+package source
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Maximum size of a chart repository index. Indexes of large repositories
+// list every version of hundreds of charts.
+const maxHelmIndex = 64 << 20
+
+const ociScheme = "oci://"
+
+type HelmRepositoryConfig struct {
+	Username string
+	Password string
+}
+
+// Index of an HTTP chart repository
+type helmIndex struct {
+	Entries map[string][]helmChartVersion `yaml:"entries"`
+}
+
+type helmChartVersion struct {
+	Version    string    `yaml:"version"`
+	Created    time.Time `yaml:"created"`
+	Deprecated bool      `yaml:"deprecated"`
+}
+
+// Split a chart reference of the form <repository>/<chart> into the
+// repository URL and the chart name
+func SplitHelmChart(ref string) (repository string, chart string) {
+	i := strings.LastIndex(ref, "/")
+	if i < 0 {
+		return "", ref
+	}
+
+	return ref[:i], ref[i+1:]
+}
+
+func NewHelmRepositories(repositories map[string]HelmRepositoryConfig, registry *OCIRegistry) *HelmRepositories {
+	return &HelmRepositories{
+		repositories: repositories,
+		registry:     registry,
+		indexes:      make(map[string]*helmIndex),
+		client:       &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for Helm chart repositories
+//
+// Charts are referred to by their repository URL followed by their name,
+// i.e. https://charts.bitnami.com/bitnami/redis. HTTP repositories are read
+// through their index.yaml, while charts stored in OCI registries, referred
+// to by oci:// URLs, are listed by their tags.
+type HelmRepositories struct {
+	repositories map[string]HelmRepositoryConfig // Credentials keyed by repository host
+	registry     *OCIRegistry
+	mu           sync.Mutex
+	indexes      map[string]*helmIndex // Indexes already fetched by repository URL
+	client       *http.Client
+}
+
+func (*HelmRepositories) GetName() string {
+	return "HelmRepositories"
+}
+
+func (h *HelmRepositories) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	repository, chart := SplitHelmChart(dep.Name)
+
+	if strings.HasPrefix(repository, ociScheme) {
+		return h.ociVersions(strings.TrimPrefix(dep.Name, ociScheme))
+	}
+
+	entries, err := h.entries(repository, chart)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(entries))
+
+	for _, e := range entries {
+		v, err := domain.ParseVersion(domain.Semver, e.Version)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{
+			Version:     v,
+			PublishedAt: e.Created,
+			Prerelease:  v.Prerelease(),
+		})
+	}
+
+	return versions, nil
+}
+
+// OCI tags can not contain +, which Helm replaces with _ when pushing charts
+func (h *HelmRepositories) ociVersions(name string) (domain.Versions, error) {
+	tags, err := h.registry.Tags(name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(tags))
+
+	for _, tag := range tags {
+		v, err := domain.ParseVersion(domain.Semver, strings.ReplaceAll(tag, "_", "+"))
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{Version: v, Prerelease: v.Prerelease()})
+	}
+
+	return versions, nil
+}
+
+func (h *HelmRepositories) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	versions, err := h.FetchVersions(dep)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	for _, v := range versions {
+		if v.Version.Equal(version) {
+			return v, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("%s %s not found in repository", dep.Name, version.String())
+}
+
+// Whether the newest version of a chart in an HTTP repository is marked
+// deprecated, which is how charts are deprecated. Charts stored in OCI
+// registries can not be deprecated.
+func (h *HelmRepositories) Deprecated(ref string) (bool, error) {
+	repository, chart := SplitHelmChart(ref)
+	if strings.HasPrefix(repository, ociScheme) {
+		return false, nil
+	}
+
+	entries, err := h.entries(repository, chart)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		newest     domain.Version
+		deprecated bool
+	)
+
+	for _, e := range entries {
+		v, err := domain.ParseVersion(domain.Semver, e.Version)
+		if err == nil && v.GreaterThan(newest) {
+			newest, deprecated = v, e.Deprecated
+		}
+	}
+
+	return deprecated, nil
+}
+
+// Get the versions of a chart listed by the index of an HTTP repository
+func (h *HelmRepositories) entries(repository string, chart string) ([]helmChartVersion, error) {
+	index, err := h.index(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := index.Entries[chart]
+	if !ok {
+		return nil, fmt.Errorf("chart %s not found in %s", chart, repository)
+	}
+
+	return entries, nil
+}
+
+// Fetch the index of a repository once, as every chart of the repository
+// shares it
+func (h *HelmRepositories) index(repository string) (*helmIndex, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if index, ok := h.indexes[repository]; ok {
+		return index, nil
+	}
+
+	target, err := url.Parse(strings.TrimSuffix(repository, "/") + "/index.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if config, ok := h.repositories[target.Host]; ok {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target.Redacted(), resp.Status)
+	}
+
+	var index helmIndex
+
+	if err := yaml.NewDecoder(io.LimitReader(resp.Body, maxHelmIndex)).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid index %s: %w", target.Redacted(), err)
+	}
+
+	h.indexes[repository] = &index
+
+	return &index, nil
+}
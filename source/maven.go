@@ -0,0 +1,144 @@
+package source
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const defaultMavenRepository = "https://repo.maven.apache.org/maven2"
+
+type MavenRepositoryConfig struct {
+	URL      string // Repository base URL. Defaults to Maven Central
+	Username string
+	Password string
+}
+
+// Metadata of an artifact listing its versions, as published next to them
+type mavenMetadata struct {
+	Versioning struct {
+		Versions []string `xml:"versions>version"`
+	} `xml:"versioning"`
+}
+
+// Split a dependency name of the form <groupId>:<artifactId>
+func SplitMavenArtifact(name string) (group string, artifact string, err error) {
+	group, artifact, ok := strings.Cut(name, ":")
+	if !ok || group == "" || artifact == "" || strings.Contains(artifact, ":") {
+		return "", "", fmt.Errorf("invalid Maven artifact %q", name)
+	}
+
+	return group, artifact, nil
+}
+
+func NewMavenRepository(config MavenRepositoryConfig) *MavenRepository {
+	base := config.URL
+	if base == "" {
+		base = defaultMavenRepository
+	}
+
+	return &MavenRepository{
+		url:      strings.TrimSuffix(base, "/"),
+		username: config.Username,
+		password: config.Password,
+		client:   &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for Maven repositories
+//
+// Dependencies are named <groupId>:<artifactId> and their versions are read
+// from the artifact's maven-metadata.xml. The metadata carries no publication
+// dates of individual versions.
+type MavenRepository struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+func (*MavenRepository) GetName() string {
+	return "MavenRepository"
+}
+
+func (m *MavenRepository) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	metadata, err := m.metadata(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(metadata.Versioning.Versions))
+
+	for _, raw := range metadata.Versioning.Versions {
+		v, err := domain.ParseVersion(Maven, strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{Version: v, Prerelease: v.Prerelease()})
+	}
+
+	return versions, nil
+}
+
+func (m *MavenRepository) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	versions, err := m.FetchVersions(dep)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	for _, v := range versions {
+		if v.Version.Equal(version) {
+			return v, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("%s %s not found in repository", dep.Name, version.String())
+}
+
+func (m *MavenRepository) metadata(name string) (mavenMetadata, error) {
+	group, artifact, err := SplitMavenArtifact(name)
+	if err != nil {
+		return mavenMetadata{}, err
+	}
+
+	base, err := url.Parse(m.url)
+	if err != nil {
+		return mavenMetadata{}, err
+	}
+
+	segments := append(strings.Split(group, "."), artifact, "maven-metadata.xml")
+	target := base.JoinPath(segments...)
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return mavenMetadata{}, err
+	}
+
+	if m.username != "" || m.password != "" {
+		req.SetBasicAuth(m.username, m.password)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return mavenMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return mavenMetadata{}, fmt.Errorf("GET %s: %s", target.Redacted(), resp.Status)
+	}
+
+	var metadata mavenMetadata
+
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(&metadata); err != nil {
+		return mavenMetadata{}, fmt.Errorf("invalid metadata %s: %w", target.Redacted(), err)
+	}
+
+	return metadata, nil
+}
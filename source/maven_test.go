@@ -0,0 +1,126 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func TestMavenOrdering(t *testing.T) {
+	// Ascending, as sorted by Maven's ComparableVersion
+	ordered := []string{
+		"1-alpha-1",
+		"1-alpha2",
+		"1-beta",
+		"1-M1",
+		"1-rc1",
+		"1-SNAPSHOT",
+		"1",
+		"1-sp",
+		"1-abc",
+		"1-jre",
+		"1.0.1",
+		"1.1",
+		"1.10",
+		"2.0.0.Beta1",
+		"2.0.0.CR2",
+		"2.0.0.Final",
+		"2.0.0.1",
+		"10",
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		a := domain.MustParseVersion(source.Maven, ordered[i-1])
+		b := domain.MustParseVersion(source.Maven, ordered[i])
+
+		if !a.LessThan(b) || !b.GreaterThan(a) {
+			t.Errorf("expected %s < %s", a, b)
+		}
+	}
+
+	for _, pair := range [][2]string{{"1", "1.0.0"}, {"1.0", "1-ga"}, {"1.0.Final", "1.0.RELEASE"}, {"1-cr1", "1-rc1"}} {
+		a := domain.MustParseVersion(source.Maven, pair[0])
+		b := domain.MustParseVersion(source.Maven, pair[1])
+
+		if !a.Equal(b) {
+			t.Errorf("expected %s to equal %s", a, b)
+		}
+	}
+
+	for raw, prerelease := range map[string]bool{
+		"2.0.0-M3":       true,
+		"1.0-SNAPSHOT":   true,
+		"3.0.0-RC1":      true,
+		"33.0.0-jre":     false,
+		"5.3.30.RELEASE": false,
+	} {
+		if v := domain.MustParseVersion(source.Maven, raw); v.Prerelease() != prerelease {
+			t.Errorf("expected pre-release of %s to be %v", raw, prerelease)
+		}
+	}
+
+	if !source.SameMavenVariant(
+		domain.MustParseVersion(source.Maven, "32.1.3-jre"),
+		domain.MustParseVersion(source.Maven, "33.0.0-jre"),
+	) {
+		t.Error("expected -jre versions to share their variant")
+	}
+
+	if source.SameMavenVariant(
+		domain.MustParseVersion(source.Maven, "32.1.3-jre"),
+		domain.MustParseVersion(source.Maven, "33.0.0-android"),
+	) {
+		t.Error("expected -jre and -android versions to differ")
+	}
+}
+
+func TestMavenRepository(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/maven2/com/google/guava/guava/maven-metadata.xml" || user != "reader" || pass != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.google.guava</groupId>
+  <artifactId>guava</artifactId>
+  <versioning>
+    <latest>33.0.0-jre</latest>
+    <release>33.0.0-jre</release>
+    <versions>
+      <version>32.1.3-jre</version>
+      <version>33.0.0-android</version>
+      <version>33.0.0-jre</version>
+    </versions>
+    <lastUpdated>20231218190452</lastUpdated>
+  </versioning>
+</metadata>`))
+	}))
+	defer srv.Close()
+
+	repo := source.NewMavenRepository(source.MavenRepositoryConfig{URL: srv.URL + "/maven2/", Username: "reader", Password: "secret"})
+
+	dep := domain.Dependency{Name: "com.google.guava:guava", Version: domain.MustParseVersion(source.Maven, "32.1.3-jre")}
+
+	versions, err := repo.FetchVersions(dep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(versions))
+	}
+
+	if _, err := repo.FetchVersion(dep, domain.MustParseVersion(source.Maven, "33.0.0-jre")); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := repo.FetchVersions(domain.Dependency{Name: "guava"}); err == nil {
+		t.Error("expected a name without a group to be rejected")
+	}
+}
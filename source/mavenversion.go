@@ -0,0 +1,347 @@
+package source
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Qualifiers in the order Maven ranks them. The empty qualifier stands for a
+// release, which ga, final and release are aliases of.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenQualifierAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// Index of the empty qualifier in mavenQualifiers. Qualifiers ranked below
+// it mark pre-releases.
+const mavenReleaseRank = 5
+
+// Version scheme of Maven artifacts
+//
+// Versions are ordered like Maven's ComparableVersion: they are split into
+// numeric and textual items at dots, hyphens and transitions between digits
+// and letters. Known qualifiers such as alpha, rc or sp are ranked relative
+// to a release, while unknown ones order after it, alphabetically.
+var Maven domain.VersionScheme = mavenScheme{}
+
+func init() {
+	domain.RegisterVersionScheme(Maven)
+}
+
+type mavenScheme struct{}
+
+func (mavenScheme) GetName() string {
+	return "maven"
+}
+
+type mavenItemKind int
+
+const (
+	mavenInt mavenItemKind = iota
+	mavenString
+	mavenList
+)
+
+// Item of a Maven version. Sub-lists start at each hyphen and at each
+// transition between digits and letters.
+type mavenItem struct {
+	kind   mavenItemKind
+	digits string // Number without leading zeros, empty for zero
+	value  string // Qualifier with its aliases resolved
+	items  []*mavenItem
+}
+
+type mavenVersion struct {
+	items []*mavenItem
+}
+
+func (mavenScheme) Parse(raw string) (domain.ParsedVersion, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return nil, errors.New("empty Maven version")
+	}
+
+	root := &mavenItem{kind: mavenList}
+	list := root
+	stack := []*mavenItem{root}
+
+	// Start a sub-list within the current list
+	sublist := func() {
+		l := &mavenItem{kind: mavenList}
+		list.items = append(list.items, l)
+		list = l
+		stack = append(stack, l)
+	}
+
+	digit := false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				list.items = append(list.items, &mavenItem{kind: mavenInt})
+			} else {
+				list.items = append(list.items, parseMavenItem(digit, s[start:i], false))
+			}
+
+			start = i + 1
+
+			if c == '-' {
+				sublist()
+			}
+		case c >= '0' && c <= '9':
+			if !digit && i > start {
+				// A qualifier directly followed by a number, i.e. rc1
+				list.items = append(list.items, parseMavenItem(false, s[start:i], true))
+				start = i
+
+				sublist()
+			}
+
+			digit = true
+		default:
+			if digit && i > start {
+				list.items = append(list.items, parseMavenItem(true, s[start:i], false))
+				start = i
+
+				sublist()
+			}
+
+			digit = false
+		}
+	}
+
+	if len(s) > start {
+		list.items = append(list.items, parseMavenItem(digit, s[start:], false))
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+
+	return mavenVersion{items: root.items}, nil
+}
+
+func parseMavenItem(digit bool, s string, followedByDigit bool) *mavenItem {
+	if digit {
+		return &mavenItem{kind: mavenInt, digits: strings.TrimLeft(s, "0")}
+	}
+
+	// Single letters are short for a qualifier when a number follows, i.e. m1
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+
+	if alias, ok := mavenQualifierAliases[s]; ok {
+		s = alias
+	}
+
+	return &mavenItem{kind: mavenString, value: s}
+}
+
+// Drop trailing items equivalent to nothing, so 1.0.0 equals 1 and 1-ga
+// equals 1
+func (m *mavenItem) normalize() {
+	for i := len(m.items) - 1; i >= 0; i-- {
+		item := m.items[i]
+
+		if item.isNull() {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+		} else if item.kind != mavenList {
+			break
+		}
+	}
+}
+
+func (m *mavenItem) isNull() bool {
+	switch m.kind {
+	case mavenInt:
+		return m.digits == ""
+	case mavenString:
+		return m.value == ""
+	default:
+		return len(m.items) == 0
+	}
+}
+
+// Rank of a qualifier. Unknown qualifiers rank after every known one and
+// among themselves alphabetically.
+func qualifierRank(q string) string {
+	for i, known := range mavenQualifiers {
+		if q == known {
+			return strconv.Itoa(i)
+		}
+	}
+
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + q
+}
+
+// Order m relative to o, which is nil for a missing item
+func (m *mavenItem) compare(o *mavenItem) int {
+	switch m.kind {
+	case mavenInt:
+		if o == nil {
+			if m.digits == "" {
+				return 0
+			}
+
+			return 1
+		}
+
+		if o.kind != mavenInt {
+			return 1
+		}
+
+		if len(m.digits) != len(o.digits) {
+			return compareUint(uint64(len(m.digits)), uint64(len(o.digits)))
+		}
+
+		return strings.Compare(m.digits, o.digits)
+	case mavenString:
+		if o == nil {
+			return strings.Compare(qualifierRank(m.value), strconv.Itoa(mavenReleaseRank))
+		}
+
+		if o.kind != mavenString {
+			return -1
+		}
+
+		return strings.Compare(qualifierRank(m.value), qualifierRank(o.value))
+	default:
+		if o == nil {
+			if len(m.items) == 0 {
+				return 0
+			}
+
+			return m.items[0].compare(nil)
+		}
+
+		switch o.kind {
+		case mavenInt:
+			return -1
+		case mavenString:
+			return 1
+		}
+
+		return compareMavenItems(m.items, o.items)
+	}
+}
+
+func compareMavenItems(a, b []*mavenItem) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var c int
+
+		switch {
+		case i >= len(a):
+			c = -b[i].compare(nil)
+		case i >= len(b):
+			c = a[i].compare(nil)
+		default:
+			c = a[i].compare(b[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+func (v mavenVersion) Compare(other domain.ParsedVersion) int {
+	return compareMavenItems(v.items, other.(mavenVersion).items)
+}
+
+// Versions qualified as alpha, beta, milestone, release candidate or
+// snapshot are pre-releases
+func (v mavenVersion) Prerelease() bool {
+	prerelease := false
+
+	v.walk(func(m *mavenItem) {
+		if m.kind == mavenString && m.value != "" {
+			if rank, err := strconv.Atoi(qualifierRank(m.value)); err == nil && rank < mavenReleaseRank {
+				prerelease = true
+			}
+		}
+	})
+
+	return prerelease
+}
+
+// Leading numeric items, i.e. [33 0 0] for 33.0.0-jre
+func (v mavenVersion) Release() []uint64 {
+	release := make([]uint64, 0)
+
+	for _, m := range v.items {
+		if m.kind != mavenInt {
+			break
+		}
+
+		n, err := strconv.ParseUint("0"+m.digits, 10, 64)
+		if err != nil {
+			break
+		}
+
+		release = append(release, n)
+	}
+
+	return release
+}
+
+func (v mavenVersion) walk(fn func(*mavenItem)) {
+	var visit func(items []*mavenItem)
+
+	visit = func(items []*mavenItem) {
+		for _, m := range items {
+			fn(m)
+			visit(m.items)
+		}
+	}
+
+	visit(v.items)
+}
+
+// Whether two Maven versions are of the same variant of an artifact, i.e.
+// both -jre or both -android builds of Guava. Variants are the qualifiers
+// Maven does not know, which are not part of the version's release line.
+func SameMavenVariant(a, b domain.Version) bool {
+	return mavenVariant(a) == mavenVariant(b)
+}
+
+func mavenVariant(v domain.Version) string {
+	parsed, err := Maven.Parse(v.String())
+	if err != nil {
+		return v.String()
+	}
+
+	variant := make([]string, 0)
+
+	parsed.(mavenVersion).walk(func(m *mavenItem) {
+		if m.kind != mavenString {
+			return
+		}
+
+		if _, err := strconv.Atoi(qualifierRank(m.value)); err != nil {
+			variant = append(variant, m.value)
+		}
+	})
+
+	return strings.Join(variant, "-")
+}
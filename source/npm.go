@@ -0,0 +1,203 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const defaultNpmRegistry = "https://registry.npmjs.org"
+
+// Maximum size of a registry response. Package documents of popular packages
+// list thousands of versions and run to tens of megabytes.
+const maxNpmResponse = 128 << 20
+
+type NpmRegistryConfig struct {
+	URL   string // Registry base URL. Defaults to registry.npmjs.org
+	Token string // Bearer token sent to the registry, if it requires authentication
+}
+
+// Published manifest of a single package version
+type NpmManifest struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Deprecated   string            `json:"deprecated"`
+	License      json.RawMessage   `json:"license"` // Either an SPDX expression or a legacy {"type": ...} object
+	Dependencies map[string]string `json:"dependencies"`
+	Dist         NpmDist           `json:"dist"`
+}
+
+// Location and integrity of a package tarball
+type NpmDist struct {
+	Tarball   string `json:"tarball"`
+	Integrity string `json:"integrity"`
+	Shasum    string `json:"shasum"`
+}
+
+// Package document listing every published version
+type npmPackument struct {
+	DistTags map[string]string      `json:"dist-tags"`
+	Versions map[string]NpmManifest `json:"versions"`
+	Time     map[string]time.Time   `json:"time"`
+}
+
+func NewNpmRegistry(config NpmRegistryConfig) *NpmRegistry {
+	base := config.URL
+	if base == "" {
+		base = defaultNpmRegistry
+	}
+
+	return &NpmRegistry{
+		url:    strings.TrimSuffix(base, "/"),
+		token:  config.Token,
+		client: &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for the npm registry API
+//
+// Versions deprecated by their maintainers are excluded so they are never
+// proposed, matching how npm itself avoids them when resolving ranges.
+type NpmRegistry struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (*NpmRegistry) GetName() string {
+	return "NpmRegistry"
+}
+
+func (n *NpmRegistry) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	doc, err := n.packument(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(doc.Versions))
+
+	for raw, m := range doc.Versions {
+		v, err := domain.ParseVersion(domain.Semver, raw)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, npmVersionInfo(v, m, doc.Time[raw]))
+	}
+
+	return versions, nil
+}
+
+func (n *NpmRegistry) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	m, err := n.Manifest(dep.Name, version.String())
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	return npmVersionInfo(version, m, time.Time{}), nil
+}
+
+func npmVersionInfo(v domain.Version, m NpmManifest, published time.Time) domain.VersionInfo {
+	info := domain.VersionInfo{
+		Version:     v,
+		PublishedAt: published,
+		Prerelease:  v.Prerelease(),
+		Licenses:    npmLicenses(m.License),
+	}
+
+	if m.Deprecated != "" {
+		info.Excluded = "deprecated: " + m.Deprecated
+	}
+
+	return info
+}
+
+func npmLicenses(raw json.RawMessage) []string {
+	var expr string
+	if json.Unmarshal(raw, &expr) == nil && expr != "" {
+		return []string{expr}
+	}
+
+	var legacy struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(raw, &legacy) == nil && legacy.Type != "" {
+		return []string{legacy.Type}
+	}
+
+	return nil
+}
+
+// Get the version the `latest` dist-tag points at
+func (n *NpmRegistry) Latest(name string) (NpmManifest, error) {
+	doc, err := n.packument(name)
+	if err != nil {
+		return NpmManifest{}, err
+	}
+
+	latest, ok := doc.Versions[doc.DistTags["latest"]]
+	if !ok {
+		return NpmManifest{}, fmt.Errorf("%s has no latest version", name)
+	}
+
+	return latest, nil
+}
+
+// Get the published manifest of a single version
+func (n *NpmRegistry) Manifest(name string, version string) (NpmManifest, error) {
+	var m NpmManifest
+
+	err := n.get(escapeNpmName(name)+"/"+url.PathEscape(version), &m)
+
+	return m, err
+}
+
+func (n *NpmRegistry) packument(name string) (npmPackument, error) {
+	var doc npmPackument
+
+	err := n.get(escapeNpmName(name), &doc)
+
+	return doc, err
+}
+
+// The slash separating the scope of a scoped package is escaped, as the
+// registry expects i.e. `@types%2Fnode`
+func escapeNpmName(name string) string {
+	return url.PathEscape(name)
+}
+
+func (n *NpmRegistry) get(rel string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, n.url+"/"+rel, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxNpmResponse)).Decode(v)
+	if err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Redacted(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/geektype/dependy/domain"
+)
+
+// Matches PEP 440 versions, accepting every spelling the specification
+// normalises
+var pep440Re = regexp.MustCompile(`(?i)^v?(?:([0-9]+)!)?([0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|rc|c|pre|preview)[-_.]?([0-9]*))?` +
+	`(?:-([0-9]+)|[-_.]?(post|rev|r)[-_.]?([0-9]*))?` +
+	`(?:[-_.]?(dev)[-_.]?([0-9]*))?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// Matches runs of characters PEP 503 treats as equivalent in project names
+var pypiNameSepRe = regexp.MustCompile(`[-_.]+`)
+
+// Normalise a Python project name as described by PEP 503
+func NormalizePyPIName(name string) string {
+	return strings.ToLower(pypiNameSepRe.ReplaceAllString(name, "-"))
+}
+
+// Version scheme of Python packages, as specified by PEP 440
+var PEP440 domain.VersionScheme = pep440Scheme{}
+
+func init() {
+	domain.RegisterVersionScheme(PEP440)
+}
+
+type pep440Scheme struct{}
+
+func (pep440Scheme) GetName() string {
+	return "pep440"
+}
+
+// Ranks of pre-release labels, every spelling normalised
+var pep440PreRanks = map[string]int{
+	"a": 0, "alpha": 0,
+	"b": 1, "beta": 1,
+	"rc": 2, "c": 2, "pre": 2, "preview": 2,
+}
+
+func (pep440Scheme) Parse(raw string) (domain.ParsedVersion, error) {
+	m := pep440Re.FindStringSubmatch(strings.TrimSpace(raw))
+	if m == nil {
+		return nil, fmt.Errorf("invalid PEP 440 version %q", raw)
+	}
+
+	var (
+		v   pep440Version
+		err error
+	)
+
+	if m[1] != "" {
+		if v.epoch, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range strings.Split(m[2], ".") {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		v.release = append(v.release, n)
+	}
+
+	if m[3] != "" {
+		v.pre = &pep440Part{rank: pep440PreRanks[strings.ToLower(m[3])], n: atou(m[4])}
+	}
+
+	if m[5] != "" || m[6] != "" {
+		v.post = &pep440Part{n: atou(m[5] + m[7])}
+	}
+
+	if m[8] != "" {
+		v.dev = &pep440Part{n: atou(m[9])}
+	}
+
+	if m[10] != "" {
+		v.local = strings.FieldsFunc(strings.ToLower(m[10]), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return v, nil
+}
+
+// Implicit numbers of pre, post and development releases are zero
+func atou(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+type pep440Part struct {
+	rank int // Pre-release label: 0 for alpha, 1 for beta and 2 for release candidates
+	n    uint64
+}
+
+type pep440Version struct {
+	epoch   uint64
+	release []uint64
+	pre     *pep440Part
+	post    *pep440Part
+	dev     *pep440Part
+	local   []string
+}
+
+func (v pep440Version) Prerelease() bool {
+	return v.pre != nil || v.dev != nil
+}
+
+func (v pep440Version) Release() []uint64 {
+	return v.release
+}
+
+// Ordering follows the reference implementation of the packaging library
+func (v pep440Version) Compare(other domain.ParsedVersion) int {
+	o := other.(pep440Version)
+
+	if c := compareUint(v.epoch, o.epoch); c != 0 {
+		return c
+	}
+
+	// Trailing zeros are insignificant, 1.0 equals 1.0.0
+	for i := 0; i < len(v.release) || i < len(o.release); i++ {
+		if c := compareUint(segmentAt(v.release, i), segmentAt(o.release, i)); c != 0 {
+			return c
+		}
+	}
+
+	if c := comparePart(v.preKey(), o.preKey()); c != 0 {
+		return c
+	}
+
+	// A missing post release orders before any post release while a missing
+	// development release orders after any development release
+	if c := comparePart(partKey(v.post, -1), partKey(o.post, -1)); c != 0 {
+		return c
+	}
+
+	if c := comparePart(partKey(v.dev, 1), partKey(o.dev, 1)); c != 0 {
+		return c
+	}
+
+	return compareLocal(v.local, o.local)
+}
+
+// Key of a part which orders missing parts at the given extreme
+type orderKey struct {
+	bound int // -1 or 1 for a missing part, 0 when present
+	rank  int
+	n     uint64
+}
+
+// Development releases without a pre or post release order before every
+// pre-release of the same release, a final release after all of them
+func (v pep440Version) preKey() orderKey {
+	switch {
+	case v.pre != nil:
+		return orderKey{rank: v.pre.rank, n: v.pre.n}
+	case v.post == nil && v.dev != nil:
+		return orderKey{bound: -1}
+	default:
+		return orderKey{bound: 1}
+	}
+}
+
+func partKey(p *pep440Part, missing int) orderKey {
+	if p == nil {
+		return orderKey{bound: missing}
+	}
+
+	return orderKey{n: p.n}
+}
+
+func comparePart(a, b orderKey) int {
+	if a.bound != b.bound {
+		if a.bound < b.bound {
+			return -1
+		}
+
+		return 1
+	}
+
+	if a.rank != b.rank {
+		if a.rank < b.rank {
+			return -1
+		}
+
+		return 1
+	}
+
+	return compareUint(a.n, b.n)
+}
+
+// Numeric segments of a local label order after alphanumeric ones
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aErr := strconv.ParseUint(a[i], 10, 64)
+		bn, bErr := strconv.ParseUint(b[i], 10, 64)
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareUint(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return 1
+		case bErr == nil:
+			return -1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+func segmentAt(release []uint64, i int) uint64 {
+	if i < len(release) {
+		return release[i]
+	}
+
+	return 0
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,273 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const (
+	defaultPyPIJSON   = "https://pypi.org/pypi"
+	defaultPyPISimple = "https://pypi.org/simple"
+)
+
+type PyPIConfig struct {
+	URL      string // Index base URL. Defaults to pypi.org for the chosen API
+	Simple   bool   // Use the simple repository API (PEP 691), as served by most private indexes, rather than the PyPI JSON API
+	Username string
+	Password string
+}
+
+// Response of the PyPI JSON API for a project or a single release
+type pypiProject struct {
+	Info struct {
+		License           string `json:"license"`
+		LicenseExpression string `json:"license_expression"`
+	} `json:"info"`
+	Releases map[string][]pypiFile `json:"releases"`
+}
+
+type pypiFile struct {
+	Filename   string          `json:"filename"`
+	UploadTime time.Time       `json:"upload_time_iso_8601"`
+	Yanked     json.RawMessage `json:"yanked"` // A boolean, or the reason in the simple API
+	Uploaded   time.Time       `json:"upload-time"`
+}
+
+// Response of the simple repository API for a project
+type pypiSimpleProject struct {
+	Versions []string   `json:"versions"` // Only served by indexes implementing PEP 700
+	Files    []pypiFile `json:"files"`
+}
+
+func NewPyPI(config PyPIConfig) *PyPI {
+	base := config.URL
+	if base == "" {
+		base = defaultPyPIJSON
+		if config.Simple {
+			base = defaultPyPISimple
+		}
+	}
+
+	return &PyPI{
+		url:      strings.TrimSuffix(base, "/"),
+		simple:   config.Simple,
+		username: config.Username,
+		password: config.Password,
+		client:   &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for Python package indexes
+//
+// Versions follow PEP 440. A version is retracted when every one of its files
+// has been yanked. Only the JSON API provides licenses.
+type PyPI struct {
+	url      string
+	simple   bool
+	username string
+	password string
+	client   *http.Client
+}
+
+func (*PyPI) GetName() string {
+	return "PyPI"
+}
+
+func (p *PyPI) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	releases, err := p.releases(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(releases))
+
+	for raw, files := range releases {
+		v, err := domain.ParseVersion(PEP440, raw)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, pypiVersionInfo(v, files))
+	}
+
+	return versions, nil
+}
+
+func (p *PyPI) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	releases, err := p.releases(dep.Name)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	// Releases are looked up by the version string they were published as
+	for raw, files := range releases {
+		v, err := domain.ParseVersion(PEP440, raw)
+		if err != nil || !v.Equal(version) {
+			continue
+		}
+
+		info := pypiVersionInfo(v, files)
+
+		if !p.simple {
+			var release pypiProject
+
+			err := p.get(url.PathEscape(dep.Name)+"/"+url.PathEscape(raw)+"/json", &release)
+			if err != nil {
+				return domain.VersionInfo{}, err
+			}
+
+			info.Licenses = pypiLicenses(release)
+		}
+
+		return info, nil
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("%s %s not found in index", dep.Name, version.String())
+}
+
+func pypiVersionInfo(v domain.Version, files []pypiFile) domain.VersionInfo {
+	info := domain.VersionInfo{
+		Version:    v,
+		Prerelease: v.Prerelease(),
+		Retracted:  len(files) > 0,
+	}
+
+	for _, f := range files {
+		uploaded := f.UploadTime
+		if uploaded.IsZero() {
+			uploaded = f.Uploaded
+		}
+
+		if !uploaded.IsZero() && (info.PublishedAt.IsZero() || uploaded.Before(info.PublishedAt)) {
+			info.PublishedAt = uploaded
+		}
+
+		if !f.yanked() {
+			info.Retracted = false
+		}
+	}
+
+	return info
+}
+
+func (f pypiFile) yanked() bool {
+	var yanked bool
+	if json.Unmarshal(f.Yanked, &yanked) == nil {
+		return yanked
+	}
+
+	var reason string
+
+	return json.Unmarshal(f.Yanked, &reason) == nil
+}
+
+// Licenses are only reported when given as an SPDX expression, either by the
+// PEP 639 field or a single line legacy license field
+func pypiLicenses(release pypiProject) []string {
+	if release.Info.LicenseExpression != "" {
+		return []string{release.Info.LicenseExpression}
+	}
+
+	if l := strings.TrimSpace(release.Info.License); l != "" && !strings.ContainsAny(l, "\n ") {
+		return []string{l}
+	}
+
+	return nil
+}
+
+// Get the files of every release of a project, keyed by version
+func (p *PyPI) releases(name string) (map[string][]pypiFile, error) {
+	if !p.simple {
+		var project pypiProject
+
+		err := p.get(url.PathEscape(name)+"/json", &project)
+
+		return project.Releases, err
+	}
+
+	var project pypiSimpleProject
+
+	if err := p.get(url.PathEscape(NormalizePyPIName(name))+"/", &project); err != nil {
+		return nil, err
+	}
+
+	releases := make(map[string][]pypiFile, len(project.Versions))
+	for _, v := range project.Versions {
+		releases[v] = nil
+	}
+
+	for _, f := range project.Files {
+		if v := fileVersion(name, f.Filename); v != "" {
+			releases[v] = append(releases[v], f)
+		}
+	}
+
+	return releases, nil
+}
+
+// Extract the version from the name of a wheel or source distribution,
+// i.e. requests-2.31.0-py3-none-any.whl or requests-2.31.0.tar.gz
+func fileVersion(project string, filename string) string {
+	if strings.HasSuffix(filename, ".whl") {
+		parts := strings.Split(filename, "-")
+		if len(parts) < 5 {
+			return ""
+		}
+
+		return parts[1]
+	}
+
+	base := path.Base(filename)
+	for _, ext := range []string{".tar.gz", ".tar.bz2", ".zip"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+
+	i := strings.LastIndex(base, "-")
+	if i < 0 || NormalizePyPIName(base[:i]) != NormalizePyPIName(project) {
+		return ""
+	}
+
+	return base[i+1:]
+}
+
+func (p *PyPI) get(rel string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, p.url+"/"+rel, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if p.simple {
+		req.Header.Set("Accept", "application/vnd.pypi.simple.v1+json")
+	}
+
+	if p.username != "" || p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(v)
+	if err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Redacted(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func TestPEP440Ordering(t *testing.T) {
+	// Ascending, as sorted by the packaging library
+	ordered := []string{
+		"1.0.dev0",
+		"1.0a1.dev1",
+		"1.0a1",
+		"1.0b2.post3",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc",
+		"1.0+5",
+		"1.0.post1.dev2",
+		"1.0.post1",
+		"1.1",
+		"1!0.1",
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		a := domain.MustParseVersion(source.PEP440, ordered[i-1])
+		b := domain.MustParseVersion(source.PEP440, ordered[i])
+
+		if !a.LessThan(b) || !b.GreaterThan(a) {
+			t.Errorf("expected %s < %s", a, b)
+		}
+	}
+
+	if !domain.MustParseVersion(source.PEP440, "1.0").Equal(domain.MustParseVersion(source.PEP440, "1.0.0")) {
+		t.Error("expected trailing zeros to be insignificant")
+	}
+
+	v := domain.MustParseVersion(source.PEP440, "1.0RC1")
+	if v.String() != "1.0RC1" || !v.Prerelease() {
+		t.Errorf("expected 1.0RC1 to be kept as published and be a pre-release, got %s", v)
+	}
+
+	for _, in := range []string{"1.0-beta-x", "latest", ""} {
+		if _, err := domain.ParseVersion(source.PEP440, in); err == nil {
+			t.Errorf("expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestPyPISimple(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/zope-interface/" || r.Header.Get("Accept") != "application/vnd.pypi.simple.v1+json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"files": [
+			{"filename": "zope.interface-6.0.tar.gz", "yanked": false},
+			{"filename": "zope.interface-6.1-cp312-cp312-win_amd64.whl", "yanked": "broken wheel"},
+			{"filename": "zope.interface-6.1.tar.gz", "yanked": "broken sdist"},
+			{"filename": "zope.interface-6.2b1.tar.gz", "yanked": false}
+		]}`))
+	}))
+	defer srv.Close()
+
+	index := source.NewPyPI(source.PyPIConfig{URL: srv.URL + "/simple", Simple: true})
+
+	versions, err := index.FetchVersions(domain.Dependency{Name: "zope.interface"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %v", versions)
+	}
+
+	latest, ok := versions.Latest(nil)
+	if !ok || latest.Version.String() != "6.0" {
+		t.Errorf("expected yanked and pre-release versions to be skipped, got %v", latest.Version)
+	}
+}
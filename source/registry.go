@@ -0,0 +1,342 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/geektype/dependy/domain"
+)
+
+const (
+	defaultRegistry = "docker.io"
+
+	// Docker Hub serves its API from a different host than images are named
+	// after
+	dockerHubAPI = "https://registry-1.docker.io"
+)
+
+// Manifest media types accepted when resolving digests. Multi-platform
+// indexes come first so the digest covers every platform of the tag.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Matches the parameters of a WWW-Authenticate challenge
+var challengeParamRe = regexp.MustCompile(`([a-zA-Z]+)="([^"]*)"`)
+
+// Matches the next page of a paginated tag list
+var nextLinkRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+type RegistryConfig struct {
+	URL      string // API base URL, for mirrors or registries not served over HTTPS at their host name
+	Username string
+	Password string // Password or access token
+}
+
+// A reference to an image repository split into the registry hosting it and
+// the repository path within the registry
+type ImageRepository struct {
+	Registry   string // Host name, i.e. docker.io or ghcr.io
+	Repository string // Path of the repository, i.e. library/golang
+}
+
+// Resolve an image name as written in a Dockerfile. Names without a registry
+// host refer to Docker Hub, where official images live under library/.
+func ParseImageRepository(name string) ImageRepository {
+	host, rest, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, rest = defaultRegistry, name
+	}
+
+	if host == defaultRegistry && !strings.Contains(rest, "/") {
+		rest = "library/" + rest
+	}
+
+	return ImageRepository{Registry: host, Repository: rest}
+}
+
+func NewOCIRegistry(registries map[string]RegistryConfig) *OCIRegistry {
+	return &OCIRegistry{
+		registries: registries,
+		tokens:     make(map[string]string),
+		client:     &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for container registries implementing the Docker Registry HTTP API
+// v2, standardised as the OCI distribution specification
+//
+// Tags are parsed with the DockerTag scheme, leaving out tags which do not
+// start with a version. Registries record neither publish times nor
+// licenses.
+type OCIRegistry struct {
+	registries map[string]RegistryConfig // Keyed by registry host
+	mu         sync.Mutex
+	tokens     map[string]string // Bearer tokens by registry host and scope
+	client     *http.Client
+}
+
+func (*OCIRegistry) GetName() string {
+	return "OCIRegistry"
+}
+
+func (r *OCIRegistry) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	tags, err := r.Tags(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(tags))
+
+	for _, tag := range tags {
+		v, err := domain.ParseVersion(DockerTag, tag)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{Version: v, Prerelease: v.Prerelease()})
+	}
+
+	return versions, nil
+}
+
+func (r *OCIRegistry) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	tags, err := r.Tags(dep.Name)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	for _, tag := range tags {
+		if tag == version.String() {
+			return domain.VersionInfo{Version: version, Prerelease: version.Prerelease()}, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("%s:%s not found in registry", dep.Name, version.String())
+}
+
+// List every tag of an image, following pagination
+func (r *OCIRegistry) Tags(name string) ([]string, error) {
+	repo := ParseImageRepository(name)
+	next := "/v2/" + repo.Repository + "/tags/list?n=1000"
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+
+	for next != "" && !seen[next] {
+		seen[next] = true
+
+		resp, err := r.do(http.MethodGet, repo, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(&page)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag list of %s: %w", name, err)
+		}
+
+		tags = append(tags, page.Tags...)
+
+		next = ""
+
+		// Registries may link to the next page by absolute URL
+		if m := nextLinkRe.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			if u, err := url.Parse(m[1]); err == nil {
+				next = u.RequestURI()
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// Get the digest of the manifest a tag points at, i.e. sha256:...
+func (r *OCIRegistry) Digest(name string, tag string) (string, error) {
+	repo := ParseImageRepository(name)
+	header := http.Header{"Accept": {strings.Join(manifestMediaTypes, ", ")}}
+	rel := "/v2/" + repo.Repository + "/manifests/" + url.PathEscape(tag)
+
+	resp, err := r.do(http.MethodHead, repo, rel, header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// The digest header is optional, while the digest of a manifest is
+	// always the hash of its content
+	resp, err = r.do(http.MethodGet, repo, rel, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.LimitReader(resp.Body, maxProxyResponse)); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Send a request to the registry of repo, authenticating when challenged.
+// Responses other than 200 OK are returned as errors.
+func (r *OCIRegistry) do(method string, repo ImageRepository, rel string, header http.Header) (*http.Response, error) {
+	config := r.registries[repo.Registry]
+
+	base := config.URL
+	switch {
+	case base != "":
+	case repo.Registry == defaultRegistry:
+		base = dockerHubAPI
+	default:
+		base = "https://" + repo.Registry
+	}
+
+	target, err := url.Parse(strings.TrimSuffix(base, "/") + rel)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := "repository:" + repo.Repository + ":pull"
+	key := repo.Registry + " " + scope
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(method, target.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		r.mu.Lock()
+		token := r.tokens[key]
+		r.mu.Unlock()
+
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case config.Username != "" || config.Password != "":
+			req.SetBasicAuth(config.Username, config.Password)
+		}
+
+		return r.client.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			return nil, fmt.Errorf("%s %s: %s", method, target.Redacted(), resp.Status)
+		}
+
+		token, err := r.token(challenge, scope, config)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.tokens[key] = token
+		r.mu.Unlock()
+
+		if resp, err = send(); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, target.Redacted(), resp.Status)
+	}
+
+	return resp, nil
+}
+
+// Obtain a bearer token from the authorization service named by a
+// WWW-Authenticate challenge, anonymously unless credentials are configured
+func (r *OCIRegistry) token(challenge string, scope string, config RegistryConfig) (string, error) {
+	params := make(map[string]string)
+	for _, m := range challengeParamRe.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid authentication challenge %q", challenge)
+	}
+
+	if params["scope"] != "" {
+		scope = params["scope"]
+	}
+
+	q := realm.Query()
+	q.Set("scope", scope)
+
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if config.Username != "" || config.Password != "" {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", realm.Redacted(), resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response from %s: %w", realm.Redacted(), err)
+	}
+
+	if body.Token == "" {
+		body.Token = body.AccessToken
+	}
+
+	return body.Token, nil
+}
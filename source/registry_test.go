@@ -0,0 +1,117 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func TestParseImageRepository(t *testing.T) {
+	for name, want := range map[string]source.ImageRepository{
+		"golang":                   {Registry: "docker.io", Repository: "library/golang"},
+		"grafana/grafana":          {Registry: "docker.io", Repository: "grafana/grafana"},
+		"ghcr.io/org/app":          {Registry: "ghcr.io", Repository: "org/app"},
+		"localhost:5000/app":       {Registry: "localhost:5000", Repository: "app"},
+		"cgr.dev/chainguard/wolfi": {Registry: "cgr.dev", Repository: "chainguard/wolfi"},
+	} {
+		if got := source.ParseImageRepository(name); got != want {
+			t.Errorf("ParseImageRepository(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestDockerTagVariants(t *testing.T) {
+	v := func(tag string) domain.Version { return domain.MustParseVersion(source.DockerTag, tag) }
+
+	if !v("1.23.1-alpine").LessThan(v("1.23.10-alpine")) {
+		t.Error("expected tags to be ordered numerically")
+	}
+
+	if !v("3.13.0rc1").Prerelease() || v("1.23-bookworm").Prerelease() {
+		t.Error("expected only release candidates to be pre-releases")
+	}
+
+	for _, tc := range []struct {
+		a, b string
+		same bool
+	}{
+		{"1.23.1", "1.23.2", true},
+		{"1.23.1-alpine", "1.24.0-alpine", true},
+		{"1.23.1", "1.24", false},
+		{"1.23.1", "1.23.2-alpine", false},
+		{"v2.4", "2.5", false},
+	} {
+		if got := source.SameDockerVariant(v(tc.a), v(tc.b)); got != tc.same {
+			t.Errorf("SameDockerVariant(%s, %s) = %t, want %t", tc.a, tc.b, got, tc.same)
+		}
+	}
+
+	if _, err := domain.ParseVersion(source.DockerTag, "latest"); err == nil {
+		t.Error("expected tags without a version to be rejected")
+	}
+}
+
+func TestOCIRegistry(t *testing.T) {
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:library/golang:pull" {
+				http.Error(w, "bad scope", http.StatusForbidden)
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"token": "pull-token"}`))
+
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/library/golang/tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/library/golang/tags/list?last=1.23.1&n=1000>; rel="next"`)
+			_, _ = w.Write([]byte(`{"name": "library/golang", "tags": ["latest", "1.22.7", "1.23.1"]}`))
+		case r.URL.Path == "/v2/library/golang/tags/list":
+			_, _ = w.Write([]byte(`{"name": "library/golang", "tags": ["1.23.2", "1.24rc1"]}`))
+		case r.URL.Path == "/v2/library/golang/manifests/1.23.2":
+			w.Header().Set("Docker-Content-Digest", "sha256:abc")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	registry := source.NewOCIRegistry(map[string]source.RegistryConfig{"docker.io": {URL: server.URL}})
+
+	versions, err := registry.FetchVersions(domain.Dependency{Name: "golang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 4 {
+		t.Fatalf("expected tags of both pages without latest, got %v", versions)
+	}
+
+	latest, ok := versions.Latest(nil)
+	if !ok || latest.Version.String() != "1.23.2" {
+		t.Errorf("expected 1.23.2, got %v", latest.Version)
+	}
+
+	digest, err := registry.Digest("golang", "1.23.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest != "sha256:abc" {
+		t.Errorf("unexpected digest %s", digest)
+	}
+}
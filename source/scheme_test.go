@@ -0,0 +1,173 @@
+package source_test
+
+import (
+	"testing"
+
+	"github.com/geektype/dependy/domain"
+	"github.com/geektype/dependy/source"
+)
+
+func TestSchemeParse(t *testing.T) {
+	tests := []struct {
+		scheme     domain.VersionScheme
+		raw        string
+		valid      bool
+		prerelease bool
+		release    [3]uint64
+	}{
+		{source.GoRelease, "1.21", true, false, [3]uint64{1, 21, 0}},
+		{source.GoRelease, "go1.22.3", true, false, [3]uint64{1, 22, 3}},
+		{source.GoRelease, "1.23rc1", true, true, [3]uint64{1, 23, 0}},
+		{source.GoRelease, "go1.21.0-bigcorp", false, false, [3]uint64{}},
+		{source.PEP440, "1.0", true, false, [3]uint64{1, 0, 0}},
+		{source.PEP440, "2!1.2.3.post4", true, false, [3]uint64{1, 2, 3}},
+		{source.PEP440, "1.0.dev1", true, true, [3]uint64{1, 0, 0}},
+		{source.PEP440, "1.0-beta-x", false, false, [3]uint64{}},
+		{source.Maven, "5.3.30.RELEASE", true, false, [3]uint64{5, 3, 30}},
+		{source.Maven, "1.0-SNAPSHOT", true, true, [3]uint64{1, 0, 0}},
+		{source.Maven, "", false, false, [3]uint64{}},
+		{source.DockerTag, "1.23-alpine", true, false, [3]uint64{1, 23, 0}},
+		{source.DockerTag, "v2.4.1", true, false, [3]uint64{2, 4, 1}},
+		{source.DockerTag, "3.13.0rc1-slim", true, true, [3]uint64{3, 13, 0}},
+		{source.DockerTag, "bookworm", false, false, [3]uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme.GetName()+"/"+tt.raw, func(t *testing.T) {
+			v, err := domain.ParseVersion(tt.scheme, tt.raw)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected %q to be rejected", tt.raw)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.String() != tt.raw {
+				t.Errorf("expected %q to be kept as written, got %q", tt.raw, v.String())
+			}
+
+			if v.Prerelease() != tt.prerelease {
+				t.Errorf("expected prerelease %v, got %v", tt.prerelease, v.Prerelease())
+			}
+
+			if got := [3]uint64{v.Major(), v.Minor(), v.Patch()}; got != tt.release {
+				t.Errorf("expected release %v, got %v", tt.release, got)
+			}
+		})
+	}
+}
+
+func TestSchemeCompare(t *testing.T) {
+	tests := []struct {
+		scheme domain.VersionScheme
+		a, b   string
+		want   int
+	}{
+		{source.GoRelease, "1.21", "go1.21.0", 0},
+		{source.GoRelease, "1.22rc1", "1.22.0", -1},
+		{source.GoRelease, "go1.21.10", "1.21.9", 1},
+		{source.PEP440, "1.0", "1.0.0", 0},
+		{source.PEP440, "1.0.post1", "1.0", 1},
+		{source.PEP440, "1.0rc1", "1.0.dev0", 1},
+		{source.Maven, "1.0", "1-ga", 0},
+		{source.Maven, "1.0-SNAPSHOT", "1.0", -1},
+		{source.Maven, "1.10", "1.9", 1},
+		{source.DockerTag, "1.23.10-alpine", "1.23.9-alpine", 1},
+		{source.DockerTag, "1.23", "1.23.0", -1},
+		{source.DockerTag, "1.23-alpine", "1.23-alpine", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme.GetName()+"/"+tt.a+"/"+tt.b, func(t *testing.T) {
+			a := domain.MustParseVersion(tt.scheme, tt.a)
+			b := domain.MustParseVersion(tt.scheme, tt.b)
+
+			got, err := a.Compare(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+
+			if back, _ := b.Compare(a); back != -tt.want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, back, -tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemeCompareAcrossSchemes(t *testing.T) {
+	tests := []struct {
+		a, b       domain.Version
+		comparable bool
+	}{
+		// Semantic versions are valid Go releases, but Go releases named after
+		// the toolchain are not valid semantic versions
+		{domain.MustParseVersion(source.GoRelease, "1.22.3"), domain.MustParseVersion(domain.Semver, "1.21.0"), true},
+		{domain.MustParseVersion(source.GoRelease, "go1.22.3"), domain.MustParseVersion(domain.Semver, "1.21.0"), false},
+		{domain.MustParseVersion(source.PEP440, "1.0"), domain.MustParseVersion(source.Maven, "1.0-SNAPSHOT"), false},
+		{domain.MustParseVersion(source.Maven, "1.0"), domain.MustParseVersion(source.PEP440, "1.0.post1"), true},
+		{domain.MustParseVersion(source.PEP440, "1.0"), domain.MustParseVersion(source.DockerTag, "1.23-alpine"), false},
+	}
+
+	for _, tt := range tests {
+		c, err := tt.a.Compare(tt.b)
+		if (err == nil) != tt.comparable {
+			t.Errorf("Compare(%s %s, %s %s): expected comparable %v, got %v",
+				tt.a.Scheme().GetName(), tt.a, tt.b.Scheme().GetName(), tt.b, tt.comparable, err)
+		}
+
+		// The order does not depend on which version is compared
+		back, backErr := tt.b.Compare(tt.a)
+		if (backErr == nil) != (err == nil) || back != -c {
+			t.Errorf("Compare(%s, %s) = %d, %v, want %d, %v", tt.b, tt.a, back, backErr, -c, err)
+		}
+
+		if err != nil && (tt.a.LessThan(tt.b) || tt.a.GreaterThan(tt.b) || tt.a.Equal(tt.b)) {
+			t.Errorf("expected %s and %s to be neither lower, higher nor equal", tt.a, tt.b)
+		}
+	}
+}
+
+func TestSchemeConstraint(t *testing.T) {
+	tests := []struct {
+		scheme  domain.VersionScheme
+		expr    string
+		version string
+		want    bool
+	}{
+		{source.GoRelease, "~1.22.0", "go1.22.3", true},
+		{source.GoRelease, "~1.22.0", "go1.23.0", false},
+		{source.GoRelease, ">=1.21", "1.21rc1", false},
+		{source.PEP440, "<2", "1.9", true},
+		// Versions without a semantic version equivalent never satisfy one
+		{source.PEP440, "<2", "1.9.post1", false},
+		{source.PEP440, "^1.2", "1.3", true},
+		{source.PEP440, "^1.2", "2!1.3", false},
+		{source.Maven, "~5.3", "5.3.30", true},
+		{source.Maven, "<6", "6.0.0", false},
+		{source.Maven, ">=1", "5.3.30.RELEASE", false},
+		{source.DockerTag, "~1.23", "1.23.4", true},
+		{source.DockerTag, "~1.23", "1.23.4-alpine", false},
+	}
+
+	for _, tt := range tests {
+		c, err := domain.NewSemverConstraint(tt.expr)
+		if err != nil {
+			t.Fatalf("NewSemverConstraint(%s): %v", tt.expr, err)
+		}
+
+		v := domain.MustParseVersion(tt.scheme, tt.version)
+
+		if got := c.Check(v); got != tt.want {
+			t.Errorf("%s %s satisfies %s: got %v, want %v", tt.scheme.GetName(), tt.version, tt.expr, got, tt.want)
+		}
+	}
+}
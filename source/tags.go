@@ -0,0 +1,99 @@
+package source
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/geektype/dependy/domain"
+)
+
+func NewTagSource(name string, lister domain.TagLister) *TagSource {
+	return &TagSource{name: name, lister: lister, tags: make(map[string][]domain.Tag)}
+}
+
+// Versions of Git repositories taken from their tags
+//
+// Dependencies are named after the path of their repository, i.e.
+// actions/checkout. Tags which are not versions, such as release branches'
+// names, are left out. Tags are listed once per repository.
+type TagSource struct {
+	name   string
+	lister domain.TagLister
+	mu     sync.Mutex
+	tags   map[string][]domain.Tag // Tags already listed by repository
+}
+
+func (t *TagSource) GetName() string {
+	return t.name
+}
+
+func (t *TagSource) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	tags, err := t.list(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(domain.Versions, 0, len(tags))
+
+	for _, tag := range tags {
+		v, err := domain.ParseVersion(domain.Semver, tag.Name)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{Version: v, PublishedAt: tag.Date, Prerelease: v.Prerelease()})
+	}
+
+	return versions, nil
+}
+
+func (t *TagSource) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	versions, err := t.FetchVersions(dep)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	// Tags such as v4 and v4.0.0 are equal as versions but point at
+	// different commits
+	for _, v := range versions {
+		if v.Version.String() == version.String() {
+			return v, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("tag %s of %s not found", version.String(), dep.Name)
+}
+
+// Get the commit a tag of a repository points to
+func (t *TagSource) Commit(project string, tag string) (string, error) {
+	tags, err := t.list(project)
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range tags {
+		if candidate.Name == tag && candidate.Commit != "" {
+			return candidate.Commit, nil
+		}
+	}
+
+	return "", fmt.Errorf("tag %s of %s not found", tag, project)
+}
+
+func (t *TagSource) list(project string) ([]domain.Tag, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tags, ok := t.tags[project]; ok {
+		return tags, nil
+	}
+
+	tags, err := t.lister.ListTags(project)
+	if err != nil {
+		return nil, err
+	}
+
+	t.tags[project] = tags
+
+	return tags, nil
+}
@@ -0,0 +1,376 @@
+package source
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/geektype/dependy/domain"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+const defaultTerraformRegistry = "registry.terraform.io"
+
+// Maximum size of a provider package. Packages of the large cloud providers
+// run to hundreds of megabytes.
+const maxTerraformPackage = 1 << 30
+
+type TerraformRegistryConfig struct {
+	Host  string // Registry of sources without a host, i.e. registry.opentofu.org. Defaults to registry.terraform.io
+	URL   string // Base URL the registry host is reached at, i.e. for a mirror. Defaults to https://<Host>
+	Token string // Bearer token sent to the registry host
+}
+
+// Address of a provider or module in a registry. Providers have no target
+// system.
+type TerraformAddress struct {
+	Host      string
+	Namespace string
+	Name      string
+	System    string // Target system of a module, i.e. aws
+}
+
+func (a TerraformAddress) IsModule() bool {
+	return a.System != ""
+}
+
+// Get the fully qualified address, as recorded in .terraform.lock.hcl
+func (a TerraformAddress) String() string {
+	parts := []string{a.Host, a.Namespace, a.Name}
+	if a.IsModule() {
+		parts = append(parts, a.System)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// A provider package for a single platform
+type TerraformPackage struct {
+	Filename    string `json:"filename"`
+	DownloadURL string `json:"download_url"`
+	Shasum      string `json:"shasum"`
+	ShasumsURL  string `json:"shasums_url"`
+}
+
+// Paths of the registry APIs announced by a host
+type terraformServices struct {
+	Providers string `json:"providers.v1"`
+	Modules   string `json:"modules.v1"`
+}
+
+func NewTerraformRegistry(config TerraformRegistryConfig) *TerraformRegistry {
+	host := config.Host
+	if host == "" {
+		host = defaultTerraformRegistry
+	}
+
+	return &TerraformRegistry{
+		host:     strings.ToLower(host),
+		url:      strings.TrimSuffix(config.URL, "/"),
+		token:    config.Token,
+		services: make(map[string]*url.URL),
+		client:   &http.Client{Timeout: proxyTimeout},
+	}
+}
+
+// Client for registries implementing the Terraform provider and module
+// registry protocols
+//
+// Dependencies are named after their source address, i.e. hashicorp/aws
+// for a provider or terraform-aws-modules/vpc/aws for a module. Registry
+// APIs are located through service discovery.
+type TerraformRegistry struct {
+	host     string
+	url      string
+	token    string
+	mu       sync.Mutex
+	services map[string]*url.URL // Discovered API base URLs by host and service
+	client   *http.Client
+}
+
+func (*TerraformRegistry) GetName() string {
+	return "TerraformRegistry"
+}
+
+// Parse a provider source, [host/]namespace/type, or a registry module
+// source, [host/]namespace/name/system. Sources without a host belong to the
+// configured registry.
+func (r *TerraformRegistry) ParseAddress(src string) (TerraformAddress, error) {
+	parts := strings.Split(strings.ToLower(src), "/")
+
+	host := r.host
+	if (len(parts) == 3 && strings.ContainsAny(parts[0], ".:")) || len(parts) == 4 {
+		host, parts = parts[0], parts[1:]
+	}
+
+	for _, p := range parts {
+		if p == "" {
+			return TerraformAddress{}, fmt.Errorf("invalid registry address %q", src)
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return TerraformAddress{Host: host, Namespace: parts[0], Name: parts[1]}, nil
+	case 3:
+		return TerraformAddress{Host: host, Namespace: parts[0], Name: parts[1], System: parts[2]}, nil
+	default:
+		return TerraformAddress{}, fmt.Errorf("invalid registry address %q", src)
+	}
+}
+
+func (r *TerraformRegistry) FetchVersions(dep domain.Dependency) (domain.Versions, error) {
+	addr, err := r.ParseAddress(dep.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]string, 0)
+
+	if addr.IsModule() {
+		var resp struct {
+			Modules []struct {
+				Versions []struct {
+					Version string `json:"version"`
+				} `json:"versions"`
+			} `json:"modules"`
+		}
+
+		if err := r.get(addr.Host, "modules.v1", addr.Namespace+"/"+addr.Name+"/"+addr.System+"/versions", &resp); err != nil {
+			return nil, err
+		}
+
+		for _, m := range resp.Modules {
+			for _, v := range m.Versions {
+				raw = append(raw, v.Version)
+			}
+		}
+	} else {
+		var resp struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		}
+
+		if err := r.get(addr.Host, "providers.v1", addr.Namespace+"/"+addr.Name+"/versions", &resp); err != nil {
+			return nil, err
+		}
+
+		for _, v := range resp.Versions {
+			raw = append(raw, v.Version)
+		}
+	}
+
+	versions := make(domain.Versions, 0, len(raw))
+
+	for _, s := range raw {
+		v, err := domain.ParseVersion(domain.Semver, s)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, domain.VersionInfo{Version: v, Prerelease: v.Prerelease()})
+	}
+
+	return versions, nil
+}
+
+func (r *TerraformRegistry) FetchVersion(dep domain.Dependency, version domain.Version) (domain.VersionInfo, error) {
+	versions, err := r.FetchVersions(dep)
+	if err != nil {
+		return domain.VersionInfo{}, err
+	}
+
+	for _, v := range versions {
+		if v.Version.Equal(version) {
+			return v, nil
+		}
+	}
+
+	return domain.VersionInfo{}, fmt.Errorf("%s %s not found in registry", dep.Name, version.String())
+}
+
+// Get the package of a provider version for a platform, i.e. linux_amd64
+func (r *TerraformRegistry) ProviderPackage(addr TerraformAddress, version string, platform string) (TerraformPackage, error) {
+	osName, arch, ok := strings.Cut(platform, "_")
+	if !ok {
+		return TerraformPackage{}, fmt.Errorf("invalid platform %q", platform)
+	}
+
+	var pkg TerraformPackage
+
+	rel := addr.Namespace + "/" + addr.Name + "/" + version + "/download/" + osName + "/" + arch
+
+	return pkg, r.get(addr.Host, "providers.v1", rel, &pkg)
+}
+
+// Get the zh: hashes of every package of a provider version, the SHA-256
+// of each package archive as listed by its SHASUMS file
+func (r *TerraformRegistry) PackageHashes(pkg TerraformPackage) ([]string, error) {
+	resp, err := r.download(pkg.ShasumsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	hashes := make([]string, 0)
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxProxyResponse))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.HasSuffix(fields[1], ".zip") {
+			hashes = append(hashes, "zh:"+fields[0])
+		}
+	}
+
+	sort.Strings(hashes)
+
+	return hashes, scanner.Err()
+}
+
+// Get the h1: hash of a provider package, the hash of the files it contains
+// as computed by Terraform once installed. The package is downloaded and
+// checked against its published SHA-256.
+func (r *TerraformRegistry) PackageContentHash(pkg TerraformPackage) (string, error) {
+	resp, err := r.download(pkg.DownloadURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.CreateTemp("", "dependy-provider-*.zip")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, maxTerraformPackage)); err != nil {
+		return "", err
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != pkg.Shasum {
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", pkg.Filename, sum, pkg.Shasum)
+	}
+
+	return dirhash.HashZip(f.Name(), dirhash.Hash1)
+}
+
+func (r *TerraformRegistry) download(rawURL string) (*http.Response, error) {
+	resp, err := r.client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// Get a document from a registry API, located through the service discovery
+// of the host
+func (r *TerraformRegistry) get(host string, service string, rel string, v any) error {
+	base, err := r.service(host, service)
+	if err != nil {
+		return err
+	}
+
+	target := base.JoinPath(strings.Split(rel, "/")...)
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	if r.token != "" && host == r.host {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target.Redacted(), resp.Status)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", target.Redacted(), err)
+	}
+
+	return nil
+}
+
+// Discover the base URL of a service of a host, once per host
+func (r *TerraformRegistry) service(host string, service string) (*url.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.services[host+" "+service]; ok {
+		return u, nil
+	}
+
+	base := "https://" + host
+	if host == r.host && r.url != "" {
+		base = r.url
+	}
+
+	root, err := url.Parse(base + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	discovery := root.JoinPath(".well-known", "terraform.json")
+
+	resp, err := r.client.Get(discovery.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", discovery.Redacted(), resp.Status)
+	}
+
+	var services terraformServices
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProxyResponse)).Decode(&services); err != nil {
+		return nil, fmt.Errorf("invalid service discovery document from %s: %w", host, err)
+	}
+
+	for name, path := range map[string]string{"providers.v1": services.Providers, "modules.v1": services.Modules} {
+		if path == "" {
+			continue
+		}
+
+		u, err := root.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s service of %s: %w", name, host, err)
+		}
+
+		r.services[host+" "+name] = u
+	}
+
+	u, ok := r.services[host+" "+service]
+	if !ok {
+		return nil, fmt.Errorf("%s does not provide the %s service", host, service)
+	}
+
+	return u, nil
+}